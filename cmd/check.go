@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// checkCmd defines the "check" subcommand, which resolves metadata and reports
+// through its exit code whether updates are available, without applying them.
+var checkCmd = &cobra.Command{
+	Use:   "check [ROOT_DIR]",
+	Short: "Exit non-zero when mod updates are available, without applying them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args)
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		updater, err := buildUpdater(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		resolveErr := resolveWithUI(ctx, updater, "Check")
+
+		mods := updater.GetMods()
+		pending := 0
+		for _, mod := range mods {
+			if !awaitsUpdate(mod, cfg.Force) {
+				continue
+			}
+			pending++
+			if !mod.Installed {
+				pterm.Printf("  MISSING   %s (latest: %s)\n", mod.Title, mod.Latest.Version)
+			} else {
+				pterm.Printf("  OUTDATED  %s (%s -> %s)\n", mod.Title, mod.Version, mod.Latest.Version)
+			}
+		}
+		pterm.Printf("Summary: %d of %d mods need updates\n", pending, len(mods))
+
+		code := checkExitCode(mods, cfg.Force, resolveErr)
+		switch code {
+		case ExitOK:
+			return nil
+		case ExitUpdatesAvailable:
+			return &exitCodeError{code: code}
+		default:
+			return &exitCodeError{code: code, err: fmt.Errorf("could not determine update status: %w", resolveErr)}
+		}
+	},
+}
+
+// checkExitCode maps the resolved mod states to the check command's exit code.
+// A resolution error takes precedence, since an incomplete graph cannot prove
+// that everything is current.
+func checkExitCode(mods []*factorio.ModData, force bool, resolveErr error) int {
+	if resolveErr != nil {
+		return exitCodeFor(resolveErr)
+	}
+	if updatesAvailable(mods, force) {
+		return ExitUpdatesAvailable
+	}
+	return ExitOK
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
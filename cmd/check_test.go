@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"factorio-updater/internal/factorio"
+)
+
+func TestCheckExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		mods       []*factorio.ModData
+		force      bool
+		resolveErr error
+		expected   int
+	}{
+		{
+			name:     "no mods is current",
+			expected: ExitOK,
+		},
+		{
+			name: "installed latest is current",
+			mods: []*factorio.ModData{
+				{Name: "helmod", Installed: true, Version: "2.2.12", Latest: &factorio.ModRelease{Version: "2.2.12"}},
+			},
+			expected: ExitOK,
+		},
+		{
+			name: "outdated mod reports updates",
+			mods: []*factorio.ModData{
+				{Name: "helmod", Installed: true, Version: "2.2.11", Latest: &factorio.ModRelease{Version: "2.2.12"}},
+			},
+			expected: ExitUpdatesAvailable,
+		},
+		{
+			name: "missing mod reports updates",
+			mods: []*factorio.ModData{
+				{Name: "helmod", Latest: &factorio.ModRelease{Version: "2.2.12"}},
+			},
+			expected: ExitUpdatesAvailable,
+		},
+		{
+			name: "outdated directory mod is left alone",
+			mods: []*factorio.ModData{
+				{Name: "helmod", Installed: true, Version: "2.2.11", InstallDir: "helmod", Latest: &factorio.ModRelease{Version: "2.2.12"}},
+			},
+			expected: ExitOK,
+		},
+		{
+			name: "outdated directory mod with force reports updates",
+			mods: []*factorio.ModData{
+				{Name: "helmod", Installed: true, Version: "2.2.11", InstallDir: "helmod", Latest: &factorio.ModRelease{Version: "2.2.12"}},
+			},
+			force:    true,
+			expected: ExitUpdatesAvailable,
+		},
+		{
+			name: "unresolved mod alone is current",
+			mods: []*factorio.ModData{
+				{Name: "helmod", Installed: true, Version: "2.2.12"},
+			},
+			expected: ExitOK,
+		},
+		{
+			name: "resolution error wins over pending updates",
+			mods: []*factorio.ModData{
+				{Name: "helmod", Latest: &factorio.ModRelease{Version: "2.2.12"}},
+			},
+			resolveErr: errors.New("status 503"),
+			expected:   ExitError,
+		},
+		{
+			name:       "unresolved mods are a partial failure",
+			resolveErr: &factorio.ResolveError{Errs: []error{&factorio.MetadataError{Mod: "gone", Kind: factorio.MetadataNotFound}}},
+			expected:   ExitPartialFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkExitCode(tt.mods, tt.force, tt.resolveErr); got != tt.expected {
+				t.Errorf("checkExitCode() = %d; want %d", got, tt.expected)
+			}
+		})
+	}
+}
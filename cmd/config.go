@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// fileConfig is the optional persistent configuration stored in
+// config.json. Keys mirror the equivalent command-line flag names.
+type fileConfig struct {
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token,omitempty"`
+	ModPath  string `json:"mod-path,omitempty"`
+	BinPath  string `json:"bin-path,omitempty"`
+}
+
+// configKeys lists the keys accepted by "config set", in display order.
+var configKeys = []string{"username", "token", "mod-path", "bin-path"}
+
+// envPrefix namespaces the environment variables read as a configuration
+// source, e.g. FACTORIO_UPDATER_TOKEN.
+const envPrefix = "FACTORIO_UPDATER_"
+
+// set assigns value to the field named by key.
+func (fc *fileConfig) set(key, value string) error {
+	switch key {
+	case "username":
+		fc.Username = value
+	case "token":
+		fc.Token = value
+	case "mod-path":
+		fc.ModPath = value
+	case "bin-path":
+		fc.BinPath = value
+	default:
+		return fmt.Errorf("unknown config key %q (valid keys: %s)", key, strings.Join(configKeys, ", "))
+	}
+	return nil
+}
+
+// get returns the value stored under key.
+func (fc fileConfig) get(key string) string {
+	switch key {
+	case "username":
+		return fc.Username
+	case "token":
+		return fc.Token
+	case "mod-path":
+		return fc.ModPath
+	case "bin-path":
+		return fc.BinPath
+	}
+	return ""
+}
+
+// configSearchPaths lists the candidate config.json locations in search
+// order: $XDG_CONFIG_HOME/factorio-updater, then ~/.config/factorio-updater.
+// Relative XDG_CONFIG_HOME values are ignored, as the XDG spec requires.
+func configSearchPaths() []string {
+	var paths []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		paths = append(paths, filepath.Join(xdg, "factorio-updater", "config.json"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		fallback := filepath.Join(home, ".config", "factorio-updater", "config.json")
+		if !slices.Contains(paths, fallback) {
+			paths = append(paths, fallback)
+		}
+	}
+	return paths
+}
+
+// defaultConfigPath returns the first existing config file in search order,
+// or the first candidate (where "config set" creates it) if none exists.
+func defaultConfigPath() (string, error) {
+	paths := configSearchPaths()
+	if len(paths) == 0 {
+		return "", errors.New("locating config directory: neither XDG_CONFIG_HOME nor a home directory is available")
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return paths[0], nil
+}
+
+// loadFileConfig reads the config file at path. A missing file yields an
+// empty configuration rather than an error.
+func loadFileConfig(path string) (fileConfig, error) {
+	var fc fileConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fc, nil
+	}
+	if err != nil {
+		return fc, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fc, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// saveFileConfig writes fc to path owner-only, creating parent directories
+// as needed.
+func saveFileConfig(path string, fc fileConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(fc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling config: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyCredentialFiles fills the username and token from --username-file and
+// --token-file unless the plain flags already set them. A file that cannot
+// be read is recorded in cfg.credentialErr.
+func applyCredentialFiles(cfg *CLIConfig, usernameFile, tokenFile string) {
+	files := []struct {
+		flag, path    string
+		value, source *string
+	}{
+		{"--username-file", usernameFile, &cfg.Username, &cfg.UsernameSource},
+		{"--token-file", tokenFile, &cfg.Token, &cfg.TokenSource},
+	}
+	for _, f := range files {
+		if f.path == "" || *f.value != "" {
+			continue
+		}
+		secret, err := readSecretFile(f.path)
+		if err != nil {
+			cfg.credentialErr = errors.Join(cfg.credentialErr, fmt.Errorf("%s: %w", f.flag, err))
+			continue
+		}
+		*f.value, *f.source = secret, f.path
+	}
+}
+
+// readSecretFile reads a credential from path, trimming surrounding
+// whitespace such as the trailing newline of a mounted secret.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading credential file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("credential file %s is empty", path)
+	}
+	return secret, nil
+}
+
+// applyConfigSources fills any setting not given as a flag from the
+// environment, then from the config file, so the precedence is
+// flags > environment > config file. A ROOT_DIR argument counts as explicit
+// for both paths. Credentials from the config file are only kept as
+// fallbacks, which NewUpdater uses after server-settings.json and
+// player-data.json. Where each credential came from is recorded, with
+// filePath naming the config file.
+func applyConfigSources(cfg *CLIConfig, lookupEnv func(string) (string, bool), file fileConfig, filePath string) {
+	fields := map[string]*string{
+		"username": &cfg.Username,
+		"token":    &cfg.Token,
+		"mod-path": &cfg.ModPath,
+		"bin-path": &cfg.FactPath,
+	}
+	sources := map[string]*string{
+		"username": &cfg.UsernameSource,
+		"token":    &cfg.TokenSource,
+	}
+	fallbacks := map[string]*string{
+		"username": &cfg.FallbackUsername,
+		"token":    &cfg.FallbackToken,
+	}
+	setSource := func(key, source string) {
+		if dst := sources[key]; dst != nil {
+			*dst = source
+		}
+	}
+	for _, key := range configKeys {
+		dst := fields[key]
+		if *dst != "" {
+			continue
+		}
+		if cfg.RootDir != "" && (key == "mod-path" || key == "bin-path") {
+			continue
+		}
+		envName := envPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if v, ok := lookupEnv(envName); ok && v != "" {
+			*dst = v
+			setSource(key, "$"+envName)
+			continue
+		}
+		if fallback := fallbacks[key]; fallback != nil {
+			*fallback = file.get(key)
+			continue
+		}
+		*dst = file.get(key)
+	}
+	if cfg.FallbackUsername != "" || cfg.FallbackToken != "" {
+		cfg.FallbackSource = filePath
+	}
+}
+
+// loadConfigSources applies the environment and the default config file to
+// cfg, warning instead of failing when the file cannot be read.
+func loadConfigSources(cfg *CLIConfig) {
+	var file fileConfig
+	path, err := defaultConfigPath()
+	if err == nil {
+		if file, err = loadFileConfig(path); err != nil {
+			pterm.Warning.Println(err)
+		}
+	}
+	applyConfigSources(cfg, os.LookupEnv, file, path)
+}
+
+// configCmd groups the subcommands managing the persistent config file.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the persistent configuration file",
+}
+
+// configSetCmd stores a single key in the config file.
+var configSetCmd = &cobra.Command{
+	Use:       "set KEY VALUE",
+	Short:     "Store a default (username, token, mod-path, bin-path) in the config file",
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: configKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+
+		path, err := defaultConfigPath()
+		if err != nil {
+			return err
+		}
+		fc, err := loadFileConfig(path)
+		if err != nil {
+			return err
+		}
+		if err := fc.set(key, value); err != nil {
+			return err
+		}
+		if err := saveFileConfig(path, fc); err != nil {
+			return err
+		}
+		pterm.Success.Printf("Saved %s to %s\n", key, path)
+		return nil
+	},
+}
+
+// configShowCmd prints the configuration a run would actually use. It
+// builds no Updater, so everything that would stop one is shown inline.
+var configShowCmd = &cobra.Command{
+	Use:   "show [ROOT_DIR]",
+	Short: "Print the resolved paths, Factorio version, credential sources, and network settings, then exit",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args)
+		factPath, modPath, pathErr := resolvePaths(cfg)
+		if pathErr != nil {
+			factPath, modPath = cfg.FactPath, cfg.ModPath
+		}
+		insp := factorio.InspectConfig(factorio.Options{
+			SettingsPath:     cfg.SettingsPath,
+			DataPath:         cfg.DataPath,
+			ModPath:          modPath,
+			FactPath:         factPath,
+			Username:         cfg.Username,
+			Token:            cfg.Token,
+			UsernameSource:   cfg.UsernameSource,
+			TokenSource:      cfg.TokenSource,
+			FallbackUsername: cfg.FallbackUsername,
+			FallbackToken:    cfg.FallbackToken,
+			FallbackSource:   cfg.FallbackSource,
+			FactorioVersion:  cfg.FactorioVersion,
+			Mirror:           cfg.Mirror,
+			LogLevel:         factorio.LogQuiet,
+		})
+		configPath, _ := defaultConfigPath()
+		for _, line := range configShowLines(cfg, configPath, pathErr, insp) {
+			pterm.Println(line)
+		}
+		return nil
+	},
+}
+
+// configShowLines renders the inspected configuration as aligned
+// "key: value" lines, with each failure after the value it affects. The
+// token only ever appears masked, and a config file that does not exist is
+// marked so, since none of its values apply.
+func configShowLines(cfg CLIConfig, configPath string, pathErr error, insp factorio.ConfigInspection) []string {
+	orNone := func(s string) string {
+		if s == "" {
+			return "(none)"
+		}
+		return s
+	}
+	from := func(value, source string) string {
+		if source == "" {
+			return orNone(value)
+		}
+		return fmt.Sprintf("%s (from %s)", value, source)
+	}
+	failed := func(value string, err error) string {
+		if err == nil {
+			return value
+		}
+		return fmt.Sprintf("%s (error: %v)", value, err)
+	}
+	overall := "none"
+	if cfg.TimeoutOverall > 0 {
+		overall = cfg.TimeoutOverall.String()
+	}
+	configFile := orNone(configPath)
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			configFile = configPath + " (not found)"
+		} else if _, err := loadFileConfig(configPath); err != nil {
+			configFile = failed(configPath, err)
+		}
+	}
+	eff := insp.EffectiveConfig
+	modPathErr := pathErr
+	if modPathErr == nil {
+		modPathErr = factorio.ValidateModPath(eff.ModPath)
+	}
+	credentialErr := errors.Join(cfg.credentialErr, insp.CredentialErr)
+	download := "mod portal"
+	if eff.MirrorURL != "" {
+		download = eff.MirrorURL
+	}
+
+	rows := [][2]string{
+		{"config file", configFile},
+		{"mod path", failed(orNone(eff.ModPath), modPathErr)},
+		{"bin path", failed(orNone(eff.FactPath), pathErr)},
+		{"factorio version", failed(orNone(eff.FactorioVersion), insp.VersionErr)},
+		{"server settings", orNone(eff.SettingsPath)},
+		{"player data", orNone(eff.DataPath)},
+		{"username", failed(from(eff.Username, eff.UsernameSource), credentialErr)},
+		{"token", failed(from(eff.MaskedToken, eff.TokenSource), credentialErr)},
+		{"server url", eff.ServerURL},
+		{"downloads from", failed(download, insp.MirrorErr)},
+		{"metadata workers", fmt.Sprint(eff.MetadataWorkers)},
+		{"download workers", fmt.Sprint(eff.DownloadWorkers)},
+		{"hash workers", fmt.Sprint(eff.HashWorkers)},
+		{"metadata timeout", eff.MetadataTimeout.String()},
+		{"download timeout", eff.DownloadTimeout.String()},
+		{"overall timeout", overall},
+	}
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, fmt.Sprintf("%-17s %s", row[0]+":", row[1]))
+	}
+	return lines
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
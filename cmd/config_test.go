@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"factorio-updater/internal/factorio"
+)
+
+func TestApplyConfigSourcesPrecedence(t *testing.T) {
+	file := fileConfig{Username: "file-user", Token: "file-token", ModPath: "/file/mods", BinPath: "/file/bin"}
+	env := map[string]string{
+		"FACTORIO_UPDATER_TOKEN":    "env-token",
+		"FACTORIO_UPDATER_MOD_PATH": "/env/mods",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	t.Run("flags beat environment beat file", func(t *testing.T) {
+		cfg := CLIConfig{ModPath: "/flag/mods"}
+		applyConfigSources(&cfg, lookup, file, "/home/u/config.json")
+
+		want := CLIConfig{Token: "env-token", ModPath: "/flag/mods", FactPath: "/file/bin", TokenSource: "$FACTORIO_UPDATER_TOKEN",
+			FallbackUsername: "file-user", FallbackSource: "/home/u/config.json"}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("cfg = %+v; want %+v", cfg, want)
+		}
+	})
+
+	t.Run("root dir suppresses configured paths", func(t *testing.T) {
+		cfg := CLIConfig{RootDir: "/opt/factorio"}
+		applyConfigSources(&cfg, lookup, file, "/home/u/config.json")
+
+		if cfg.ModPath != "" || cfg.FactPath != "" {
+			t.Errorf("paths = %q, %q; want both empty so ROOT_DIR inference applies", cfg.ModPath, cfg.FactPath)
+		}
+		if cfg.Token != "env-token" {
+			t.Errorf("Token = %q; want env-token", cfg.Token)
+		}
+	})
+
+	t.Run("empty sources leave credentials for server settings", func(t *testing.T) {
+		cfg := CLIConfig{}
+		applyConfigSources(&cfg, func(string) (string, bool) { return "", false }, fileConfig{}, "/home/u/config.json")
+		if cfg.Username != "" || cfg.Token != "" {
+			t.Errorf("credentials = %q, %q; want empty", cfg.Username, cfg.Token)
+		}
+		if cfg.UsernameSource != "" || cfg.TokenSource != "" {
+			t.Errorf("sources = %q, %q; want empty for unset credentials", cfg.UsernameSource, cfg.TokenSource)
+		}
+	})
+
+	t.Run("flag credentials keep their source", func(t *testing.T) {
+		cfg := CLIConfig{Username: "flag-user", UsernameSource: "the --username flag"}
+		applyConfigSources(&cfg, lookup, file, "/home/u/config.json")
+		if cfg.UsernameSource != "the --username flag" || cfg.TokenSource != "$FACTORIO_UPDATER_TOKEN" {
+			t.Errorf("sources = %q, %q; want the flag and the environment", cfg.UsernameSource, cfg.TokenSource)
+		}
+	})
+}
+
+func TestApplyCredentialFiles(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	_ = os.WriteFile(tokenFile, []byte("  secret-token\n"), 0600)
+	emptyFile := filepath.Join(dir, "empty")
+	_ = os.WriteFile(emptyFile, []byte("\n"), 0600)
+
+	t.Run("reads and trims the token", func(t *testing.T) {
+		cfg := CLIConfig{}
+		applyCredentialFiles(&cfg, "", tokenFile)
+		if cfg.Token != "secret-token" || cfg.TokenSource != tokenFile || cfg.credentialErr != nil {
+			t.Errorf("token = %q from %q, err %v; want secret-token from %s", cfg.Token, cfg.TokenSource, cfg.credentialErr, tokenFile)
+		}
+
+		// The file outranks the environment and the config file.
+		applyConfigSources(&cfg, func(string) (string, bool) { return "env-token", true }, fileConfig{Token: "file-token"}, "/home/u/config.json")
+		if cfg.Token != "secret-token" {
+			t.Errorf("Token after applyConfigSources = %q; want the token file to win", cfg.Token)
+		}
+	})
+
+	t.Run("explicit --token wins", func(t *testing.T) {
+		cfg := CLIConfig{Token: "flag-token", TokenSource: "the --token flag"}
+		applyCredentialFiles(&cfg, "", tokenFile)
+		if cfg.Token != "flag-token" || cfg.TokenSource != "the --token flag" {
+			t.Errorf("token = %q from %q; want the flag value", cfg.Token, cfg.TokenSource)
+		}
+	})
+
+	t.Run("unreadable files are reported", func(t *testing.T) {
+		cfg := CLIConfig{}
+		applyCredentialFiles(&cfg, filepath.Join(dir, "missing"), emptyFile)
+		if cfg.Username != "" || cfg.Token != "" {
+			t.Errorf("credentials = %q, %q; want none set", cfg.Username, cfg.Token)
+		}
+		for _, want := range []string{"--username-file", "--token-file", "is empty"} {
+			if cfg.credentialErr == nil || !strings.Contains(cfg.credentialErr.Error(), want) {
+				t.Errorf("credentialErr = %v; want it to mention %q", cfg.credentialErr, want)
+			}
+		}
+		if _, err := buildUpdater(t.Context(), cfg); err != cfg.credentialErr {
+			t.Errorf("buildUpdater() error = %v; want the credential file error", err)
+		}
+	})
+}
+
+func TestFileConfigRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "factorio-updater", "config.json")
+
+	missing, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig() on a missing file returned error: %v", err)
+	}
+	if missing != (fileConfig{}) {
+		t.Errorf("missing config = %+v; want zero value", missing)
+	}
+
+	fc := fileConfig{}
+	if err := fc.set("token", "secret"); err != nil {
+		t.Fatalf("set() returned unexpected error: %v", err)
+	}
+	if err := fc.set("bogus", "x"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+	if err := saveFileConfig(path, fc); err != nil {
+		t.Fatalf("saveFileConfig() returned unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat config: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config permissions = %o; want 600", perm)
+	}
+
+	loaded, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig() returned unexpected error: %v", err)
+	}
+	if loaded.Token != "secret" {
+		t.Errorf("Token = %q; want secret", loaded.Token)
+	}
+}
+
+func TestDefaultConfigPathXDG(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	xdgPath := filepath.Join(xdg, "factorio-updater", "config.json")
+	homePath := filepath.Join(home, ".config", "factorio-updater", "config.json")
+	writeConfig := func(path string) {
+		_ = os.MkdirAll(filepath.Dir(path), 0700)
+		_ = os.WriteFile(path, []byte(`{"username": "someone"}`), 0600)
+	}
+
+	t.Run("XDG_CONFIG_HOME is preferred when nothing exists", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+		if got, _ := defaultConfigPath(); got != xdgPath {
+			t.Errorf("defaultConfigPath() = %q; want %q", got, xdgPath)
+		}
+	})
+
+	t.Run("falls back to an existing ~/.config file", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+		writeConfig(homePath)
+		if got, _ := defaultConfigPath(); got != homePath {
+			t.Errorf("defaultConfigPath() = %q; want %q", got, homePath)
+		}
+	})
+
+	t.Run("config in XDG_CONFIG_HOME is discovered first", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+		writeConfig(xdgPath)
+		got, _ := defaultConfigPath()
+		if got != xdgPath {
+			t.Fatalf("defaultConfigPath() = %q; want %q", got, xdgPath)
+		}
+		fc, err := loadFileConfig(got)
+		if err != nil || fc.Username != "someone" {
+			t.Errorf("loadFileConfig() = %+v, %v; want username someone", fc, err)
+		}
+	})
+
+	t.Run("unset or relative XDG_CONFIG_HOME uses ~/.config", func(t *testing.T) {
+		for _, value := range []string{"", "relative/dir"} {
+			t.Setenv("XDG_CONFIG_HOME", value)
+			if got, _ := defaultConfigPath(); got != homePath {
+				t.Errorf("XDG_CONFIG_HOME=%q: defaultConfigPath() = %q; want %q", value, got, homePath)
+			}
+		}
+	})
+}
+
+func TestConfigShowLinesMasksToken(t *testing.T) {
+	modDir := filepath.Join(t.TempDir(), "mods")
+	_ = os.MkdirAll(modDir, 0755)
+	const token = "0123456789abcdef0123456789abcdef"
+	insp := factorio.InspectConfig(factorio.Options{ModPath: modDir, Username: "alice", Token: token,
+		TokenSource: "$FACTORIO_UPDATER_TOKEN", FactorioVersion: "2.0", LogLevel: factorio.LogQuiet})
+
+	lines := configShowLines(CLIConfig{TimeoutOverall: time.Minute}, "/home/u/config.json", nil, insp)
+	out := strings.Join(lines, "\n")
+	if strings.Contains(out, token) || strings.Contains(out, token[:8]) {
+		t.Errorf("config show output contains the token:\n%s", out)
+	}
+	for _, want := range []string{
+		"config file:      /home/u/config.json (not found)",
+		"token:            ****************************cdef (from $FACTORIO_UPDATER_TOKEN)",
+		"mod path:         " + modDir,
+		"factorio version: 2.0",
+		"overall timeout:  1m0s",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("config show output is missing %q:\n%s", want, out)
+		}
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	_ = os.WriteFile(configPath, []byte(`{}`), 0600)
+	if lines := configShowLines(CLIConfig{}, configPath, nil, insp); !slices.Contains(lines, "config file:      "+configPath) {
+		t.Errorf("config show output is missing the existing config file %s:\n%s", configPath, strings.Join(lines, "\n"))
+	}
+}
+
+func TestConfigShowLinesReportsFailuresInline(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "config.json")
+	_ = os.WriteFile(configPath, []byte(`{not json`), 0600)
+	settingsPath := filepath.Join(root, "server-settings.json")
+	_ = os.WriteFile(settingsPath, []byte(`{not json`), 0600)
+	pathErr := errors.New("must specify either a ROOT_DIR positional argument, or both --bin-path and --mod-path")
+
+	insp := factorio.InspectConfig(factorio.Options{SettingsPath: settingsPath, FactorioVersion: "two",
+		LogLevel: factorio.LogQuiet})
+	lines := configShowLines(CLIConfig{}, configPath, pathErr, insp)
+	out := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"config file:      " + configPath + " (error: ",
+		"mod path:         (none) (error: " + pathErr.Error() + ")",
+		"factorio version: (none) (error: invalid factorio version \"two\"",
+		"token:            (none) (error: parsing config " + settingsPath,
+	} {
+		found := false
+		for _, line := range lines {
+			found = found || strings.HasPrefix(line, want)
+		}
+		if !found {
+			t.Errorf("config show output has no line starting %q:\n%s", want, out)
+		}
+	}
+}
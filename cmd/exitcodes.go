@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"net"
+
+	"factorio-updater/internal/factorio"
+)
+
+// Process exit codes, spaced apart so scripts can branch on the reason a run
+// did not succeed. They are part of the CLI's public contract.
+const (
+	// ExitOK means the run succeeded and, for check, everything is current.
+	ExitOK = 0
+	// ExitError is any failure not covered by a more specific code.
+	ExitError = 1
+	// ExitUpdatesAvailable is returned by check when mods need updating.
+	ExitUpdatesAvailable = 10
+	// ExitAuthError means the mod portal rejected the username/token.
+	ExitAuthError = 20
+	// ExitNetworkError means the mod portal could not be reached.
+	ExitNetworkError = 30
+	// ExitPartialFailure means the run finished but some mods failed to
+	// resolve or update.
+	ExitPartialFailure = 40
+)
+
+// partialFailureError marks an update that ran to completion while some
+// mods failed, as opposed to one that could not start.
+type partialFailureError struct {
+	err error
+}
+
+func (e *partialFailureError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialFailureError) Unwrap() error {
+	return e.err
+}
+
+// exitCodeFor maps a command error to the process exit code. An explicit
+// exitCodeError wins; otherwise credential problems rank above network
+// problems, which rank above partial failures, so the most actionable cause
+// is reported when several are joined together.
+func exitCodeFor(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	if errors.Is(err, factorio.ErrInvalidCredentials) {
+		return ExitAuthError
+	}
+	// Checked before the network test: a deadline error also satisfies net.Error.
+	if errors.Is(err, errOverallTimeout) {
+		return ExitPartialFailure
+	}
+	if isNetworkError(err) {
+		return ExitNetworkError
+	}
+
+	var partialErr *partialFailureError
+	var resolveErr *factorio.ResolveError
+	if errors.As(err, &partialErr) || errors.As(err, &resolveErr) {
+		return ExitPartialFailure
+	}
+	return ExitError
+}
+
+// isNetworkError reports whether err stems from the transport rather than a
+// portal response: a net.Error from a download, or a metadata request that
+// never got an answer.
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var resolveErr *factorio.ResolveError
+	if errors.As(err, &resolveErr) {
+		for _, group := range resolveErr.Groups() {
+			if group.Kind == factorio.MetadataNetwork {
+				return true
+			}
+		}
+	}
+	var metaErr *factorio.MetadataError
+	return errors.As(err, &metaErr) && metaErr.Kind == factorio.MetadataNetwork
+}
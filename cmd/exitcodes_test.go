@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"factorio-updater/internal/factorio"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil is ok", nil, ExitOK},
+		{"generic error", errors.New("boom"), ExitError},
+		{"explicit exit code", &exitCodeError{code: ExitUpdatesAvailable}, ExitUpdatesAvailable},
+		{"invalid credentials", fmt.Errorf("mod portal token check: %w", factorio.ErrInvalidCredentials), ExitAuthError},
+		{"download network error", fmt.Errorf("executing download: %w", dialErr), ExitNetworkError},
+		{
+			"metadata network error",
+			&factorio.ResolveError{Errs: []error{
+				&factorio.MetadataError{Mod: "gone", Kind: factorio.MetadataNotFound},
+				&factorio.MetadataError{Mod: "flaky", Kind: factorio.MetadataNetwork, Err: errors.New("timeout")},
+			}},
+			ExitNetworkError,
+		},
+		{
+			"unresolved mods",
+			&factorio.ResolveError{Errs: []error{&factorio.MetadataError{Mod: "gone", Kind: factorio.MetadataNotFound}}},
+			ExitPartialFailure,
+		},
+		{"overall timeout", fmt.Errorf("stopped before downloading 2 mod(s): %w", errOverallTimeout), ExitPartialFailure},
+		{"failed downloads", &partialFailureError{err: errors.New("failed to complete update: status 500")}, ExitPartialFailure},
+		{
+			"credentials outrank partial failure",
+			&partialFailureError{err: fmt.Errorf("failed to complete update: %w", errors.Join(
+				errors.New("status 500"),
+				fmt.Errorf("download returned status 403: %w", factorio.ErrInvalidCredentials),
+			))},
+			ExitAuthError,
+		},
+		{
+			"network outranks partial failure",
+			&partialFailureError{err: fmt.Errorf("failed to complete update: %w", fmt.Errorf("executing download: %w", dialErr))},
+			ExitNetworkError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeFor(tt.err); got != tt.expected {
+				t.Errorf("exitCodeFor(%v) = %d; want %d", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
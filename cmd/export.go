@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd defines the "export" subcommand, which snapshots the enabled mods
+// and their installed versions as a manifest consumable by "sync".
+var exportCmd = &cobra.Command{
+	Use:   "export [ROOT_DIR]",
+	Short: "Write the enabled mods and installed versions as a modpack manifest",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args)
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		outPath, _ := cmd.Flags().GetString("output")
+
+		updater, err := buildUpdater(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		manifest := updater.ExportManifest()
+		bytes, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshalling manifest: %w", err)
+		}
+		bytes = append(bytes, '\n')
+
+		if outPath == "" {
+			_, err := os.Stdout.Write(bytes)
+			return err
+		}
+
+		if err := os.WriteFile(outPath, bytes, 0600); err != nil {
+			return fmt.Errorf("writing manifest: %w", err)
+		}
+		pterm.Success.Printf("Exported %d mods to %s\n", len(manifest.Mods), outPath)
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringP("output", "o", "", "File to write the manifest to (defaults to stdout)")
+	rootCmd.AddCommand(exportCmd)
+}
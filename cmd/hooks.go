@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"factorio-updater/internal/factorio"
+)
+
+// hookPayload is the JSON document handed to post-update hooks and webhooks.
+// The embedded UpdateResult contributes the per-mod "updated" list.
+type hookPayload struct {
+	UpdatedCount int    `json:"updated_count"`
+	Summary      string `json:"summary"`
+	factorio.UpdateResult
+}
+
+// runPostUpdateHook executes the user's hook command through the platform
+// shell, with the update count and summary in FACTORIO_UPDATER_COUNT and
+// FACTORIO_UPDATER_SUMMARY and the full JSON payload on stdin.
+func runPostUpdateHook(command string, payload hookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshalling hook payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"FACTORIO_UPDATER_COUNT="+strconv.Itoa(payload.UpdatedCount),
+		"FACTORIO_UPDATER_SUMMARY="+payload.Summary,
+	)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running post-update hook %q: %w", command, err)
+	}
+	return nil
+}
+
+// postWebhook delivers the JSON payload to webhookURL via HTTP POST, treating
+// any non-2xx response as a failure.
+func postWebhook(webhookURL string, payload hookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshalling webhook payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
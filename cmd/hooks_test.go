@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+)
+
+func testHookPayload() hookPayload {
+	return hookPayload{
+		UpdatedCount: 2,
+		Summary:      "Update complete! Successfully updated 2 mod(s).",
+		UpdateResult: factorio.UpdateResult{
+			Updated: []factorio.UpdatedMod{
+				{Name: "helmod", Title: "Helmod", FromVersion: "2.2.11", ToVersion: "2.2.12"},
+				{Name: "jetpack", Title: "Jetpack", ToVersion: "0.4.15"},
+			},
+		},
+	}
+}
+
+func TestRunPostUpdateHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook contract test relies on a POSIX shell")
+	}
+
+	tmpDir := t.TempDir()
+	envFile := filepath.Join(tmpDir, "env.txt")
+	stdinFile := filepath.Join(tmpDir, "stdin.json")
+	command := `printf '%s|%s' "$FACTORIO_UPDATER_COUNT" "$FACTORIO_UPDATER_SUMMARY" > ` + envFile + ` && cat > ` + stdinFile
+
+	payload := testHookPayload()
+	if err := runPostUpdateHook(command, payload); err != nil {
+		t.Fatalf("runPostUpdateHook() returned unexpected error: %v", err)
+	}
+
+	env, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatalf("hook did not write env file: %v", err)
+	}
+	if want := "2|" + payload.Summary; string(env) != want {
+		t.Errorf("hook env = %q; want %q", env, want)
+	}
+
+	stdin, err := os.ReadFile(stdinFile)
+	if err != nil {
+		t.Fatalf("hook did not write stdin file: %v", err)
+	}
+	var got hookPayload
+	if err := json.Unmarshal(stdin, &got); err != nil {
+		t.Fatalf("hook stdin is not valid JSON: %v", err)
+	}
+	if got.UpdatedCount != 2 || len(got.Updated) != 2 || got.Updated[0].Name != "helmod" {
+		t.Errorf("hook stdin payload = %+v; want the original payload", got)
+	}
+
+	t.Run("failing command returns error", func(t *testing.T) {
+		if err := runPostUpdateHook("exit 3", payload); err == nil {
+			t.Error("expected an error for a non-zero hook exit status")
+		}
+	})
+}
+
+func TestPostWebhook(t *testing.T) {
+	t.Run("posts the expected payload shape", func(t *testing.T) {
+		var body []byte
+		var contentType string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType = r.Header.Get("Content-Type")
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		if err := postWebhook(server.URL, testHookPayload()); err != nil {
+			t.Fatalf("postWebhook() returned unexpected error: %v", err)
+		}
+
+		if contentType != "application/json" {
+			t.Errorf("Content-Type = %q; want application/json", contentType)
+		}
+
+		var got map[string]any
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("webhook body is not valid JSON: %v", err)
+		}
+		for _, key := range []string{"updated_count", "summary", "updated"} {
+			if _, ok := got[key]; !ok {
+				t.Errorf("webhook payload missing key %q: %s", key, body)
+			}
+		}
+		updated, _ := got["updated"].([]any)
+		if len(updated) != 2 {
+			t.Fatalf("updated = %v; want 2 entries", got["updated"])
+		}
+		first, _ := updated[0].(map[string]any)
+		if first["name"] != "helmod" || first["from_version"] != "2.2.11" || first["to_version"] != "2.2.12" {
+			t.Errorf("first updated entry = %v; want helmod 2.2.11 -> 2.2.12", first)
+		}
+		second, _ := updated[1].(map[string]any)
+		if _, ok := second["from_version"]; ok {
+			t.Errorf("new installs should omit from_version, got %v", second)
+		}
+	})
+
+	t.Run("non-2xx status is an error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := postWebhook(server.URL, testHookPayload())
+		if err == nil || !strings.Contains(err.Error(), "502") {
+			t.Errorf("postWebhook() error = %v; want status 502 error", err)
+		}
+	})
+}
+
+func TestHooksSkippedOnPartialFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook contract test relies on a POSIX shell")
+	}
+	oldRaw := pterm.RawOutput
+	pterm.RawOutput = true
+	t.Cleanup(func() { pterm.RawOutput = oldRaw })
+
+	for _, tt := range []struct {
+		name      string
+		flibFails bool
+		wantHooks bool
+	}{
+		{"clean update runs hooks", false, true},
+		{"partial failure skips hooks", true, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			modDir := filepath.Join(root, "mods")
+			_ = os.MkdirAll(modDir, 0o755)
+			_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods": [
+				{"name": "flib", "enabled": true},
+				{"name": "helmod", "enabled": true}
+			]}`), 0644)
+			_ = os.WriteFile(filepath.Join(modDir, "flib_0.16.1.zip"), []byte("flib 0.16.1"), 0644)
+			_ = os.WriteFile(filepath.Join(modDir, "helmod_2.2.11.zip"), []byte("helmod 2.2.11"), 0644)
+
+			stub := portalStub{}
+			for _, rel := range [][2]string{{"flib", "0.16.2"}, {"helmod", "2.2.12"}} {
+				name, version := rel[0], rel[1]
+				content := []byte(name + " " + version)
+				sum := sha1.Sum(content)
+				stub["/api/mods/"+name+"/full"] = fmt.Appendf(nil, `{"title": %[1]q, "releases": [{"download_url": "/download/%[1]s/%[2]s",
+					"file_name": "%[1]s_%[2]s.zip", "info_json": {"factorio_version": "2.0"}, "sha1": %[3]q, "version": %[2]q}]}`,
+					name, version, hex.EncodeToString(sum[:]))
+				if name != "flib" || !tt.flibFails {
+					stub["/download/"+name+"/"+version] = content
+				}
+			}
+
+			var webhooks atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				webhooks.Add(1)
+			}))
+			defer server.Close()
+			marker := filepath.Join(t.TempDir(), "hook-ran")
+
+			cfg := CLIConfig{
+				Username: "user", Token: "token", RootDir: root, FactorioVersion: "2.0", KeepVersions: 1,
+				Yes: true, NoFsync: true, PostUpdateHook: "touch " + marker, WebhookURL: server.URL,
+				httpClient: &http.Client{Transport: stub},
+			}
+			err := runUpdateFlow(context.Background(), cfg)
+			if (err != nil) != tt.flibFails {
+				t.Fatalf("runUpdateFlow() error = %v; want failure %v", err, tt.flibFails)
+			}
+			if _, statErr := os.Stat(marker); (statErr == nil) != tt.wantHooks {
+				t.Errorf("post-update hook ran = %v; want %v", statErr == nil, tt.wantHooks)
+			}
+			if got := webhooks.Load() > 0; got != tt.wantHooks {
+				t.Errorf("webhook delivered = %v; want %v", got, tt.wantHooks)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+)
+
+// installResult records the outcome of the update flow for one installation.
+type installResult struct {
+	RootDir string
+	Err     error
+}
+
+// installConfigs derives one config per root directory from the shared
+// flags, so every installation resolves its own binary, mods directory, and
+// Factorio version while reusing one HTTP client. Explicit --bin-path,
+// --mod-path, and --mod-list-path would point every installation at the same
+// place and are rejected, as is a single --json-summary-file every
+// installation would overwrite.
+func installConfigs(cfg CLIConfig, roots []string) ([]CLIConfig, error) {
+	if len(roots) > 1 && (cfg.FactPath != "" || cfg.ModPath != "" || cfg.ModListPath != "") {
+		return nil, fmt.Errorf("--bin-path, --mod-path, and --mod-list-path cannot be combined with multiple ROOT_DIR arguments")
+	}
+	if len(roots) > 1 && cfg.JSONSummaryFile != "" {
+		return nil, fmt.Errorf("--json-summary-file cannot be combined with multiple ROOT_DIR arguments")
+	}
+
+	if cfg.httpClient == nil {
+		client, err := factorio.NewHTTPClient(transportOptions(cfg))
+		if err != nil {
+			return nil, fmt.Errorf("configuring HTTP client: %w", err)
+		}
+		cfg.httpClient = client
+	}
+	configs := make([]CLIConfig, 0, len(roots))
+	for _, root := range roots {
+		instCfg := cfg
+		instCfg.RootDir = root
+		configs = append(configs, instCfg)
+	}
+	return configs, nil
+}
+
+// forEachInstall runs fn for every installation in order, continuing past
+// failures so one broken server does not hold back the others.
+func forEachInstall(configs []CLIConfig, fn func(CLIConfig) error) []installResult {
+	results := make([]installResult, 0, len(configs))
+	for _, instCfg := range configs {
+		results = append(results, installResult{RootDir: instCfg.RootDir, Err: fn(instCfg)})
+	}
+	return results
+}
+
+// runMultiInstallFlow runs the update flow against each root directory with
+// its own Updater and prints a combined summary. All installations share the
+// --timeout-overall budget in ctx. The returned error joins every failed
+// installation's error so the exit code reflects the worst one.
+func runMultiInstallFlow(ctx context.Context, cfg CLIConfig, roots []string) error {
+	configs, err := installConfigs(cfg, roots)
+	if err != nil {
+		return err
+	}
+
+	results := forEachInstall(configs, func(instCfg CLIConfig) error {
+		if !pterm.RawOutput {
+			pterm.DefaultSection.Println(instCfg.RootDir)
+		} else {
+			pterm.Printf("== %s ==\n", instCfg.RootDir)
+		}
+		return runUpdateFlow(ctx, instCfg)
+	})
+
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.RootDir, res.Err))
+		}
+	}
+
+	pterm.Println()
+	printSummary(fmt.Sprintf("Summary: %d installations | %d succeeded | %d failed", len(results), len(results)-len(errs), len(errs)))
+	for _, res := range results {
+		status := "ok"
+		if res.Err != nil {
+			status = "failed"
+		}
+		pterm.Printf("  %s: %s\n", res.RootDir, status)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"factorio-updater/internal/factorio"
+)
+
+func TestMultiInstallDispatch(t *testing.T) {
+	var roots []string
+	for _, name := range []string{"vanilla", "space-age"} {
+		root := filepath.Join(t.TempDir(), name)
+		_ = os.MkdirAll(filepath.Join(root, "mods"), 0o755)
+		roots = append(roots, root)
+	}
+	shared := CLIConfig{Username: "user", Token: "token", FactorioVersion: "2.0", KeepVersions: 1}
+
+	configs, err := installConfigs(shared, roots)
+	if err != nil {
+		t.Fatalf("installConfigs() returned unexpected error: %v", err)
+	}
+
+	var updaters []*factorio.Updater
+	results := forEachInstall(configs, func(instCfg CLIConfig) error {
+		u, err := buildUpdater(context.Background(), instCfg)
+		if err != nil {
+			return err
+		}
+		updaters = append(updaters, u)
+		return nil
+	})
+
+	if len(results) != len(roots) || len(updaters) != len(roots) {
+		t.Fatalf("got %d results and %d updaters; want %d of each", len(results), len(updaters), len(roots))
+	}
+	for i, root := range roots {
+		if results[i].RootDir != root || results[i].Err != nil {
+			t.Errorf("result %d = %+v; want root %q without error", i, results[i], root)
+		}
+		if want := filepath.Join(root, "mods"); updaters[i].ModPath() != want {
+			t.Errorf("updater %d ModPath() = %q; want %q", i, updaters[i].ModPath(), want)
+		}
+	}
+	if updaters[0] == updaters[1] {
+		t.Error("each installation should get its own Updater")
+	}
+	if configs[0].httpClient == nil || configs[0].httpClient != configs[1].httpClient {
+		t.Error("installations should share one HTTP client")
+	}
+}
+
+func TestForEachInstallContinuesPastFailures(t *testing.T) {
+	configs := []CLIConfig{{RootDir: "/srv/a"}, {RootDir: "/srv/b"}, {RootDir: "/srv/c"}}
+	var visited []string
+	results := forEachInstall(configs, func(instCfg CLIConfig) error {
+		visited = append(visited, instCfg.RootDir)
+		if instCfg.RootDir == "/srv/a" {
+			return errors.New("broken")
+		}
+		return nil
+	})
+
+	if len(visited) != 3 {
+		t.Errorf("visited %v; want every installation", visited)
+	}
+	if results[0].Err == nil || results[1].Err != nil || results[2].Err != nil {
+		t.Errorf("results = %+v; want only /srv/a to fail", results)
+	}
+}
+
+func TestInstallConfigsRejectsSharedPaths(t *testing.T) {
+	if _, err := installConfigs(CLIConfig{ModPath: "/srv/mods"}, []string{"/srv/a", "/srv/b"}); err == nil {
+		t.Error("installConfigs() should reject --mod-path with multiple roots")
+	}
+	if _, err := installConfigs(CLIConfig{JSONSummaryFile: "/tmp/summary.json"}, []string{"/srv/a", "/srv/b"}); err == nil {
+		t.Error("installConfigs() should reject --json-summary-file with multiple roots")
+	}
+	if _, err := installConfigs(CLIConfig{ModPath: "/srv/mods"}, []string{"/srv/a"}); err != nil {
+		t.Errorf("installConfigs() with a single root returned unexpected error: %v", err)
+	}
+}
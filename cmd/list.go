@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"factorio-updater/internal/factorio"
 
@@ -15,68 +19,330 @@ var listCmd = &cobra.Command{
 	Short: "List the currently installed mods with versions",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := parseConfig(cmd, args)
-		updater, err := buildUpdater(cfg)
-		if err != nil {
-			return err
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+
+		filter := listFilter{}
+		filter.outdated, _ = cmd.Flags().GetBool("outdated")
+		filter.disabled, _ = cmd.Flags().GetBool("disabled")
+		filter.missing, _ = cmd.Flags().GetBool("missing")
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			d, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			filter.since, filter.now = d, time.Now()
 		}
+		return runList(ctx, cfg, filter)
+	},
+}
 
-		resolveWithUI(updater, "List")
+// runList resolves the tracked mods and prints those matching filter. It
+// writes nothing but the metadata cache, which --read-only skips.
+func runList(ctx context.Context, cfg CLIConfig, filter listFilter) error {
+	updater, err := buildUpdater(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	_ = resolveWithUI(ctx, updater, "List")
+	_ = printModList(updater, filter)
+	return nil
+}
 
-		_ = printModList(updater)
-		return nil
-	},
+// modState classifies a tracked mod for display and summary counting.
+type modState int
+
+const (
+	stateCurrent modState = iota
+	stateOutdated
+	stateMissing
+	stateDisabled
+	stateDeprecated
+)
+
+// classifyMod determines the display state of a mod. Disabled takes
+// precedence, then missing, then outdated; a current mod the portal marks
+// as deprecated is reported as deprecated.
+func classifyMod(mod *factorio.ModData) modState {
+	switch {
+	case !mod.Enabled:
+		return stateDisabled
+	case !mod.Installed:
+		return stateMissing
+	case mod.Latest == nil || mod.Version != mod.Latest.Version:
+		return stateOutdated
+	case mod.Deprecated:
+		return stateDeprecated
+	default:
+		return stateCurrent
+	}
 }
 
-// printModList renders the list of tracked mods to the console, using a rich
-// It returns a summary string of the operations computed for persistent logging.
-func printModList(updater *factorio.Updater) string {
-	mods := updater.GetMods()
+// modSummary holds the per-state counts shown in the list summary line.
+type modSummary struct {
+	upToDate   int
+	outdated   int
+	missing    int
+	disabled   int
+	deprecated int
+	total      int
+}
 
-	upToDate := 0
-	outdated := 0
-	missing := 0
-	disabled := 0
+// summarizeMods counts the mods in each display state.
+func summarizeMods(mods []*factorio.ModData) modSummary {
+	sum := modSummary{total: len(mods)}
+	for _, mod := range mods {
+		switch classifyMod(mod) {
+		case stateDisabled:
+			sum.disabled++
+		case stateMissing:
+			sum.missing++
+		case stateOutdated:
+			sum.outdated++
+		case stateDeprecated:
+			sum.deprecated++
+		default:
+			sum.upToDate++
+		}
+	}
+	return sum
+}
 
-	tableData := pterm.TableData{
-		{"Mod Name", "Enabled", "Installed", "Current Version", "Latest Version"},
+// String renders the summary line used on the console and in the log.
+func (s modSummary) String() string {
+	return fmt.Sprintf("Summary: %d up to date, %d outdated, %d missing, %d disabled, %d deprecated (%d total)",
+		s.upToDate, s.outdated, s.missing, s.disabled, s.deprecated, s.total)
+}
+
+// listFilter restricts the rows shown by printModList to the selected states.
+// State filters combine as a union, and the zero value shows every mod. A
+// since window further keeps only mods with an update published within it.
+type listFilter struct {
+	outdated bool
+	disabled bool
+	missing  bool
+	since    time.Duration // 0 disables the recency filter
+	now      time.Time     // the end of the since window
+}
+
+// active reports whether any filter is selected.
+func (f listFilter) active() bool {
+	return f.outdated || f.disabled || f.missing || f.since > 0
+}
+
+// matches reports whether a mod in the given state passes the state filters.
+func (f listFilter) matches(state modState) bool {
+	if !f.outdated && !f.disabled && !f.missing {
+		return true
+	}
+	switch state {
+	case stateOutdated:
+		return f.outdated
+	case stateDisabled:
+		return f.disabled
+	case stateMissing:
+		return f.missing
+	default:
+		return false
 	}
+}
 
+// filterMods returns the mods whose state passes the filter, preserving order.
+func filterMods(mods []*factorio.ModData, f listFilter) []*factorio.ModData {
+	var out []*factorio.ModData
 	for _, mod := range mods {
-		lver := "N/A"
-		if mod.Latest != nil {
-			lver = mod.Latest.Version
+		if f.matches(classifyMod(mod)) && (f.since <= 0 || updatedSince(mod, f.now.Add(-f.since))) {
+			out = append(out, mod)
 		}
-		cver := "N/A"
-		if mod.Installed {
-			cver = mod.Version
+	}
+	return out
+}
+
+// updatedSince reports whether mod has an update, a latest release other
+// than the installed one, that was published at or after cutoff. Releases
+// without a publish date never match.
+func updatedSince(mod *factorio.ModData, cutoff time.Time) bool {
+	if mod.Latest == nil || (mod.Installed && mod.Version == mod.Latest.Version) {
+		return false
+	}
+	published := mod.Latest.ReleasedAt
+	return !published.IsZero() && !published.Before(cutoff)
+}
+
+// parseSince parses a --since window: a Go duration such as "36h", or a
+// whole number of days or weeks such as "7d" or "2w".
+func parseSince(s string) (time.Duration, error) {
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err == nil && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid --since %q: want a positive duration such as 7d, 2w, or 36h", s)
+}
+
+// displayVersions returns the installed and latest version strings shown
+// for a mod, substituting "N/A" when either is unknown.
+func displayVersions(mod *factorio.ModData) (cver, lver string) {
+	cver, lver = "N/A", "N/A"
+	if mod.Installed {
+		cver = mod.Version
+	}
+	if mod.Latest != nil {
+		lver = mod.Latest.Version
+	} else if reason := mod.UnresolvedReason(); reason == factorio.UnknownOffline || reason == factorio.Orphaned {
+		lver = reason.String()
+	}
+	return cver, lver
+}
+
+// statusLine renders the one-line textual status of a mod used in the
+// persistent log and in filtered raw output.
+func statusLine(mod *factorio.ModData) string {
+	cver, lver := displayVersions(mod)
+	switch classifyMod(mod) {
+	case stateDisabled:
+		return fmt.Sprintf("  DISABLED  %s", mod.Title)
+	case stateMissing:
+		return fmt.Sprintf("  MISSING   %s (latest: %s)", mod.Title, lver)
+	case stateOutdated:
+		if mod.Latest == nil && mod.UnresolvedReason() == factorio.Orphaned {
+			return fmt.Sprintf("  ORPHANED  %s (%s, removed from portal)", mod.Title, cver)
 		}
+		return fmt.Sprintf("  OUTDATED  %s (%s -> %s)", mod.Title, cver, lver)
+	case stateDeprecated:
+		return fmt.Sprintf("  DEPRECATED %s (%s)", mod.Title, cver)
+	default:
+		return fmt.Sprintf("  CURRENT   %s (%s)", mod.Title, cver)
+	}
+}
 
-		if !mod.Enabled {
-			disabled++
-			updater.WriteLog("  DISABLED  %s", mod.Title)
-		} else if !mod.Installed {
-			missing++
-			updater.WriteLog("  MISSING   %s (latest: %s)", mod.Title, lver)
-		} else if cver != lver {
-			outdated++
-			updater.WriteLog("  OUTDATED  %s (%s -> %s)", mod.Title, cver, lver)
-		} else {
-			upToDate++
-			updater.WriteLog("  CURRENT   %s (%s)", mod.Title, cver)
+// unresolvedLines describes the mods ResolveMetadata left without a release,
+// one line per reason, e.g. "2 mods have no compatible release for Factorio
+// 2.0: bobores, boblibrary". It returns nil when every mod resolved.
+func unresolvedLines(mods []*factorio.ModData, factVersion string) []string {
+	var noRelease, portalErr, offline, tooDeep, orphaned []string
+	for _, mod := range mods {
+		switch mod.UnresolvedReason() {
+		case factorio.PortalError:
+			portalErr = append(portalErr, mod.Name)
+		case factorio.UnknownOffline:
+			offline = append(offline, mod.Name)
+		case factorio.BeyondMaxDepth:
+			tooDeep = append(tooDeep, mod.Name)
+		case factorio.Orphaned:
+			orphaned = append(orphaned, mod.Name)
+		default:
+			noRelease = append(noRelease, mod.Name)
+		}
+	}
+
+	var lines []string
+	if n := len(noRelease); n > 0 {
+		verb := "have"
+		if n == 1 {
+			verb = "has"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s no compatible release for Factorio %s: %s",
+			modCount(n), verb, factVersion, strings.Join(noRelease, ", ")))
+	}
+	if n := len(portalErr); n > 0 {
+		lines = append(lines, fmt.Sprintf("%s could not be checked because of a mod portal error: %s",
+			modCount(n), strings.Join(portalErr, ", ")))
+	}
+	if n := len(offline); n > 0 {
+		verb := "are"
+		if n == 1 {
+			verb = "is"
 		}
+		lines = append(lines, fmt.Sprintf("%s %s unknown (offline), with no cached metadata: %s",
+			modCount(n), verb, strings.Join(offline, ", ")))
+	}
+	if n := len(tooDeep); n > 0 {
+		lines = append(lines, fmt.Sprintf("%s not checked, beyond the dependency depth limit: %s",
+			modCount(n), strings.Join(tooDeep, ", ")))
+	}
+	if n := len(orphaned); n > 0 {
+		lines = append(lines, fmt.Sprintf("%s orphaned (removed from portal); keep the installed copy, it will not be updated: %s",
+			modCount(n), strings.Join(orphaned, ", ")))
+	}
+	return lines
+}
+
+// newerReleaseLines notes every mod whose newest release was passed over
+// because it needs another Factorio version, e.g. "helmod: newer version
+// 3.0.0 requires Factorio 2.1".
+func newerReleaseLines(mods []*factorio.ModData) []string {
+	var lines []string
+	for _, mod := range mods {
+		newer := mod.NewerIncompatible()
+		if newer == nil {
+			continue
+		}
+		target := newer.InfoJSON.FactorioVersion
+		if target == "" {
+			target = "another version"
+		}
+		lines = append(lines, fmt.Sprintf("%s: newer version %s requires Factorio %s", mod.Name, newer.Version, target))
+	}
+	return lines
+}
 
-		titleStr := mod.Title
-		cverStr := cver
-		lverStr := lver
+// modCount renders a count of mods, e.g. "1 mod" or "3 mods".
+func modCount(n int) string {
+	if n == 1 {
+		return "1 mod"
+	}
+	return fmt.Sprintf("%d mods", n)
+}
+
+// stateColor returns the pterm color function used to paint a mod's row.
+func stateColor(state modState) func(a ...any) string {
+	switch state {
+	case stateDisabled:
+		return pterm.Gray
+	case stateMissing:
+		return pterm.Yellow
+	case stateOutdated:
+		return pterm.Red
+	case stateDeprecated:
+		return pterm.Magenta
+	default:
+		return pterm.Green
+	}
+}
+
+// printModList renders the list of tracked mods to the console, using a rich
+// table colored by mod state (or just the summary line for raw output).
+// Rows are restricted by the filter, but the summary always covers every mod.
+// It returns a summary string of the operations computed for persistent logging.
+func printModList(updater *factorio.Updater, filter listFilter) string {
+	mods := updater.GetMods()
+	for _, mod := range mods {
+		updater.WriteLog("%s", statusLine(mod))
+	}
 
-		if !mod.Installed || cver != lver {
-			titleStr = pterm.Red(titleStr)
-			cverStr = pterm.Red(cverStr)
-			lverStr = pterm.Red(lverStr)
-		} else {
-			titleStr = pterm.Green(titleStr)
-			cverStr = pterm.Green(cverStr)
-			lverStr = pterm.Green(lverStr)
+	tableData := pterm.TableData{
+		{"Mod Name", "Enabled", "Installed", "Current Version", "Latest Version"},
+	}
+	var rawLines []string
+
+	for _, mod := range filterMods(mods, filter) {
+		cver, lver := displayVersions(mod)
+		rawLines = append(rawLines, statusLine(mod))
+
+		paint := stateColor(classifyMod(mod))
+		if filter.since > 0 {
+			lver += " (" + mod.Latest.ReleasedAt.Format(time.DateOnly) + ")"
 		}
 
 		enabledStr := pterm.Red("false")
@@ -90,26 +356,52 @@ func printModList(updater *factorio.Updater) string {
 		}
 
 		tableData = append(tableData, []string{
-			titleStr,
+			paint(mod.Title),
 			enabledStr,
 			installedStr,
-			cverStr,
-			lverStr,
+			paint(cver),
+			paint(lver),
 		})
 	}
 
-	summaryStr := fmt.Sprintf("Summary: %d up to date, %d outdated, %d missing, %d disabled (%d total)",
-		upToDate, outdated, missing, disabled, len(mods))
+	summaryStr := summarizeMods(mods).String()
+	notes := append(unresolvedLines(updater.UnresolvedMods(), updater.FactorioVersion()), newerReleaseLines(mods)...)
+	for _, line := range notes {
+		updater.WriteLog("%s", line)
+	}
 
 	if pterm.RawOutput {
+		// Raw output normally only carries the summary, but an explicit filter
+		// asks for specific rows, so print them as plain status lines.
+		if filter.active() {
+			for _, line := range rawLines {
+				fmt.Println(line)
+			}
+		}
 		fmt.Printf("\n%s\n", summaryStr)
+		for _, line := range notes {
+			fmt.Println(line)
+		}
+	} else if !outputEnabled(outputLevel, outputInfo) {
+		// Quiet mode drops the table but keeps the one-line summary.
+		fmt.Println(summaryStr)
+		for _, line := range notes {
+			fmt.Println(line)
+		}
 	} else {
 		_ = pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+		for _, line := range notes {
+			pterm.Warning.Println(line)
+		}
 	}
 
 	return summaryStr
 }
 
 func init() {
+	listCmd.Flags().Bool("outdated", false, "Only show mods with a newer compatible release")
+	listCmd.Flags().Bool("disabled", false, "Only show mods disabled in mod-list.json")
+	listCmd.Flags().Bool("missing", false, "Only show enabled mods that are not installed")
+	listCmd.Flags().String("since", "", "Only show mods with an update published within this window, e.g. 7d or 36h")
 	rootCmd.AddCommand(listCmd)
 }
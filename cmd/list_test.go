@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+)
+
+func TestSummarizeMods(t *testing.T) {
+	latest := &factorio.ModRelease{Version: "1.0.0"}
+	mods := []*factorio.ModData{
+		{Name: "current", Enabled: true, Installed: true, Version: "1.0.0", Latest: latest},
+		{Name: "current-2", Enabled: true, Installed: true, Version: "1.0.0", Latest: latest},
+		{Name: "outdated", Enabled: true, Installed: true, Version: "0.9.0", Latest: latest},
+		{Name: "unresolved", Enabled: true, Installed: true, Version: "0.9.0"},
+		{Name: "missing", Enabled: true, Latest: latest},
+		{Name: "disabled", Enabled: false, Installed: true, Version: "0.9.0", Latest: latest},
+		{Name: "deprecated", Enabled: true, Installed: true, Version: "1.0.0", Latest: latest, Deprecated: true},
+		{Name: "deprecated-outdated", Enabled: true, Installed: true, Version: "0.9.0", Latest: latest, Deprecated: true},
+	}
+
+	got := summarizeMods(mods)
+	want := modSummary{upToDate: 2, outdated: 3, missing: 1, disabled: 1, deprecated: 1, total: 8}
+	if got != want {
+		t.Errorf("summarizeMods() = %+v; want %+v", got, want)
+	}
+
+	if str := got.String(); str != "Summary: 2 up to date, 3 outdated, 1 missing, 1 disabled, 1 deprecated (8 total)" {
+		t.Errorf("String() = %q", str)
+	}
+}
+
+func TestFilterMods(t *testing.T) {
+	latest := &factorio.ModRelease{Version: "1.0.0"}
+	mods := []*factorio.ModData{
+		{Name: "current", Enabled: true, Installed: true, Version: "1.0.0", Latest: latest},
+		{Name: "outdated", Enabled: true, Installed: true, Version: "0.9.0", Latest: latest},
+		{Name: "missing", Enabled: true, Latest: latest},
+		{Name: "disabled", Enabled: false, Installed: true, Version: "1.0.0", Latest: latest},
+		{Name: "deprecated", Enabled: true, Installed: true, Version: "1.0.0", Latest: latest, Deprecated: true},
+	}
+
+	tests := []struct {
+		name   string
+		filter listFilter
+		want   []string
+	}{
+		{"no filter shows everything", listFilter{}, []string{"current", "outdated", "missing", "disabled", "deprecated"}},
+		{"outdated only", listFilter{outdated: true}, []string{"outdated"}},
+		{"disabled only", listFilter{disabled: true}, []string{"disabled"}},
+		{"missing only", listFilter{missing: true}, []string{"missing"}},
+		{"outdated and missing combine", listFilter{outdated: true, missing: true}, []string{"outdated", "missing"}},
+		{"all filters", listFilter{outdated: true, missing: true, disabled: true}, []string{"outdated", "missing", "disabled"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, mod := range filterMods(mods, tt.filter) {
+				got = append(got, mod.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterMods() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdatedSince(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	release := func(version string, age time.Duration) *factorio.ModRelease {
+		rel := &factorio.ModRelease{Version: version}
+		if age >= 0 {
+			rel.ReleasedAt = now.Add(-age)
+		}
+		return rel
+	}
+	mods := []*factorio.ModData{
+		{Name: "fresh", Enabled: true, Installed: true, Version: "1.0.0", Latest: release("1.1.0", 2*24*time.Hour)},
+		{Name: "stale", Enabled: true, Installed: true, Version: "1.0.0", Latest: release("1.1.0", 30*24*time.Hour)},
+		{Name: "current", Enabled: true, Installed: true, Version: "1.1.0", Latest: release("1.1.0", time.Hour)},
+		{Name: "missing", Enabled: true, Latest: release("0.5.0", 6*24*time.Hour)},
+		{Name: "undated", Enabled: true, Installed: true, Version: "1.0.0", Latest: release("1.1.0", -1)},
+		{Name: "edge", Enabled: true, Installed: true, Version: "1.0.0", Latest: release("2.0.0", 7*24*time.Hour)},
+		{Name: "unresolved", Enabled: true, Installed: true, Version: "1.0.0"},
+	}
+
+	tests := []struct {
+		name   string
+		filter listFilter
+		want   []string
+	}{
+		{"seven days", listFilter{since: 7 * 24 * time.Hour, now: now}, []string{"fresh", "missing", "edge"}},
+		{"one day", listFilter{since: 24 * time.Hour, now: now}, nil},
+		{"combined with missing", listFilter{missing: true, since: 7 * 24 * time.Hour, now: now}, []string{"missing"}},
+		{"no window", listFilter{}, []string{"fresh", "stale", "current", "missing", "undated", "edge", "unresolved"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range filterMods(mods, tt.filter) {
+				got = append(got, m.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterMods() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"week", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSince(%q) = %v, %v; want %v (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUnresolvedLines(t *testing.T) {
+	portalErr := &factorio.MetadataError{Mod: "broken", Kind: factorio.MetadataBadStatus, Err: errors.New("status 500")}
+	tests := []struct {
+		name string
+		mods []*factorio.ModData
+		want []string
+	}{
+		{"all resolved", nil, nil},
+		{
+			"one without release",
+			[]*factorio.ModData{{Name: "legacy"}},
+			[]string{"1 mod has no compatible release for Factorio 2.0: legacy"},
+		},
+		{
+			"both reasons",
+			[]*factorio.ModData{{Name: "broken", ResolveErr: portalErr}, {Name: "legacy"}, {Name: "old-lib"}},
+			[]string{
+				"2 mods have no compatible release for Factorio 2.0: legacy, old-lib",
+				"1 mod could not be checked because of a mod portal error: broken",
+			},
+		},
+		{
+			"offline without cache",
+			[]*factorio.ModData{{Name: "helmod", ResolveErr: &factorio.MetadataError{Mod: "helmod", Kind: factorio.MetadataOffline, Err: factorio.ErrOffline}}},
+			[]string{"1 mod is unknown (offline), with no cached metadata: helmod"},
+		},
+		{
+			"beyond depth limit",
+			[]*factorio.ModData{{Name: "deep-lib", ResolveErr: factorio.ErrDepthLimit}},
+			[]string{"1 mod not checked, beyond the dependency depth limit: deep-lib"},
+		},
+		{
+			"removed from portal while installed",
+			[]*factorio.ModData{{Name: "taken-down", Installed: true, ResolveErr: &factorio.MetadataError{Mod: "taken-down", Kind: factorio.MetadataOrphaned, Err: errors.New("status 404")}}},
+			[]string{"1 mod orphaned (removed from portal); keep the installed copy, it will not be updated: taken-down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unresolvedLines(tt.mods, "2.0"); !slices.Equal(got, tt.want) {
+				t.Errorf("unresolvedLines() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewerReleaseLines(t *testing.T) {
+	newer := &factorio.ModRelease{Version: "3.0.0"}
+	newer.InfoJSON.FactorioVersion = "2.1"
+	mods := []*factorio.ModData{
+		{Name: "helmod", Latest: &factorio.ModRelease{Version: "1.5.0"}, Newest: newer},
+		{Name: "current", Latest: newer, Newest: newer},
+	}
+
+	want := []string{"helmod: newer version 3.0.0 requires Factorio 2.1"}
+	if got := newerReleaseLines(mods); !slices.Equal(got, want) {
+		t.Errorf("newerReleaseLines() = %q; want %q", got, want)
+	}
+}
+
+func TestListReadOnlyWritesNothing(t *testing.T) {
+	oldRaw := pterm.RawOutput
+	pterm.RawOutput = true
+	t.Cleanup(func() { pterm.RawOutput = oldRaw })
+
+	root := t.TempDir()
+	modDir := filepath.Join(root, "mods")
+	_ = os.MkdirAll(modDir, 0o755)
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods": [{"name": "helmod", "enabled": true}]}`), 0644)
+	_ = os.WriteFile(filepath.Join(modDir, "helmod_2.2.11.zip"), []byte("helmod 2.2.11"), 0644)
+	// A leftover download old enough that a normal startup would sweep it.
+	stale := time.Now().Add(-24 * time.Hour)
+	_ = os.WriteFile(filepath.Join(modDir, "helmod_2.2.12.zip.tmp"), []byte("partial"), 0644)
+	_ = os.Chtimes(filepath.Join(modDir, "helmod_2.2.12.zip.tmp"), stale, stale)
+
+	// Root ignores permission bits, so the snapshot is what proves no write.
+	_ = os.Chmod(modDir, 0o555)
+	t.Cleanup(func() { _ = os.Chmod(modDir, 0o755) })
+	snapshot := func() string {
+		var b strings.Builder
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if info, err := d.Info(); err == nil {
+				fmt.Fprintf(&b, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			}
+			return nil
+		})
+		return b.String()
+	}
+	before := snapshot()
+
+	stub := portalStub{"/api/mods/helmod/full": []byte(`{"title": "Helmod", "releases": [{"download_url": "/download/helmod/2.2.12",
+		"file_name": "helmod_2.2.12.zip", "info_json": {"factorio_version": "2.0"}, "sha1": "00", "version": "2.2.12"}]}`)}
+	cfg := CLIConfig{
+		Username: "user", Token: "token", RootDir: root, FactorioVersion: "2.0",
+		KeepVersions: 1, ReadOnly: true, NoFsync: true, httpClient: &http.Client{Transport: stub},
+	}
+	if err := runList(context.Background(), cfg, listFilter{}); err != nil {
+		t.Fatalf("runList() returned unexpected error: %v", err)
+	}
+	if after := snapshot(); after != before {
+		t.Errorf("list --read-only changed the installation:\nbefore:\n%s\nafter:\n%s", before, after)
+	}
+
+	if err := runUpdateFlow(context.Background(), cfg); !errors.Is(err, factorio.ErrReadOnly) {
+		t.Errorf("runUpdateFlow() error = %v; want ErrReadOnly before any work", err)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"io"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+)
+
+// outputKind classifies a console message so the verbosity level can decide
+// whether it is shown.
+type outputKind int
+
+const (
+	outputError outputKind = iota
+	outputSummary
+	outputWarning
+	outputInfo
+	outputSuccess
+	outputDebug
+)
+
+// outputLevel is the verbosity selected by --quiet/--verbose for this run.
+var outputLevel = factorio.LogNormal
+
+// outputEnabled reports whether a message of the given kind is printed at
+// the given verbosity level. Quiet keeps only errors and the final summary;
+// debug traces require verbose.
+func outputEnabled(level factorio.LogLevel, kind outputKind) bool {
+	switch kind {
+	case outputError, outputSummary:
+		return true
+	case outputDebug:
+		return level == factorio.LogVerbose
+	default:
+		return level != factorio.LogQuiet
+	}
+}
+
+// configureOutput points the shared pterm printers at io.Discard, or back at
+// pterm's default writer, according to level, so call sites need no
+// verbosity checks of their own.
+func configureOutput(level factorio.LogLevel) {
+	outputLevel = level
+	writerFor := func(kind outputKind) io.Writer {
+		if outputEnabled(level, kind) {
+			return nil
+		}
+		return io.Discard
+	}
+
+	pterm.Info.Writer = writerFor(outputInfo)
+	pterm.Success.Writer = writerFor(outputSuccess)
+	pterm.Warning.Writer = writerFor(outputWarning)
+	pterm.DefaultSpinner.Writer = writerFor(outputInfo)
+}
+
+// printSummary prints the final outcome of a run. It is the one success
+// message kept in quiet mode, so it falls back to plain text there.
+func printSummary(msg string) {
+	if pterm.RawOutput || !outputEnabled(outputLevel, outputSuccess) {
+		pterm.Println(msg)
+		return
+	}
+	pterm.Success.Println(msg)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+)
+
+func TestOutputEnabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		level factorio.LogLevel
+		kind  outputKind
+		want  bool
+	}{
+		{"quiet keeps errors", factorio.LogQuiet, outputError, true},
+		{"quiet keeps summary", factorio.LogQuiet, outputSummary, true},
+		{"quiet drops warnings", factorio.LogQuiet, outputWarning, false},
+		{"quiet drops info", factorio.LogQuiet, outputInfo, false},
+		{"quiet drops success", factorio.LogQuiet, outputSuccess, false},
+		{"quiet drops debug", factorio.LogQuiet, outputDebug, false},
+		{"normal shows info", factorio.LogNormal, outputInfo, true},
+		{"normal shows success", factorio.LogNormal, outputSuccess, true},
+		{"normal drops debug", factorio.LogNormal, outputDebug, false},
+		{"verbose shows debug", factorio.LogVerbose, outputDebug, true},
+		{"verbose shows info", factorio.LogVerbose, outputInfo, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputEnabled(tt.level, tt.kind); got != tt.want {
+				t.Errorf("outputEnabled(%v, %v) = %v; want %v", tt.level, tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureOutputQuiet(t *testing.T) {
+	t.Cleanup(func() { configureOutput(factorio.LogNormal) })
+
+	configureOutput(factorio.LogQuiet)
+	if pterm.Info.Writer != io.Discard {
+		t.Error("expected pterm.Info to be discarded at quiet level")
+	}
+	if pterm.Success.Writer != io.Discard {
+		t.Error("expected pterm.Success to be discarded at quiet level")
+	}
+
+	configureOutput(factorio.LogNormal)
+	if pterm.Info.Writer != nil {
+		t.Error("expected pterm.Info to use its default writer at normal level")
+	}
+}
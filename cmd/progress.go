@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"factorio-updater/internal/factorio"
+)
+
+// progressStream writes progress events as newline-delimited JSON for
+// programs wrapping the CLI. A nil stream discards everything.
+type progressStream struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	updated int
+}
+
+// progressOut is the stream selected by --progress-json, or nil.
+var progressOut *progressStream
+
+// newProgressStream returns a stream writing one JSON event per line to w.
+func newProgressStream(w io.Writer) *progressStream {
+	return &progressStream{enc: json.NewEncoder(w)}
+}
+
+// handler returns the callback to hand to factorio.Options.OnEvent, or nil
+// so the Updater skips building events nobody reads.
+func (s *progressStream) handler() func(factorio.Event) {
+	if s == nil {
+		return nil
+	}
+	return s.write
+}
+
+// write encodes one event, counting successful downloads for the final
+// done event.
+func (s *progressStream) write(ev factorio.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ev.Type == factorio.EventDownloadDone && ev.Error == "" {
+		s.updated++
+	}
+	_ = s.enc.Encode(ev)
+}
+
+// done emits the closing event for the run, carrying err if it failed.
+func (s *progressStream) done(err error) {
+	if s == nil {
+		return
+	}
+	ev := factorio.Event{Type: factorio.EventDone, Time: time.Now().UTC()}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	s.mu.Lock()
+	ev.Updated = s.updated
+	s.mu.Unlock()
+	s.write(ev)
+}
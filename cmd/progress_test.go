@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"factorio-updater/internal/factorio"
+)
+
+func TestProgressStream(t *testing.T) {
+	var buf bytes.Buffer
+	stream := newProgressStream(&buf)
+	emit := stream.handler()
+
+	emit(factorio.Event{Type: factorio.EventResolveStart, Total: 2})
+	emit(factorio.Event{Type: factorio.EventDownloadDone, Mod: "helmod", Bytes: 10})
+	emit(factorio.Event{Type: factorio.EventDownloadDone, Mod: "flib", Error: "status 500"})
+	stream.done(errors.New("1 download failed"))
+
+	var events []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var ev map[string]any
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("stream is not newline-delimited JSON: %v", err)
+		}
+		events = append(events, ev)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("got %d events; want 4", len(events))
+	}
+	done := events[3]
+	if done["event"] != "done" || done["updated"] != float64(1) || done["error"] != "1 download failed" {
+		t.Errorf("done event = %v; want one successful download and the run error", done)
+	}
+	if _, ok := events[0]["mod"]; ok {
+		t.Errorf("resolve_start = %v; unset fields should be omitted", events[0])
+	}
+}
+
+func TestNilProgressStream(t *testing.T) {
+	var stream *progressStream
+	if stream.handler() != nil {
+		t.Error("a nil stream should not install an event callback")
+	}
+	stream.done(nil) // must not panic
+}
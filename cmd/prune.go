@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// pruneCmd defines the "prune" subcommand, which removes older release zips
+// from the mods directory without contacting the portal.
+var pruneCmd = &cobra.Command{
+	Use:   "prune [ROOT_DIR]",
+	Short: "Remove older releases of each installed mod, keeping the --keep-versions highest, without network access",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		lock, err := lockModDir(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = lock.Release() }()
+
+		resolvedFactPath, resolvedModPath, err := resolvePaths(cfg)
+		if err != nil {
+			return err
+		}
+		removed, err := factorio.PruneInstalled(factorio.Options{
+			ModPath:        resolvedModPath,
+			ModListPath:    cfg.ModListPath,
+			FactPath:       resolvedFactPath,
+			LogLevel:       cfg.LogLevel,
+			BuiltInMods:    cfg.BuiltInMods,
+			NoFsync:        cfg.NoFsync,
+			KeepVersions:   cfg.KeepVersions,
+			LenientModList: cfg.LenientModList,
+			ReadOnly:       cfg.ReadOnly,
+		}, dryRun)
+		printPruned(removed, dryRun)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case len(removed) == 0:
+			printSummary("No old releases to remove.")
+		case dryRun:
+			printSummary(fmt.Sprintf("Would remove %d old release(s).", len(removed)))
+		default:
+			printSummary(fmt.Sprintf("Removed %d old release(s).", len(removed)))
+		}
+		return nil
+	},
+}
+
+// printPruned lists the release zips prune removed, or would remove with
+// --dry-run.
+func printPruned(removed []string, dryRun bool) {
+	verb := "REMOVE"
+	if dryRun {
+		verb = "WOULD REMOVE"
+	}
+	for _, name := range removed {
+		pterm.Printf("  %-12s %s\n", verb, name)
+	}
+}
+
+func init() {
+	pruneCmd.Flags().Bool("dry-run", false, "List the releases that would be removed without deleting them")
+	rootCmd.AddCommand(pruneCmd)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// reconcileCmd defines the "reconcile" subcommand, which repairs a
+// mod-list.json that drifted out of sync with the mods directory.
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile [ROOT_DIR]",
+	Short: "List installed mods missing from mod-list.json, and optionally drop entries that cannot be installed",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args)
+		// Only the mods already tracked matter; discovered dependencies
+		// would otherwise be written to the list without being installed.
+		cfg.NoDeps = true
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		removeMissing, _ := cmd.Flags().GetBool("remove-missing")
+
+		lock, err := lockModDir(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = lock.Release() }()
+
+		updater, err := buildUpdater(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		// Only a portal answer proves a listed mod cannot be installed.
+		if removeMissing {
+			_ = resolveWithUI(ctx, updater, "Reconcile")
+		}
+
+		result := updater.Reconcile(removeMissing)
+		printReconcile(updater, result)
+		if result.Empty() {
+			return nil
+		}
+
+		if err := updater.SaveModList(); err != nil {
+			return fmt.Errorf("saving mod-list: %w", err)
+		}
+		msg := fmt.Sprintf("Reconciled mod-list.json: %d added, %d removed.", len(result.Added), len(result.Removed))
+		printSummary(msg)
+		_ = updater.SaveLog(msg)
+		return nil
+	},
+}
+
+// printReconcile reports the reconciliation changes to the console and the
+// persistent log.
+func printReconcile(updater *factorio.Updater, result factorio.ReconcileResult) {
+	if result.Empty() {
+		pterm.Info.Println("mod-list.json already matches the installed mods.")
+		return
+	}
+
+	for _, name := range result.Added {
+		pterm.Printf("  ADD       %s (installed but not listed)\n", name)
+		updater.WriteLog("  ADD       %s (installed but not listed)", name)
+	}
+	for _, name := range result.Removed {
+		pterm.Printf("  REMOVE    %s (not installed, no release available)\n", name)
+		updater.WriteLog("  REMOVE    %s (not installed, no release available)", name)
+	}
+}
+
+func init() {
+	reconcileCmd.Flags().Bool("remove-missing", false, "Also remove listed mods that are not installed and have no release for this Factorio version")
+	rootCmd.AddCommand(reconcileCmd)
+}
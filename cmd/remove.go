@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// removeCmd defines the "remove" subcommand, which deletes a mod's zips and
+// its mod-list.json entry, optionally taking orphaned dependencies with it.
+var removeCmd = &cobra.Command{
+	Use:   "remove MOD [ROOT_DIR]",
+	Short: "Remove a mod, and with --autoremove the dependencies nothing else needs",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args[1:])
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		autoremove, _ := cmd.Flags().GetBool("autoremove")
+		return runRemove(ctx, cfg, args[0], autoremove)
+	},
+}
+
+// runRemove removes target and, with autoremove, the dependencies it leaves
+// without a dependent.
+func runRemove(ctx context.Context, cfg CLIConfig, target string, autoremove bool) error {
+	lock, err := lockModDir(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+
+	updater, err := buildUpdater(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := updater.IsTracked(target); !ok {
+		return fmt.Errorf("mod %q is not installed or listed in mod-list.json", target)
+	}
+
+	// Resolution provides the dependency graph; without --autoremove
+	// the removal itself needs no portal access.
+	var deps []string
+	if autoremove {
+		_ = resolveWithUI(ctx, updater, "Remove")
+		deps = updater.RequiredDependencies(target)
+		for _, chain := range updater.DependentChains(target) {
+			pterm.Warning.Printf("%s is still required through %s\n", target, strings.Join(chain, " -> "))
+		}
+	}
+
+	if err := updater.RemoveMod(target); err != nil {
+		return err
+	}
+	removed := []string{target}
+
+	if autoremove {
+		// A mod without metadata may require any of the candidates, so
+		// nothing is an orphan until the whole graph is known.
+		if unresolved := updater.UnresolvedMods(); len(unresolved) > 0 {
+			names := make([]string, len(unresolved))
+			for i, m := range unresolved {
+				names[i] = m.Name
+			}
+			pterm.Warning.Printf("Keeping dependencies of %s: the dependencies of %s are unknown\n", target, strings.Join(names, ", "))
+		} else if orphans := updater.Orphans(deps); len(orphans) > 0 && confirmOrphanRemoval(cfg, orphans) {
+			for _, name := range orphans {
+				if err := updater.RemoveMod(name); err != nil {
+					return err
+				}
+				removed = append(removed, name)
+			}
+		}
+	}
+
+	if err := updater.SaveModList(); err != nil {
+		return fmt.Errorf("saving mod-list: %w", err)
+	}
+
+	msg := fmt.Sprintf("Removed %s.", strings.Join(removed, ", "))
+	printSummary(msg)
+	updater.WriteLog("%s", msg)
+	_ = updater.SaveLog(msg)
+	return nil
+}
+
+// confirmOrphanRemoval lists the dependencies left without a dependent and,
+// when interactive, asks whether to remove them as well.
+func confirmOrphanRemoval(cfg CLIConfig, orphans []string) bool {
+	pterm.Println("These dependencies are no longer required by any other mod:")
+	for _, name := range orphans {
+		pterm.Printf("  %s\n", name)
+	}
+	if !shouldPrompt(pterm.RawOutput, cfg.Yes) {
+		return true
+	}
+	ok, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show("Remove them too?")
+	return ok
+}
+
+func init() {
+	removeCmd.Flags().Bool("autoremove", false, "Also remove dependencies of MOD that no other mod requires")
+	rootCmd.AddCommand(removeCmd)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pterm/pterm"
+)
+
+// failingPortal answers the paths in fail with a server error and everything
+// else from the wrapped stub.
+type failingPortal struct {
+	portalStub
+	fail map[string]bool
+}
+
+func (p failingPortal) RoundTrip(req *http.Request) (*http.Response, error) {
+	if p.fail[req.URL.Path] {
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(http.StatusInternalServerError)
+		return rec.Result(), nil
+	}
+	return p.portalStub.RoundTrip(req)
+}
+
+func TestRunRemoveKeepsDependenciesWhenGraphIsIncomplete(t *testing.T) {
+	oldRaw := pterm.RawOutput
+	pterm.RawOutput = true
+	t.Cleanup(func() { pterm.RawOutput = oldRaw })
+
+	root := t.TempDir()
+	modDir := filepath.Join(root, "mods")
+	_ = os.MkdirAll(modDir, 0o755)
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods": [
+		{"name": "base", "enabled": true},
+		{"name": "bobplates", "enabled": true},
+		{"name": "bobwarfare", "enabled": true},
+		{"name": "boblibrary", "enabled": true}
+	]}`), 0644)
+	for _, zip := range []string{"bobplates_1.0.0.zip", "bobwarfare_1.0.0.zip", "boblibrary_1.0.0.zip"} {
+		_ = os.WriteFile(filepath.Join(modDir, zip), []byte(zip), 0644)
+	}
+
+	release := func(name, deps string) []byte {
+		return []byte(`{"title": "` + name + `", "releases": [{"download_url": "/download/` + name + `/1.0.0",
+			"file_name": "` + name + `_1.0.0.zip", "version": "1.0.0",
+			"info_json": {"factorio_version": "2.0", "dependencies": [` + deps + `]}}]}`)
+	}
+	// bobwarfare also requires boblibrary, but its metadata cannot be fetched.
+	transport := failingPortal{
+		portalStub: portalStub{
+			"/api/mods/bobplates/full":  release("bobplates", `"boblibrary"`),
+			"/api/mods/boblibrary/full": release("boblibrary", ""),
+		},
+		fail: map[string]bool{"/api/mods/bobwarfare/full": true},
+	}
+
+	cfg := CLIConfig{
+		Username: "user", Token: "token", RootDir: root, FactorioVersion: "2.0", KeepVersions: 1,
+		Yes: true, NoFsync: true,
+		httpClient: &http.Client{Transport: transport},
+	}
+	if err := runRemove(context.Background(), cfg, "bobplates", true); err != nil {
+		t.Fatalf("runRemove() returned unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(modDir, "bobplates_1.0.0.zip")); err == nil {
+		t.Error("bobplates_1.0.0.zip should have been removed")
+	}
+	if _, err := os.Stat(filepath.Join(modDir, "boblibrary_1.0.0.zip")); err != nil {
+		t.Errorf("boblibrary_1.0.0.zip was removed although bobwarfare may still need it: %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -22,18 +26,96 @@ type CLIConfig struct {
 	SettingsPath string
 	DataPath     string
 	ModPath      string
+	ModListPath  string
 	FactPath     string
 	RootDir      string
+
+	// UsernameSource and TokenSource describe where Username and Token were
+	// set, e.g. "the --token flag"; empty while unset.
+	UsernameSource string
+	TokenSource    string
+	// FallbackUsername and FallbackToken come from the config file named by
+	// FallbackSource and rank below server-settings.json/player-data.json.
+	FallbackUsername string
+	FallbackToken    string
+	FallbackSource   string
+
+	PostUpdateHook string
+	WebhookURL     string
+	Yes            bool
+	LogLevel       factorio.LogLevel
+
+	// JSONSummaryFile receives a JSON summary of each update run; "" for none.
+	JSONSummaryFile string
+
+	FactorioVersion    string
+	IgnoreVersionCheck bool
+	TokenCheck         bool
+	BuiltInMods        []string
+	NoFsync            bool
+	MaxDownloadSize    string
+	DownloadCache      string
+	KeepVersions       int
+	NoPrune            bool
+	Force              bool
+	ForceLock          bool
+	SaveOnly           bool
+	PreserveOrder      bool
+	LenientModList     bool
+	ReadOnly           bool
+	TimeoutOverall     time.Duration
+	ForceIPv4          bool
+	Insecure           bool
+	CAFile             string
+	Offline            bool
+	RefreshMetadata    bool
+	Mirror             string
+	Init               bool
+	NoDeps             bool
+	MaxDepth           int
+	MaxMods            int
+	AllowPrerelease    bool
+	StrictDependencies bool
+	AutoApprove        string
+	AutoEnable         bool
+	PreferVersion      string
+
+	// credentialErr records a --username-file or --token-file that could not
+	// be read; buildUpdater reports it.
+	credentialErr error
+
+	// httpClient is shared by every Updater built from this config; nil gives
+	// each its own. Set by runMultiInstallFlow, not by a flag.
+	httpClient *http.Client
 }
 
 var rootCmd = &cobra.Command{
-	Use:   "factorio-updater [ROOT_DIR]",
+	Use:   "factorio-updater [ROOT_DIR...]",
 	Short: "Updates mods for a target factorio installation",
 	Long:  `A modern cliff tool to manage updating and installing mods on a given Factorio server.`,
 	Args:  cobra.ArbitraryArgs,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Unlike NO_COLOR or a non-TTY stdout, --no-color keeps rich output
+		// (tables, spinners, progress bars) and only strips the colors.
+		if noColor, _ := cmd.Flags().GetBool("no-color"); noColor {
+			pterm.DisableColor()
+		}
+		configureOutput(logLevelFromFlags(cmd))
+		if progressJSON, _ := cmd.Flags().GetBool("progress-json"); progressJSON {
+			progressOut = newProgressStream(os.Stderr)
+		}
+		if insecure, _ := cmd.Flags().GetBool("insecure"); insecure {
+			pterm.Warning.Println("--insecure disables TLS certificate verification: anyone on the network path can read your token and tamper with downloads. Prefer --ca-file.")
+		}
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := parseConfig(cmd, args)
-		return runUpdateFlow(cfg)
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		if len(args) > 1 {
+			return runMultiInstallFlow(ctx, cfg, args)
+		}
+		return runUpdateFlow(ctx, cfg)
 	},
 }
 
@@ -57,56 +139,198 @@ func Execute() {
 		time.Sleep(500 * time.Millisecond)
 	}()
 
-	if err := rootCmd.Execute(); err != nil {
-		pterm.Error.Println(err)
+	err := rootCmd.Execute()
+	progressOut.done(err)
+	if err != nil {
+		code := exitCodeFor(err)
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				pterm.Error.Println(exitErr.err)
+			}
+		} else {
+			pterm.Error.Println(err)
+		}
 
 		// os.Exit bypasses defers, so we must manually flush here as well
 		os.Stdout.Sync() //nolint:errcheck // Best-effort flush for AMP pipe observer
 		os.Stderr.Sync() //nolint:errcheck // Best-effort flush for AMP pipe observer
 		time.Sleep(500 * time.Millisecond)
-		os.Exit(1)
+		os.Exit(code)
 	}
 }
 
+// exitCodeError carries a specific process exit code out of a command's RunE
+// so Execute can terminate with it instead of the generic failure code.
+type exitCodeError struct {
+	code int
+	err  error // nil when the exit code alone conveys the outcome
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringP("username", "u", "", "factorio.com username overriding server-settings.json/player-data.json")
 	rootCmd.PersistentFlags().StringP("token", "t", "", "factorio.com API token overriding server-settings.json/player-data.json")
+	rootCmd.PersistentFlags().String("username-file", "", "Read the factorio.com username from this file (e.g. a mounted secret)")
+	rootCmd.PersistentFlags().String("token-file", "", "Read the factorio.com API token from this file instead of passing it on the command line")
 	rootCmd.PersistentFlags().StringP("server-settings", "s", "", "Absolute path to the server-settings.json file (overrides player-data.json)")
 	rootCmd.PersistentFlags().StringP("player-data", "d", "", "Absolute path to the player-data.json file")
 	rootCmd.PersistentFlags().StringP("mod-path", "m", "", "Path to the mods directory")
-	rootCmd.PersistentFlags().StringP("bin-path", "b", "", "Path to the Factorio executable")
+	rootCmd.PersistentFlags().String("mod-list-path", "", "Path to mod-list.json when it is kept outside the mods directory")
+	rootCmd.PersistentFlags().StringP("bin-path", "b", "", "Path to the Factorio executable, or a directory containing it")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "Skip the confirmation prompt before downloading updates")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output without switching to raw output mode")
+	rootCmd.PersistentFlags().String("post-update-hook", "", "Shell command to run after at least one mod was updated")
+	rootCmd.PersistentFlags().String("webhook-url", "", "URL to POST a JSON update summary to after at least one mod was updated")
+	rootCmd.PersistentFlags().String("json-summary-file", "", "Write a JSON summary of the run (counts, per-mod results, duration) to this file")
+	rootCmd.PersistentFlags().String("factorio-version", "", "Target Factorio version (e.g. 2.0) instead of asking the binary")
+	rootCmd.PersistentFlags().Bool("ignore-version-check", false, "Select the newest release of every mod regardless of its factorio_version")
+	rootCmd.PersistentFlags().Bool("mod-portal-token-check", false, "Verify the username/token with a small authenticated request before resolving")
+	rootCmd.PersistentFlags().StringSlice("builtin-mods", nil, "Extra mods to treat as built-in (never queried or downloaded), comma-separated")
+	rootCmd.PersistentFlags().Bool("no-fsync", false, "Skip fsync after writing downloads and mod-list.json (faster, less crash safe)")
+	rootCmd.PersistentFlags().String("max-download-size", "1GiB", "Reject any single download larger than this (e.g. 500MiB, 2GiB)")
+	rootCmd.PersistentFlags().String("download-cache", "", "Directory of downloaded mod zips shared between installs; hits are hard-linked or copied instead of downloaded")
+	rootCmd.PersistentFlags().Int("keep-versions", 1, "Number of releases per mod, including the latest, to keep on disk")
+	rootCmd.PersistentFlags().Bool("no-prune", false, "Download updates without removing older releases")
+	rootCmd.PersistentFlags().Bool("force", false, "Also update mods installed as unpacked directories (dev installs), replacing symlinked ones with the zip")
+	rootCmd.PersistentFlags().Bool("force-lock", false, "Take over the mods directory lock even if another run appears to hold it")
+	rootCmd.PersistentFlags().Bool("save-only", false, "Rewrite mod-list.json in canonical sorted form and exit, without network access")
+	rootCmd.PersistentFlags().Bool("preserve-order", false, "Keep the original order of mod-list.json entries instead of sorting them by name")
+	rootCmd.PersistentFlags().Bool("read-only", false, "Never write to disk; commands that must write fail immediately (for read-only mods mounts)")
+	rootCmd.PersistentFlags().Bool("lenient", false, "Accept common mod-list.json mistakes such as \"enabled\": \"true\" with a warning instead of failing")
+	rootCmd.PersistentFlags().Duration("timeout-overall", 0, "Stop cleanly once the whole run has taken this long (e.g. 10m); 0 means no limit")
+	rootCmd.PersistentFlags().Bool("force-ipv4", false, "Connect to the mod portal over IPv4 only (for networks with broken IPv6)")
+	rootCmd.PersistentFlags().Bool("insecure", false, "Skip TLS certificate verification (unsafe; prefer --ca-file behind intercepting proxies)")
+	rootCmd.PersistentFlags().String("ca-file", "", "PEM bundle of CA certificates to trust in addition to the system roots (e.g. a corporate proxy CA)")
+	rootCmd.PersistentFlags().String("mirror", "", "Download mod zips from this base URL (e.g. https://my-mirror/) instead of the portal; metadata and hashes still come from the portal")
+	rootCmd.PersistentFlags().Bool("offline", false, "Make no network calls; report mod status from cached portal metadata only")
+	rootCmd.PersistentFlags().Bool("refresh-metadata", false, "Fetch all mod metadata from the portal, ignoring recently cached entries (they are still updated)")
+	rootCmd.PersistentFlags().Bool("init", false, "Create the mods directory and a mod-list.json with just base if they are missing (fresh servers)")
+	rootCmd.PersistentFlags().Bool("progress-json", false, "Write newline-delimited JSON progress events to stderr for wrapping tools and panels")
+	rootCmd.PersistentFlags().Bool("no-deps", false, "Only resolve mods already in mod-list.json; never pull in missing dependencies")
+	rootCmd.PersistentFlags().Int("max-depth", 0, "Follow dependencies at most this many levels deep; deeper ones are reported as unresolved (0 for no limit)")
+	rootCmd.PersistentFlags().Int("max-mods", 1000, "Abort resolution if more than this many mods would be tracked, guarding against runaway dependency growth (0 for no limit)")
+	rootCmd.PersistentFlags().Bool("allow-prerelease", false, "Consider releases whose version looks like a pre-release (e.g. 1.2.0-beta) when picking the latest")
+	rootCmd.PersistentFlags().Bool("auto-enable", false, "Enable disabled mods that an enabled mod requires, without asking")
+	rootCmd.PersistentFlags().String("auto-approve", "", "Only apply updates up to this magnitude (patch, minor, or major) and hold bigger ones for review")
+	rootCmd.PersistentFlags().Bool("strict-dependencies", false, "Fail before downloading if any required dependency cannot be satisfied for the target Factorio version")
+	rootCmd.PersistentFlags().String("prefer-version", "", "Resolve mods compatible with this Factorio version (e.g. 2.1) instead of the installed one")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only print errors and the final summary")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Also log HTTP requests (token redacted) and skip decisions")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.MarkFlagsMutuallyExclusive("offline", "refresh-metadata")
+}
+
+// logLevelFromFlags maps --quiet and --verbose onto an Updater log level.
+func logLevelFromFlags(cmd *cobra.Command) factorio.LogLevel {
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return factorio.LogQuiet
+	}
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		return factorio.LogVerbose
+	}
+	return factorio.LogNormal
 }
 
 // parseConfig extracts CLI flag values and the optional positional ROOT_DIR
-// argument into a CLIConfig struct for downstream consumption.
+// argument into a CLIConfig struct for downstream consumption, filling unset
+// values from the environment and the config file.
 func parseConfig(cmd *cobra.Command, args []string) CLIConfig {
 	cfg := CLIConfig{}
 	cfg.Username, _ = cmd.Flags().GetString("username")
 	cfg.Token, _ = cmd.Flags().GetString("token")
+	if cfg.Username != "" {
+		cfg.UsernameSource = "the --username flag"
+	}
+	if cfg.Token != "" {
+		cfg.TokenSource = "the --token flag"
+	}
+	usernameFile, _ := cmd.Flags().GetString("username-file")
+	tokenFile, _ := cmd.Flags().GetString("token-file")
+	applyCredentialFiles(&cfg, usernameFile, tokenFile)
 	cfg.SettingsPath, _ = cmd.Flags().GetString("server-settings")
 	cfg.DataPath, _ = cmd.Flags().GetString("player-data")
 	cfg.ModPath, _ = cmd.Flags().GetString("mod-path")
+	cfg.ModListPath, _ = cmd.Flags().GetString("mod-list-path")
 	cfg.FactPath, _ = cmd.Flags().GetString("bin-path")
+	cfg.PostUpdateHook, _ = cmd.Flags().GetString("post-update-hook")
+	cfg.WebhookURL, _ = cmd.Flags().GetString("webhook-url")
+	cfg.JSONSummaryFile, _ = cmd.Flags().GetString("json-summary-file")
+	cfg.Yes, _ = cmd.Flags().GetBool("yes")
+	cfg.LogLevel = logLevelFromFlags(cmd)
+	cfg.FactorioVersion, _ = cmd.Flags().GetString("factorio-version")
+	cfg.IgnoreVersionCheck, _ = cmd.Flags().GetBool("ignore-version-check")
+	cfg.TokenCheck, _ = cmd.Flags().GetBool("mod-portal-token-check")
+	cfg.BuiltInMods, _ = cmd.Flags().GetStringSlice("builtin-mods")
+	cfg.NoFsync, _ = cmd.Flags().GetBool("no-fsync")
+	cfg.MaxDownloadSize, _ = cmd.Flags().GetString("max-download-size")
+	cfg.DownloadCache, _ = cmd.Flags().GetString("download-cache")
+	cfg.KeepVersions, _ = cmd.Flags().GetInt("keep-versions")
+	cfg.NoPrune, _ = cmd.Flags().GetBool("no-prune")
+	cfg.Force, _ = cmd.Flags().GetBool("force")
+	cfg.ForceLock, _ = cmd.Flags().GetBool("force-lock")
+	cfg.SaveOnly, _ = cmd.Flags().GetBool("save-only")
+	cfg.PreserveOrder, _ = cmd.Flags().GetBool("preserve-order")
+	cfg.LenientModList, _ = cmd.Flags().GetBool("lenient")
+	cfg.ReadOnly, _ = cmd.Flags().GetBool("read-only")
+	cfg.TimeoutOverall, _ = cmd.Flags().GetDuration("timeout-overall")
+	cfg.ForceIPv4, _ = cmd.Flags().GetBool("force-ipv4")
+	cfg.Insecure, _ = cmd.Flags().GetBool("insecure")
+	cfg.CAFile, _ = cmd.Flags().GetString("ca-file")
+	cfg.Offline, _ = cmd.Flags().GetBool("offline")
+	cfg.RefreshMetadata, _ = cmd.Flags().GetBool("refresh-metadata")
+	cfg.Mirror, _ = cmd.Flags().GetString("mirror")
+	cfg.Init, _ = cmd.Flags().GetBool("init")
+	cfg.NoDeps, _ = cmd.Flags().GetBool("no-deps")
+	cfg.MaxDepth, _ = cmd.Flags().GetInt("max-depth")
+	cfg.MaxMods, _ = cmd.Flags().GetInt("max-mods")
+	cfg.AllowPrerelease, _ = cmd.Flags().GetBool("allow-prerelease")
+	cfg.StrictDependencies, _ = cmd.Flags().GetBool("strict-dependencies")
+	cfg.AutoApprove, _ = cmd.Flags().GetString("auto-approve")
+	cfg.AutoEnable, _ = cmd.Flags().GetBool("auto-enable")
+	cfg.PreferVersion, _ = cmd.Flags().GetString("prefer-version")
 	if len(args) > 0 {
 		cfg.RootDir = args[0]
 	}
+	loadConfigSources(&cfg)
 	return cfg
 }
 
 // resolvePaths applies the path inference logic, deriving factPath and modPath
 // from a root directory positional argument when explicit flags are absent.
+// A ROOT_DIR holding a mod-list.json is the mods directory itself; the binary
+// is then looked for in its parent, and without one --factorio-version is
+// required and the returned factPath is empty.
 func resolvePaths(cfg CLIConfig) (resolvedFactPath, resolvedModPath string, err error) {
 	rd := cfg.RootDir
 	fp := cfg.FactPath
 	mp := cfg.ModPath
 
-	if rd != "" {
+	if rd != "" && mp == "" && isModsDir(rd) {
+		mp = rd
+		if fp == "" {
+			fp = parentBinary(rd)
+		}
 		if fp == "" {
-			if runtime.GOOS == "windows" {
-				fp = filepath.Join(rd, "bin", "x64", "factorio.exe")
-			} else {
-				fp = filepath.Join(rd, "bin", "x64", "factorio")
+			if cfg.FactorioVersion == "" {
+				return "", "", fmt.Errorf("%s looks like a mods directory (it has a mod-list.json) and no Factorio binary was found next to it; pass --factorio-version or --bin-path", rd)
 			}
+			return "", mp, nil
+		}
+	} else if rd != "" {
+		rd = installRoot(rd)
+		if fp == "" {
+			fp = filepath.Join(rd, "bin", "x64", factorioBinaryName())
 		}
 		if mp == "" {
 			mp = filepath.Join(rd, "mods")
@@ -117,47 +341,300 @@ func resolvePaths(cfg CLIConfig) (resolvedFactPath, resolvedModPath string, err
 		return "", "", fmt.Errorf("must specify either a ROOT_DIR positional argument, or both --bin-path and --mod-path")
 	}
 
+	fp, err = resolveBinary(fp)
+	if err != nil {
+		return "", "", err
+	}
+
 	return fp, mp, nil
 }
 
+// installRoot returns ROOT_DIR, or its factorio subdirectory when only that
+// holds a bin/x64 binary, as the headless tarball unpacks.
+func installRoot(rootDir string) string {
+	hasBinary := func(dir string) bool {
+		info, err := os.Stat(filepath.Join(dir, "bin", "x64", factorioBinaryName()))
+		return err == nil && !info.IsDir()
+	}
+	if nested := filepath.Join(rootDir, "factorio"); !hasBinary(rootDir) && hasBinary(nested) {
+		return nested
+	}
+	return rootDir
+}
+
+// isModsDir reports whether dir is a mods directory rather than a Factorio
+// installation, judged by a mod-list.json directly inside it.
+func isModsDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "mod-list.json"))
+	return err == nil && !info.IsDir()
+}
+
+// parentBinary returns the Factorio binary of the installation a mods
+// directory sits in, or "" if its parent holds none.
+func parentBinary(modsDir string) string {
+	bin := filepath.Join(filepath.Dir(filepath.Clean(modsDir)), "bin", "x64", factorioBinaryName())
+	if info, err := os.Stat(bin); err != nil || info.IsDir() {
+		return ""
+	}
+	return bin
+}
+
+// factorioBinaryName returns the executable's file name on this platform.
+func factorioBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "factorio.exe"
+	}
+	return "factorio"
+}
+
+// resolveBinary turns a bin path into the real Factorio executable,
+// searching a directory directly and under bin/x64 and following symlinks.
+// A path that does not exist is returned unchanged so determineVersion can
+// report it.
+func resolveBinary(path string) (string, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return path, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("checking factorio binary %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		found := ""
+		for _, candidate := range []string{
+			filepath.Join(path, factorioBinaryName()),
+			filepath.Join(path, "bin", "x64", factorioBinaryName()),
+		} {
+			if ci, err := os.Stat(candidate); err == nil && !ci.IsDir() {
+				found = candidate
+				break
+			}
+		}
+		if found == "" {
+			return "", fmt.Errorf("no %s found in directory %s or its bin/x64", factorioBinaryName(), path)
+		}
+		path = found
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving factorio binary %s: %w", path, err)
+	}
+	info, err = os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("checking factorio binary %s: %w", resolved, err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		return "", fmt.Errorf("factorio binary %s is not executable", resolved)
+	}
+	return resolved, nil
+}
+
 // buildUpdater resolves paths from CLI args/flags and constructs a fully
 // initialized Updater ready for metadata resolution and mod operations.
-func buildUpdater(cfg CLIConfig) (*factorio.Updater, error) {
+func buildUpdater(ctx context.Context, cfg CLIConfig) (*factorio.Updater, error) {
+	if cfg.credentialErr != nil {
+		return nil, cfg.credentialErr
+	}
 	resolvedFactPath, resolvedModPath, err := resolvePaths(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return factorio.NewUpdater(
-		cfg.SettingsPath,
-		cfg.DataPath,
-		resolvedModPath,
-		resolvedFactPath,
-		cfg.Username,
-		cfg.Token,
-	)
+	var maxDownload int64
+	if cfg.MaxDownloadSize != "" {
+		if maxDownload, err = parseByteSize(cfg.MaxDownloadSize); err != nil {
+			return nil, fmt.Errorf("--max-download-size: %w", err)
+		}
+	}
+
+	if cfg.KeepVersions < 1 {
+		return nil, fmt.Errorf("--keep-versions must be at least 1, got %d", cfg.KeepVersions)
+	}
+	if cfg.MaxDepth < 0 {
+		return nil, fmt.Errorf("--max-depth must not be negative, got %d", cfg.MaxDepth)
+	}
+	if cfg.MaxMods < 0 {
+		return nil, fmt.Errorf("--max-mods must not be negative, got %d", cfg.MaxMods)
+	}
+	var autoApprove factorio.UpdateMagnitude
+	if cfg.AutoApprove != "" {
+		var err error
+		if autoApprove, err = factorio.ParseUpdateMagnitude(cfg.AutoApprove); err != nil {
+			return nil, fmt.Errorf("--auto-approve: %w", err)
+		}
+	}
+
+	updater, err := factorio.NewUpdater(factorio.Options{
+		SettingsPath:       cfg.SettingsPath,
+		DataPath:           cfg.DataPath,
+		ModPath:            resolvedModPath,
+		ModListPath:        cfg.ModListPath,
+		FactPath:           resolvedFactPath,
+		Username:           cfg.Username,
+		Token:              cfg.Token,
+		UsernameSource:     cfg.UsernameSource,
+		TokenSource:        cfg.TokenSource,
+		FallbackUsername:   cfg.FallbackUsername,
+		FallbackToken:      cfg.FallbackToken,
+		FallbackSource:     cfg.FallbackSource,
+		LogLevel:           cfg.LogLevel,
+		FactorioVersion:    cfg.FactorioVersion,
+		IgnoreVersionCheck: cfg.IgnoreVersionCheck,
+		BuiltInMods:        cfg.BuiltInMods,
+		NoFsync:            cfg.NoFsync,
+		MaxDownloadSize:    maxDownload,
+		DownloadCache:      cfg.DownloadCache,
+		KeepVersions:       cfg.KeepVersions,
+		NoPrune:            cfg.NoPrune,
+		Force:              cfg.Force,
+		PreserveOrder:      cfg.PreserveOrder,
+		LenientModList:     cfg.LenientModList,
+		ReadOnly:           cfg.ReadOnly,
+		HTTPClient:         cfg.httpClient,
+		Transport:          transportOptions(cfg),
+		Offline:            cfg.Offline,
+		RefreshMetadata:    cfg.RefreshMetadata,
+		Mirror:             cfg.Mirror,
+		Init:               cfg.Init,
+		NoDeps:             cfg.NoDeps,
+		MaxDepth:           cfg.MaxDepth,
+		MaxMods:            cfg.MaxMods,
+		AllowPrerelease:    cfg.AllowPrerelease,
+		StrictDependencies: cfg.StrictDependencies,
+		AutoApprove:        autoApprove,
+		PreferVersion:      cfg.PreferVersion,
+		OnEvent:            progressOut.handler(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.TokenCheck && !cfg.Offline {
+		if err := updater.ValidateCredentials(ctx); err != nil {
+			return nil, fmt.Errorf("mod portal token check: %w", err)
+		}
+	}
+	return updater, nil
+}
+
+// transportOptions returns the HTTP transport settings selected by flags.
+func transportOptions(cfg CLIConfig) factorio.TransportOptions {
+	return factorio.TransportOptions{
+		ForceIPv4:          cfg.ForceIPv4,
+		InsecureSkipVerify: cfg.Insecure,
+		CAFile:             cfg.CAFile,
+	}
+}
+
+// errOverallTimeout is the cancellation cause once --timeout-overall elapses.
+var errOverallTimeout = errors.New("overall timeout reached")
+
+// runContext returns the root context for a command run, cancelled with
+// errOverallTimeout once cfg.TimeoutOverall elapses when it is set.
+func runContext(cfg CLIConfig) (context.Context, context.CancelFunc) {
+	if cfg.TimeoutOverall > 0 {
+		return context.WithTimeoutCause(context.Background(), cfg.TimeoutOverall, errOverallTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// lockModDir takes the mods directory lock for commands that modify it, so
+// an overlapping cron job and manual run cannot race on mod-list.json and
+// the zips. The caller must Release the returned lock.
+func lockModDir(cfg CLIConfig) (*factorio.Lock, error) {
+	_, modPath, err := resolvePaths(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Every command that takes the lock is about to write, so --read-only
+	// stops it here, before anything is resolved or downloaded.
+	if cfg.ReadOnly {
+		return nil, fmt.Errorf("%s would be modified: %w", modPath, factorio.ErrReadOnly)
+	}
+	// With --init the directory may not exist yet; NewUpdater fills it in.
+	if cfg.Init {
+		if err := os.MkdirAll(modPath, 0o755); err != nil {
+			return nil, fmt.Errorf("creating mods directory: %w", err)
+		}
+	}
+	if err := factorio.ValidateModPath(modPath); err != nil {
+		return nil, err
+	}
+	return factorio.AcquireLock(modPath, cfg.ForceLock)
+}
+
+// rawProgressInterval is how many resolved mods separate the progress lines
+// printed in raw output mode.
+const rawProgressInterval = 25
+
+// progressLine formats a resolution progress snapshot for display.
+func progressLine(p factorio.ResolveProgress) string {
+	return fmt.Sprintf("resolved %d/%d mods, %d new deps discovered", p.Resolved, p.Total, p.Discovered)
+}
+
+// shouldReportProgress reports whether a raw-mode progress line is due:
+// every rawProgressInterval mods and once everything known is resolved.
+func shouldReportProgress(p factorio.ResolveProgress) bool {
+	return p.Resolved%rawProgressInterval == 0 || p.Resolved == p.Total
+}
+
+// printResolveErrors prints one deduplicated line per failure cause, falling
+// back to the raw error when it carries no grouping.
+func printResolveErrors(err error) {
+	var depErr *factorio.UnsatisfiedDependenciesError
+	if errors.As(err, &depErr) {
+		for _, e := range depErr.Errs {
+			pterm.Error.Println(e)
+		}
+	}
+	var resolveErr *factorio.ResolveError
+	if !errors.As(err, &resolveErr) {
+		if depErr == nil {
+			pterm.Warning.Println(err)
+		}
+		return
+	}
+	for _, group := range resolveErr.Groups() {
+		pterm.Warning.Println(group.String())
+	}
 }
 
 // resolveWithUI fetches and resolves mod metadata, displaying progress
 // through either a pterm spinner (TTY) or plain text (raw/CI output).
 // Why: Centralizes the resolve+UI logic that was previously duplicated
 // across listCmd and runUpdateFlow, enforcing DRY.
-func resolveWithUI(updater *factorio.Updater, modeName string) {
+// The resolution error, if any, is returned after being reported so callers
+// that care about completeness (such as check) can act on it.
+func resolveWithUI(ctx context.Context, updater *factorio.Updater, modeName string) error {
 	if pterm.RawOutput {
 		pterm.Info.Printf("Starting Factorio Mod Updater (%s Mode)...\n", modeName)
-		pterm.Println("Fetching metadata and resolving dependencies...")
-		err := updater.ResolveMetadata()
+		if outputEnabled(outputLevel, outputInfo) {
+			pterm.Println("Fetching metadata and resolving dependencies...")
+		}
+		err := updater.ResolveMetadata(ctx, func(p factorio.ResolveProgress) {
+			if shouldReportProgress(p) && outputEnabled(outputLevel, outputInfo) {
+				pterm.Println(progressLine(p))
+			}
+		})
 		if err != nil {
-			pterm.Warning.Println("Some metadata could not be resolved:", err)
+			pterm.Warning.Println("Some metadata could not be resolved:")
+			printResolveErrors(err)
 		}
 		pterm.Success.Println("Metadata resolution complete")
+		return err
+	}
+
+	spinner, _ := pterm.DefaultSpinner.Start("Fetching metadata and resolving dependencies...")
+	err := updater.ResolveMetadata(ctx, func(p factorio.ResolveProgress) {
+		spinner.UpdateText("Fetching metadata and resolving dependencies... " + progressLine(p))
+	})
+	if err != nil {
+		spinner.Warning("Some metadata could not be resolved")
+		printResolveErrors(err)
 	} else {
-		spinner, _ := pterm.DefaultSpinner.Start("Fetching metadata and resolving dependencies...")
-		err := updater.ResolveMetadata()
-		if err != nil {
-			spinner.Warning("Some metadata could not be resolved")
-		} else {
-			spinner.Success("Metadata fully resolved")
-		}
+		spinner.Success("Metadata fully resolved")
 	}
+	return err
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -119,3 +120,161 @@ func TestResolvePaths(t *testing.T) {
 		}
 	})
 }
+
+func TestResolvePathsHeadlessTarball(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the headless server is Linux-only")
+	}
+	parent := t.TempDir()
+	root := filepath.Join(parent, "factorio")
+	bin := filepath.Join(root, "bin", "x64", "factorio")
+	_ = os.MkdirAll(filepath.Dir(bin), 0o755)
+	_ = os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o755)
+
+	for _, rootDir := range []string{parent, root} {
+		fp, mp, err := resolvePaths(CLIConfig{RootDir: rootDir})
+		if err != nil {
+			t.Fatalf("resolvePaths(%s) returned unexpected error: %v", rootDir, err)
+		}
+		if want, _ := filepath.EvalSymlinks(bin); fp != want {
+			t.Errorf("resolvePaths(%s) bin-path = %q; want %q", rootDir, fp, want)
+		}
+		if want := filepath.Join(root, "mods"); mp != want {
+			t.Errorf("resolvePaths(%s) mod-path = %q; want %q", rootDir, mp, want)
+		}
+	}
+}
+
+func TestResolveBinary(t *testing.T) {
+	writeBinary := func(t *testing.T, path string, perm os.FileMode) {
+		t.Helper()
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		_ = os.WriteFile(path, []byte("#!/bin/sh\n"), perm)
+	}
+
+	t.Run("directory containing the binary", func(t *testing.T) {
+		dir := t.TempDir()
+		bin := filepath.Join(dir, factorioBinaryName())
+		writeBinary(t, bin, 0o755)
+
+		got, err := resolveBinary(dir)
+		if err != nil {
+			t.Fatalf("resolveBinary() returned unexpected error: %v", err)
+		}
+		if want, _ := filepath.EvalSymlinks(bin); got != want {
+			t.Errorf("resolveBinary() = %q; want %q", got, want)
+		}
+	})
+
+	t.Run("installation root as bin path", func(t *testing.T) {
+		root := t.TempDir()
+		bin := filepath.Join(root, "bin", "x64", factorioBinaryName())
+		writeBinary(t, bin, 0o755)
+
+		got, err := resolveBinary(root)
+		if err != nil {
+			t.Fatalf("resolveBinary() returned unexpected error: %v", err)
+		}
+		if want, _ := filepath.EvalSymlinks(bin); got != want {
+			t.Errorf("resolveBinary() = %q; want %q", got, want)
+		}
+	})
+
+	t.Run("directory without a binary", func(t *testing.T) {
+		if _, err := resolveBinary(t.TempDir()); err == nil {
+			t.Fatal("expected an error for a directory without a factorio binary")
+		}
+	})
+
+	t.Run("symlink is followed to the installation", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("creating symlinks needs extra privileges on Windows")
+		}
+		root := t.TempDir()
+		bin := filepath.Join(root, "factorio", "bin", "x64", "factorio")
+		writeBinary(t, bin, 0o755)
+		link := filepath.Join(root, "usr-local-bin-factorio")
+		if err := os.Symlink(bin, link); err != nil {
+			t.Fatalf("creating symlink: %v", err)
+		}
+
+		got, err := resolveBinary(link)
+		if err != nil {
+			t.Fatalf("resolveBinary() returned unexpected error: %v", err)
+		}
+		if want, _ := filepath.EvalSymlinks(bin); got != want {
+			t.Errorf("resolveBinary() = %q; want the link target %q", got, want)
+		}
+	})
+
+	t.Run("non-executable target is rejected", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("Windows has no executable permission bit")
+		}
+		bin := filepath.Join(t.TempDir(), "factorio")
+		writeBinary(t, bin, 0o644)
+
+		_, err := resolveBinary(bin)
+		if err == nil || !strings.Contains(err.Error(), "not executable") {
+			t.Fatalf("resolveBinary() error = %v; want a not executable error", err)
+		}
+	})
+
+	t.Run("missing path is returned unchanged", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "nope", "factorio")
+		got, err := resolveBinary(missing)
+		if err != nil || got != missing {
+			t.Errorf("resolveBinary() = %q, %v; want %q, nil", got, err, missing)
+		}
+	})
+}
+
+func TestResolvePathsModsDir(t *testing.T) {
+	t.Run("root dir is not a mods dir", func(t *testing.T) {
+		root := t.TempDir()
+		_ = os.MkdirAll(filepath.Join(root, "mods"), 0o755)
+		_ = os.WriteFile(filepath.Join(root, "mods", "mod-list.json"), []byte(`{"mods":[]}`), 0644)
+		if isModsDir(root) {
+			t.Errorf("isModsDir(%s) = true for a Factorio root", root)
+		}
+		if _, mp, err := resolvePaths(CLIConfig{RootDir: root}); err != nil || mp != filepath.Join(root, "mods") {
+			t.Errorf("resolvePaths() = %q, %v; want the mods subdirectory", mp, err)
+		}
+	})
+
+	t.Run("mods dir needs a Factorio version", func(t *testing.T) {
+		modsDir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(modsDir, "mod-list.json"), []byte(`{"mods":[]}`), 0644)
+		if !isModsDir(modsDir) {
+			t.Fatalf("isModsDir(%s) = false for a directory with mod-list.json", modsDir)
+		}
+		if _, _, err := resolvePaths(CLIConfig{RootDir: modsDir}); err == nil || !strings.Contains(err.Error(), "--factorio-version") {
+			t.Errorf("resolvePaths() error = %v; want one asking for --factorio-version", err)
+		}
+		fp, mp, err := resolvePaths(CLIConfig{RootDir: modsDir, FactorioVersion: "2.0"})
+		if err != nil {
+			t.Fatalf("resolvePaths() returned unexpected error: %v", err)
+		}
+		if mp != modsDir || fp != "" {
+			t.Errorf("resolvePaths() = %q, %q; want no binary and the mods dir itself", fp, mp)
+		}
+	})
+
+	t.Run("mods dir inside an installation", func(t *testing.T) {
+		root := t.TempDir()
+		bin := filepath.Join(root, "bin", "x64", factorioBinaryName())
+		_ = os.MkdirAll(filepath.Dir(bin), 0o755)
+		_ = os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o755)
+		modsDir := filepath.Join(root, "mods")
+		_ = os.MkdirAll(modsDir, 0o755)
+		_ = os.WriteFile(filepath.Join(modsDir, "mod-list.json"), []byte(`{"mods":[]}`), 0644)
+
+		fp, mp, err := resolvePaths(CLIConfig{RootDir: modsDir})
+		if err != nil {
+			t.Fatalf("resolvePaths() returned unexpected error: %v", err)
+		}
+		if want, _ := filepath.EvalSymlinks(bin); fp != want || mp != modsDir {
+			t.Errorf("resolvePaths() = %q, %q; want %q, %q", fp, mp, want, modsDir)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"factorio-updater/internal/factorio"
+)
+
+// runSummary is the JSON document --json-summary-file receives at the end
+// of an update run.
+type runSummary struct {
+	Timestamp       time.Time     `json:"timestamp"`
+	RunID           string        `json:"run_id"`
+	DurationSeconds float64       `json:"duration_seconds"`
+	FactorioVersion string        `json:"factorio_version"`
+	ModPath         string        `json:"mod_path"`
+	Success         bool          `json:"success"`
+	Message         string        `json:"message"`
+	Error           string        `json:"error,omitempty"`
+	Counts          summaryCounts `json:"counts"`
+	Mods            []summaryMod  `json:"mods"`
+}
+
+// summaryCounts tallies the per-mod results of a run.
+type summaryCounts struct {
+	Total      int `json:"total"`
+	Updated    int `json:"updated"`
+	UpToDate   int `json:"up_to_date"`
+	Outdated   int `json:"outdated"`
+	Unresolved int `json:"unresolved"`
+}
+
+// Per-mod results in a runSummary.
+const (
+	resultUpdated    = "updated"
+	resultUpToDate   = "up_to_date"
+	resultOutdated   = "outdated"   // an update exists but was not installed
+	resultUnresolved = "unresolved" // no release was found for this Factorio version
+)
+
+// summaryMod is the outcome of one tracked mod.
+type summaryMod struct {
+	Name string `json:"name"`
+	// Result is one of "updated", "up_to_date", "outdated", or "unresolved".
+	Result        string `json:"result"`
+	FromVersion   string `json:"from_version,omitempty"`
+	ToVersion     string `json:"to_version,omitempty"`
+	LatestVersion string `json:"latest_version,omitempty"`
+}
+
+// newRunSummary builds the summary of a run that started at started and
+// ended at now with message, result, and err.
+func newRunSummary(updater *factorio.Updater, result factorio.UpdateResult, message string, started, now time.Time, err error) runSummary {
+	s := runSummary{
+		Timestamp:       now.UTC(),
+		RunID:           updater.RunID(),
+		DurationSeconds: now.Sub(started).Seconds(),
+		FactorioVersion: updater.FactorioVersion(),
+		ModPath:         updater.ModPath(),
+		Success:         err == nil,
+		Message:         message,
+		Mods:            []summaryMod{},
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	updated := make(map[string]factorio.UpdatedMod, len(result.Updated))
+	for _, m := range result.Updated {
+		updated[m.Name] = m
+	}
+	for _, mod := range updater.GetMods() {
+		entry := summaryMod{Name: mod.Name}
+		if mod.Installed {
+			entry.FromVersion = mod.Version
+		}
+		if mod.Latest != nil {
+			entry.LatestVersion = mod.Latest.Version
+		}
+		switch u, ok := updated[mod.Name]; {
+		case ok:
+			entry.Result, entry.FromVersion, entry.ToVersion = resultUpdated, u.FromVersion, u.ToVersion
+			s.Counts.Updated++
+		case mod.Latest == nil:
+			entry.Result = resultUnresolved
+			s.Counts.Unresolved++
+		case mod.Installed && mod.Version == mod.Latest.Version:
+			entry.Result = resultUpToDate
+			s.Counts.UpToDate++
+		default:
+			entry.Result = resultOutdated
+			s.Counts.Outdated++
+		}
+		s.Mods = append(s.Mods, entry)
+	}
+	s.Counts.Total = len(s.Mods)
+	return s
+}
+
+// writeRunSummary writes s to path as indented JSON, replacing the file
+// atomically so a dashboard never reads half a summary.
+func writeRunSummary(path string, s runSummary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling run summary: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing run summary: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("writing run summary %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pterm/pterm"
+)
+
+// portalStub serves canned mod portal responses without a network.
+type portalStub map[string][]byte
+
+func (p portalStub) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	if body, ok := p[req.URL.Path]; ok {
+		_, _ = rec.Write(body)
+	} else {
+		rec.WriteHeader(http.StatusNotFound)
+	}
+	return rec.Result(), nil
+}
+
+func TestRunUpdateFlowWritesJSONSummary(t *testing.T) {
+	oldRaw := pterm.RawOutput
+	pterm.RawOutput = true
+	t.Cleanup(func() { pterm.RawOutput = oldRaw })
+
+	root := t.TempDir()
+	modDir := filepath.Join(root, "mods")
+	_ = os.MkdirAll(modDir, 0o755)
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods": [
+		{"name": "base", "enabled": true},
+		{"name": "flib", "enabled": true},
+		{"name": "helmod", "enabled": true}
+	]}`), 0644)
+	flibZip := []byte("flib 0.16.2")
+	_ = os.WriteFile(filepath.Join(modDir, "flib_0.16.2.zip"), flibZip, 0644)
+	_ = os.WriteFile(filepath.Join(modDir, "helmod_2.2.11.zip"), []byte("helmod 2.2.11"), 0644)
+	helmodZip := []byte("helmod 2.2.12")
+
+	release := func(name, version string, content []byte) string {
+		sum := sha1.Sum(content)
+		return fmt.Sprintf(`{"title": %[1]q, "releases": [{"download_url": "/download/%[1]s/%[2]s", "file_name": "%[1]s_%[2]s.zip",
+			"info_json": {"factorio_version": "2.0"}, "sha1": %[3]q, "version": %[2]q}]}`, name, version, hex.EncodeToString(sum[:]))
+	}
+	stub := portalStub{
+		"/api/mods/flib/full":     []byte(release("flib", "0.16.2", flibZip)),
+		"/api/mods/helmod/full":   []byte(release("helmod", "2.2.12", helmodZip)),
+		"/download/helmod/2.2.12": helmodZip,
+	}
+
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	cfg := CLIConfig{
+		Username: "user", Token: "token", RootDir: root, FactorioVersion: "2.0", KeepVersions: 1,
+		Yes: true, NoFsync: true, JSONSummaryFile: summaryPath,
+		httpClient: &http.Client{Transport: stub},
+	}
+	if err := runUpdateFlow(context.Background(), cfg); err != nil {
+		t.Fatalf("runUpdateFlow() returned unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("reading summary: %v", err)
+	}
+	var got runSummary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("summary is not valid JSON: %v\n%s", err, data)
+	}
+
+	if !got.Success || got.Error != "" || got.FactorioVersion != "2.0" || got.ModPath != modDir {
+		t.Errorf("summary = %+v; want a successful 2.0 run on %s", got, modDir)
+	}
+	if got.RunID == "" {
+		t.Error("summary has no run_id")
+	}
+	if got.Timestamp.IsZero() || got.DurationSeconds < 0 {
+		t.Errorf("timestamp = %v, duration = %v; want a set timestamp and a non-negative duration", got.Timestamp, got.DurationSeconds)
+	}
+	if !strings.Contains(got.Message, "1 mod") {
+		t.Errorf("message = %q; want the final summary line", got.Message)
+	}
+	wantCounts := summaryCounts{Total: 2, Updated: 1, UpToDate: 1}
+	if got.Counts != wantCounts {
+		t.Errorf("counts = %+v; want %+v", got.Counts, wantCounts)
+	}
+	wantMods := []summaryMod{
+		{Name: "flib", Result: resultUpToDate, FromVersion: "0.16.2", LatestVersion: "0.16.2"},
+		{Name: "helmod", Result: resultUpdated, FromVersion: "2.2.11", ToVersion: "2.2.12", LatestVersion: "2.2.12"},
+	}
+	if fmt.Sprint(got.Mods) != fmt.Sprint(wantMods) {
+		t.Errorf("mods = %+v; want %+v", got.Mods, wantMods)
+	}
+}
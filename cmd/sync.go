@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// syncCmd defines the "sync" subcommand, which reconciles the installation
+// against a declarative modpack manifest before running the normal update.
+var syncCmd = &cobra.Command{
+	Use:   "sync [ROOT_DIR]",
+	Short: "Install, pin, and optionally prune mods to match a modpack manifest",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args)
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		if manifestPath == "" {
+			return fmt.Errorf("--manifest is required (path to modpack.json or modpack.yaml)")
+		}
+		manifest, err := factorio.LoadManifest(manifestPath)
+		if err != nil {
+			return err
+		}
+
+		lock, err := lockModDir(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = lock.Release() }()
+
+		updater, err := buildUpdater(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		// Installs and pins are applied before resolution so the portal is
+		// queried for the desired releases and their dependencies.
+		plan := updater.DiffManifest(manifest, false)
+		for _, name := range plan.ToInstall {
+			if err := updater.AddMod(name); err != nil {
+				return err
+			}
+		}
+		for name, version := range plan.ToPin {
+			if err := updater.PinMod(name, version); err != nil {
+				return err
+			}
+		}
+
+		if err := resolveAbortError(updater, resolveWithUI(ctx, updater, "Sync")); err != nil {
+			return err
+		}
+
+		// Removals wait for the resolved graph so dependencies of manifest
+		// mods are recognised and kept.
+		if prune {
+			plan.ToRemove = updater.DiffManifest(manifest, true).ToRemove
+			for _, name := range plan.ToRemove {
+				if err := updater.RemoveMod(name); err != nil {
+					return err
+				}
+			}
+		}
+
+		printSyncPlan(updater, plan)
+
+		enabled := enableRequiredMods(cfg, updater)
+		_, err = applyUpdates(ctx, cfg, updater, !plan.Empty() || enabled)
+		return err
+	},
+}
+
+// printSyncPlan reports the manifest reconciliation changes to the console
+// and the persistent log.
+func printSyncPlan(updater *factorio.Updater, plan factorio.ManifestDiff) {
+	if plan.Empty() {
+		pterm.Info.Println("Installed mods already match the manifest.")
+		return
+	}
+
+	for _, name := range plan.ToInstall {
+		pterm.Printf("  INSTALL   %s\n", name)
+		updater.WriteLog("  INSTALL   %s", name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(plan.ToPin)) {
+		version := plan.ToPin[name]
+		if version == "" {
+			pterm.Printf("  UNPIN     %s\n", name)
+			updater.WriteLog("  UNPIN     %s", name)
+		} else {
+			pterm.Printf("  PIN       %s (%s)\n", name, version)
+			updater.WriteLog("  PIN       %s (%s)", name, version)
+		}
+	}
+	for _, name := range plan.ToRemove {
+		pterm.Printf("  REMOVE    %s\n", name)
+		updater.WriteLog("  REMOVE    %s", name)
+	}
+}
+
+func init() {
+	syncCmd.Flags().StringP("manifest", "f", "", "Path to the modpack.json or modpack.yaml manifest")
+	syncCmd.Flags().Bool("prune", false, "Remove installed mods that are not in the manifest or required by it")
+	rootCmd.AddCommand(syncCmd)
+}
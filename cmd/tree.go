@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// treeCmd defines the "tree" subcommand, which renders the resolved
+// dependency graph rooted at the mods nothing else depends on.
+var treeCmd = &cobra.Command{
+	Use:   "tree [ROOT_DIR]",
+	Short: "Show the resolved dependency graph of the installed mods",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := parseConfig(cmd, args)
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		updater, err := buildUpdater(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		_ = resolveWithUI(ctx, updater, "Tree")
+
+		var names []string
+		for _, mod := range updater.GetMods() {
+			names = append(names, mod.Name)
+		}
+		slices.Sort(names)
+		roots := buildDependencyTree(names, updater.DependencyGraph())
+
+		pterm.Println()
+		if pterm.RawOutput {
+			printTreeRaw(roots, 0)
+			return nil
+		}
+		return pterm.DefaultTree.WithRoot(pterm.TreeNode{Children: roots}).Render()
+	},
+}
+
+// buildDependencyTree arranges the dependency edges into a forest whose roots
+// are the mods no other mod depends on. Mods caught purely in cycles are
+// promoted to roots so every mod appears, and revisiting a mod already on
+// the current path is marked instead of recursing.
+func buildDependencyTree(names []string, edges []factorio.DependencyEdge) []pterm.TreeNode {
+	children := make(map[string][]factorio.DependencyEdge)
+	hasParent := make(map[string]bool)
+	for _, e := range edges {
+		children[e.Parent] = append(children[e.Parent], e)
+		hasParent[e.Child] = true
+	}
+
+	seen := make(map[string]bool)
+	var build func(name, label string, path map[string]bool) pterm.TreeNode
+	build = func(name, label string, path map[string]bool) pterm.TreeNode {
+		seen[name] = true
+		if path[name] {
+			return pterm.TreeNode{Text: label + " (cycle)"}
+		}
+		path[name] = true
+		defer delete(path, name)
+
+		node := pterm.TreeNode{Text: label}
+		for _, e := range children[name] {
+			childLabel := e.Child
+			if e.Constraint != "" {
+				childLabel += " (" + e.Constraint + ")"
+			}
+			node.Children = append(node.Children, build(e.Child, childLabel, path))
+		}
+		return node
+	}
+
+	var roots []pterm.TreeNode
+	for _, name := range names {
+		if !hasParent[name] {
+			roots = append(roots, build(name, name, make(map[string]bool)))
+		}
+	}
+	for _, name := range names {
+		if !seen[name] {
+			roots = append(roots, build(name, name, make(map[string]bool)))
+		}
+	}
+	return roots
+}
+
+// printTreeRaw writes the dependency forest as plain indented text for
+// non-TTY output.
+func printTreeRaw(nodes []pterm.TreeNode, depth int) {
+	for _, node := range nodes {
+		pterm.Println(strings.Repeat("  ", depth) + node.Text)
+		printTreeRaw(node.Children, depth+1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+}
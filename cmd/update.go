@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"factorio-updater/internal/factorio"
 
@@ -12,35 +20,167 @@ import (
 // updateCmd defines the hidden "update" subcommand retained for backward
 // compatibility with existing scripts that invoke it explicitly.
 var updateCmd = &cobra.Command{
-	Use:    "update [ROOT_DIR]",
+	Use:    "update [ROOT_DIR...]",
 	Short:  "Update all mods to their latest release",
 	Hidden: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := parseConfig(cmd, args)
-		return runUpdateFlow(cfg)
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		if len(args) > 1 {
+			return runMultiInstallFlow(ctx, cfg, args)
+		}
+		return runUpdateFlow(ctx, cfg)
 	},
 }
 
 // runUpdateFlow orchestrates the full update lifecycle: metadata resolution,
 // mod status display, download of outdated mods, pruning, and log persistence.
-func runUpdateFlow(cfg CLIConfig) error {
-	updater, err := buildUpdater(cfg)
+func runUpdateFlow(ctx context.Context, cfg CLIConfig) (err error) {
+	lock, err := lockModDir(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+
+	if cfg.SaveOnly {
+		return runSaveOnly(cfg)
+	}
+
+	started := time.Now()
+	updater, err := buildUpdater(ctx, cfg)
 	if err != nil {
 		return err
 	}
 
-	resolveWithUI(updater, "Update")
+	var outcome updateOutcome
+	if cfg.JSONSummaryFile != "" {
+		defer func() {
+			summary := newRunSummary(updater, outcome.Result, outcome.Message, started, time.Now(), err)
+			if writeErr := writeRunSummary(cfg.JSONSummaryFile, summary); writeErr != nil {
+				pterm.Warning.Printf("Failed to write the JSON summary: %v\n", writeErr)
+				updater.WriteLog("Failed to write the JSON summary: %v", writeErr)
+			}
+		}()
+	}
+
+	resolveErr := resolveWithUI(ctx, updater, "Update")
+	if ctx.Err() != nil {
+		err = fmt.Errorf("stopped while resolving metadata, nothing was downloaded: %w", context.Cause(ctx))
+		outcome.Message = err.Error()
+		updater.WriteLog("%v", err)
+		_ = updater.SaveLog(err.Error())
+		return err
+	}
+
+	if err = resolveAbortError(updater, resolveErr); err != nil {
+		outcome.Message = err.Error()
+		return err
+	}
+
+	enabled := enableRequiredMods(cfg, updater)
+	outcome, err = applyUpdates(ctx, cfg, updater, enabled)
+	return err
+}
+
+// resolveAbortError returns the error that stops a download flow after
+// resolution, after recording it in the persistent log: unsatisfiable
+// dependencies under --strict-dependencies, or more mods than --max-mods.
+// It returns nil when the flow may go on.
+func resolveAbortError(updater *factorio.Updater, resolveErr error) error {
+	var depErr *factorio.UnsatisfiedDependenciesError
+	if !errors.As(resolveErr, &depErr) && !errors.Is(resolveErr, factorio.ErrTooManyMods) {
+		return nil
+	}
+	err := fmt.Errorf("stopped before downloading: %w", resolveErr)
+	updater.WriteLog("%v", err)
+	_ = updater.SaveLog(err.Error())
+	return err
+}
+
+// runSaveOnly rewrites mod-list.json from its parsed contents without
+// building a full Updater, so it needs neither credentials nor the portal.
+func runSaveOnly(cfg CLIConfig) error {
+	resolvedFactPath, resolvedModPath, err := resolvePaths(cfg)
+	if err != nil {
+		return err
+	}
+	count, err := factorio.RewriteModList(factorio.Options{
+		ModPath:        resolvedModPath,
+		ModListPath:    cfg.ModListPath,
+		FactPath:       resolvedFactPath,
+		LogLevel:       cfg.LogLevel,
+		BuiltInMods:    cfg.BuiltInMods,
+		NoFsync:        cfg.NoFsync,
+		PreserveOrder:  cfg.PreserveOrder,
+		LenientModList: cfg.LenientModList,
+		ReadOnly:       cfg.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+	listPath := cfg.ModListPath
+	if listPath == "" {
+		listPath = filepath.Join(resolvedModPath, "mod-list.json")
+	}
+	printSummary(fmt.Sprintf("Rewrote %s with %d mod(s).", listPath, count))
+	return nil
+}
 
+// applyUpdates renders the mod status table for an already-resolved updater
+// and downloads whatever is outdated. listChanged forces mod-list.json to be
+// rewritten even when no download is needed, for callers that altered the
+// tracked mod set themselves. The returned outcome holds whatever was
+// downloaded and the final message, even when err is set.
+func applyUpdates(ctx context.Context, cfg CLIConfig, updater *factorio.Updater, listChanged bool) (outcome updateOutcome, err error) {
 	pterm.Println()
-	summaryStr := printModList(updater)
+	summaryStr := printModList(updater, listFilter{})
 	pterm.Println()
 
-	if !updatesAvailable(updater) {
+	if !updatesAvailable(updater.GetMods(), cfg.Force) {
 		msg := "All mods are up to date."
-		pterm.Success.Println(msg)
+		outcome.Message = msg
+		printSummary(msg)
 		updater.WriteLog("%s", msg)
+		if listChanged {
+			if err := updater.SaveModList(); err != nil {
+				_ = updater.SaveLog(summaryStr)
+				return outcome, fmt.Errorf("saving mod-list: %w", err)
+			}
+		}
 		_ = updater.SaveLog(summaryStr)
-		return nil
+		return outcome, nil
+	}
+
+	if cfg.Offline {
+		msg := fmt.Sprintf("Offline: %d mod(s) have updates according to cached metadata; run without --offline to download them.", len(updater.PendingDownloads()))
+		outcome.Message = msg
+		printSummary(msg)
+		updater.WriteLog("%s", msg)
+		if listChanged {
+			if err := updater.SaveModList(); err != nil {
+				_ = updater.SaveLog(summaryStr)
+				return outcome, fmt.Errorf("saving mod-list: %w", err)
+			}
+		}
+		_ = updater.SaveLog(summaryStr)
+		return outcome, nil
+	}
+
+	proceed, err := confirmUpdates(ctx, cfg, updater)
+	if err != nil {
+		outcome.Message = err.Error()
+		updater.WriteLog("%v", err)
+		_ = updater.SaveLog(summaryStr)
+		return outcome, err
+	}
+	if !proceed {
+		msg := "Update cancelled by user."
+		outcome.Message = msg
+		pterm.Warning.Println(msg)
+		updater.WriteLog("%s", msg)
+		_ = updater.SaveLog(summaryStr)
+		return outcome, nil
 	}
 
 	if pterm.RawOutput {
@@ -49,27 +189,66 @@ func runUpdateFlow(cfg CLIConfig) error {
 		pterm.Info.Println("Built-in Space Age expansions (space-age, quality, elevated-rails, core) are ignored.")
 	}
 
-	updatedCount, err := updater.UpdateMods()
+	result, err := updater.UpdateMods(ctx)
+	updatedCount := len(result.Updated)
+	for _, h := range result.Held {
+		pterm.Warning.Printf("Held %s (%s -> %s): %s update exceeds --auto-approve %s; update it manually after review\n",
+			h.Title, h.FromVersion, h.ToVersion, h.Magnitude, cfg.AutoApprove)
+	}
 	var finalMsg string
 	if err != nil {
 		finalMsg = fmt.Sprintf("Failed to complete update: %v", err)
+		var saveErr *factorio.ModListSaveError
+		if errors.As(err, &saveErr) {
+			finalMsg = fmt.Sprintf("%d mod(s) were downloaded, but %s could not be written: %v", updatedCount, saveErr.Path, saveErr.Err)
+			printSummary(finalMsg)
+		} else if errors.Is(err, errOverallTimeout) {
+			finalMsg = fmt.Sprintf("Overall timeout reached: %d mod(s) were updated before stopping.", updatedCount)
+			printSummary(finalMsg)
+		}
+		for _, hint := range updateErrorHints(err) {
+			pterm.Error.Println(hint)
+			updater.WriteLog("%s", hint)
+		}
+	} else if updatedCount == 0 && len(result.Held) > 0 {
+		finalMsg = fmt.Sprintf("No updates applied; %d held for manual review.", len(result.Held))
+		printSummary(finalMsg)
 	} else if updatedCount == 0 {
 		finalMsg = "No mod updates were required."
 		if pterm.RawOutput {
 			totalMods := len(updater.GetMods())
 			finalMsg = fmt.Sprintf("Summary: 0 mods updated | All %d mods are up to date", totalMods)
-			pterm.Println(finalMsg)
-		} else {
-			pterm.Success.Println(finalMsg)
 		}
+		printSummary(finalMsg)
 	} else {
 		if pterm.RawOutput {
 			totalMods := len(updater.GetMods())
 			finalMsg = fmt.Sprintf("Summary: %d mods updated | All %d mods are up to date", updatedCount, totalMods)
-			pterm.Println(finalMsg)
 		} else {
 			finalMsg = fmt.Sprintf("Update complete! Successfully updated %d mod(s).", updatedCount)
-			pterm.Success.Printf("%s\n", finalMsg)
+		}
+		printSummary(finalMsg)
+	}
+
+	// A partial failure skips the hooks: they are meant to restart or
+	// notify on a finished update, not one that left mods behind.
+	if err == nil && updatedCount > 0 {
+		payload := hookPayload{UpdatedCount: updatedCount, Summary: finalMsg, UpdateResult: result}
+		if cfg.PostUpdateHook != "" {
+			if hookErr := runPostUpdateHook(cfg.PostUpdateHook, payload); hookErr != nil {
+				pterm.Warning.Printf("Post-update hook failed: %v\n", hookErr)
+				updater.WriteLog("Post-update hook failed: %v", hookErr)
+			} else {
+				updater.WriteLog("Post-update hook completed: %s", cfg.PostUpdateHook)
+			}
+		}
+		if cfg.WebhookURL != "" {
+			if hookErr := postWebhook(cfg.WebhookURL, payload); hookErr != nil {
+				pterm.Warning.Printf("Webhook delivery failed: %v\n", hookErr)
+				updater.WriteLog("Webhook delivery failed: %v", hookErr)
+			} else {
+				updater.WriteLog("Webhook delivered")
+			}
 		}
 	}
 
@@ -78,27 +257,205 @@ func runUpdateFlow(cfg CLIConfig) error {
 		pterm.Warning.Printf("Failed to write last-mod-update.log: %v\n", logErr)
 	}
 
+	outcome = updateOutcome{Result: result, Message: finalMsg}
 	if err != nil {
-		return fmt.Errorf("failed to complete update: %w", err)
+		return outcome, &partialFailureError{err: fmt.Errorf("failed to complete update: %w", err)}
 	}
-	return nil
+	return outcome, nil
 }
 
-// updatesAvailable returns true if any tracked mod is missing, uninstalled,
-// or has a version that differs from the latest compatible release.
-func updatesAvailable(updater *factorio.Updater) bool {
-	for _, mod := range updater.GetMods() {
-		if mod.Latest == nil {
-			continue
+// enableRequiredMods reports disabled mods that enabled mods require and
+// enables them with --auto-enable or the user's confirmation. It returns
+// whether any were enabled, so the caller persists mod-list.json.
+func enableRequiredMods(cfg CLIConfig, updater *factorio.Updater) bool {
+	deps := updater.DisabledDependencies()
+	if len(deps) == 0 {
+		return false
+	}
+
+	names := make([]string, len(deps))
+	for i, dep := range deps {
+		names[i] = dep.Name
+		line := fmt.Sprintf("%s is disabled but required by %s", dep.Name, strings.Join(dep.RequiredBy, ", "))
+		pterm.Warning.Println(line)
+		updater.WriteLog("WARNING: %s", line)
+	}
+
+	switch {
+	case cfg.AutoEnable:
+	case shouldPrompt(pterm.RawOutput, cfg.Yes):
+		ok, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show(fmt.Sprintf("Enable %d required mod(s)?", len(names)))
+		if !ok {
+			return false
 		}
+	default:
+		pterm.Info.Println("Pass --auto-enable to enable them; Factorio will not start until they are.")
+		return false
+	}
+
+	if err := updater.EnableMods(names); err != nil {
+		pterm.Warning.Printf("Failed to enable required mods: %v\n", err)
+		return false
+	}
+	msg := fmt.Sprintf("Enabled %d required mod(s): %s", len(names), strings.Join(names, ", "))
+	pterm.Info.Println(msg)
+	updater.WriteLog("%s", msg)
+	return true
+}
+
+// updateOutcome is what applyUpdates did: the downloads UpdateMods reported
+// and the message shown to the user at the end.
+type updateOutcome struct {
+	Result  factorio.UpdateResult
+	Message string
+}
+
+// updateErrorHints turns the categorized UpdateMods failures into actionable
+// messages, one per category present in err.
+func updateErrorHints(err error) []string {
+	var hints []string
+	if errors.Is(err, factorio.ErrInvalidCredentials) {
+		hints = append(hints, "Your factorio.com token is invalid or expired. Update it in server-settings.json/player-data.json or pass --username and --token.")
+	}
+	if errors.Is(err, factorio.ErrReleaseNotFound) {
+		hints = append(hints, "A release no longer exists on the mod portal. Re-run the update to pick up the current release.")
+	}
+	var saveErr *factorio.ModListSaveError
+	if errors.As(err, &saveErr) {
+		hints = append(hints, fmt.Sprintf("The downloaded mods are in place. Fix the permissions on %s and re-run, or restore the newest mod-list.<timestamp>.json backup next to it.", saveErr.Path))
+	}
+	return hints
+}
+
+// updateGroups are the headings of pendingUpdateLines, riskiest first.
+var updateGroups = []struct {
+	magnitude factorio.UpdateMagnitude
+	heading   string
+}{
+	{factorio.MagnitudeMajor, "Major updates (check the changelogs before applying):"},
+	{factorio.MagnitudeMinor, "Minor updates:"},
+	{factorio.MagnitudePatch, "Patch updates:"},
+	{factorio.MagnitudeNone, "Reinstalls of the current version:"},
+}
+
+// pendingUpdateLines renders the pending downloads grouped by update
+// magnitude, followed by new installs, omitting empty groups.
+func pendingUpdateLines(pending []*factorio.ModData) []string {
+	groups := make(map[factorio.UpdateMagnitude][]string)
+	var installs []string
+	for _, mod := range pending {
 		if !mod.Installed {
-			return true
+			installs = append(installs, fmt.Sprintf("    %s (new install -> %s)", mod.Title, mod.Latest.Version))
+			continue
 		}
-		if mod.Version != mod.Latest.Version {
-			return true
+		m := factorio.ClassifyUpdate(mod.Version, mod.Latest.Version)
+		groups[m] = append(groups[m], fmt.Sprintf("    %s (%s -> %s)", mod.Title, mod.Version, mod.Latest.Version))
+	}
+
+	var lines []string
+	for _, g := range updateGroups {
+		if len(groups[g.magnitude]) > 0 {
+			lines = append(lines, "  "+g.heading)
+			lines = append(lines, groups[g.magnitude]...)
 		}
 	}
-	return false
+	if len(installs) > 0 {
+		lines = append(lines, "  New installs:")
+		lines = append(lines, installs...)
+	}
+	return lines
+}
+
+// confirmUpdates lists the downloads about to happen along with their total
+// size and, when interactive, asks the user to confirm. It returns false only
+// if the user declined, and an error if the mods filesystem lacks the space.
+func confirmUpdates(ctx context.Context, cfg CLIConfig, updater *factorio.Updater) (bool, error) {
+	pending := updater.PendingDownloads()
+	if len(pending) == 0 {
+		return true, nil
+	}
+
+	if outputEnabled(outputLevel, outputInfo) {
+		pterm.Println("The following mods will be downloaded:")
+		for _, line := range pendingUpdateLines(pending) {
+			pterm.Println(line)
+		}
+		if !cfg.NoPrune {
+			pterm.Println("Older releases of these mods will be removed afterwards.")
+		}
+	}
+
+	est := updater.EstimateDownloads(ctx, pending)
+	msg := fmt.Sprintf("About to download %s across %d mod(s)", factorio.FormatBytes(est.Bytes), est.Mods)
+	if est.Unknown > 0 {
+		msg += fmt.Sprintf(" (size unknown for %d)", est.Unknown)
+	}
+	pterm.Info.Println(msg)
+	updater.WriteLog("%s", msg)
+
+	if err := updater.CheckDiskSpace(est.Bytes); err != nil {
+		return false, err
+	}
+
+	if !shouldPrompt(pterm.RawOutput, cfg.Yes) {
+		return true, nil
+	}
+	ok, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show("Proceed with the update?")
+	return ok, nil
+}
+
+// shouldPrompt decides whether to ask for interactive confirmation. Raw
+// output means there is no terminal to answer (cron, panels, pipes), and
+// --yes opts out explicitly.
+func shouldPrompt(rawOutput, yes bool) bool {
+	return !rawOutput && !yes
+}
+
+// parseByteSize parses a size such as "1GiB", "500MiB", "64KiB", or a bare
+// byte count. Units are binary and case-insensitive; "GB" is read as GiB.
+func parseByteSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"B", 1},
+	}
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			factor = unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q: expected a positive number with an optional unit such as 1GiB", s)
+	}
+	if n > math.MaxInt64/factor {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return n * factor, nil
+}
+
+// updatesAvailable returns true if any tracked mod awaits an update.
+func updatesAvailable(mods []*factorio.ModData, force bool) bool {
+	return slices.ContainsFunc(mods, func(mod *factorio.ModData) bool {
+		return awaitsUpdate(mod, force)
+	})
+}
+
+// awaitsUpdate reports whether mod is uninstalled or has a version that
+// differs from the latest compatible release. A mod installed as a
+// directory does not count without force, since UpdateMods leaves it alone.
+func awaitsUpdate(mod *factorio.ModData, force bool) bool {
+	if mod.Latest == nil || (mod.InstallDir != "" && !force) {
+		return false
+	}
+	return !mod.Installed || mod.Version != mod.Latest.Version
 }
 
 func init() {
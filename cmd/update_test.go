@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"factorio-updater/internal/factorio"
+)
+
+func TestShouldPrompt(t *testing.T) {
+	tests := []struct {
+		name      string
+		rawOutput bool
+		yes       bool
+		want      bool
+	}{
+		{"interactive terminal prompts", false, false, true},
+		{"--yes skips prompt", false, true, false},
+		{"raw output skips prompt", true, false, false},
+		{"raw output with --yes skips prompt", true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldPrompt(tt.rawOutput, tt.yes); got != tt.want {
+				t.Errorf("shouldPrompt(%v, %v) = %v; want %v", tt.rawOutput, tt.yes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateErrorHints(t *testing.T) {
+	saveErr := &factorio.ModListSaveError{Path: "/srv/mods/mod-list.json", Err: errors.New("permission denied")}
+	tests := []struct {
+		name      string
+		err       error
+		wantHints int
+	}{
+		{"invalid credentials", fmt.Errorf("downloading %q: %w", "foo", factorio.ErrInvalidCredentials), 1},
+		{"release not found", errors.Join(errors.New("other"), factorio.ErrReleaseNotFound), 1},
+		{"mod-list save failure", errors.Join(saveErr), 1},
+		{"credentials and save failure", errors.Join(factorio.ErrInvalidCredentials, saveErr), 2},
+		{"uncategorized", errors.New("download returned status 500"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateErrorHints(tt.err); len(got) != tt.wantHints {
+				t.Errorf("updateErrorHints(%v) = %q; want %d hint(s)", tt.err, got, tt.wantHints)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"1GiB", 1 << 30, false},
+		{"500MiB", 500 << 20, false},
+		{"64kib", 64 << 10, false},
+		{"2GB", 2 << 30, false},
+		{"4096", 4096, false},
+		{"10 MiB", 10 << 20, false},
+		{"", 0, true},
+		{"0", 0, true},
+		{"-5MiB", 0, true},
+		{"lots", 0, true},
+		{"99999999999TiB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseByteSize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) error = %v; wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d; want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingUpdateLines(t *testing.T) {
+	mod := func(title, from, to string) *factorio.ModData {
+		return &factorio.ModData{Title: title, Installed: from != "", Version: from, Latest: &factorio.ModRelease{Version: to}}
+	}
+	pending := []*factorio.ModData{
+		mod("Flib", "0.16.1", "0.16.2"),
+		mod("Helmod", "1.9.0", "2.0.0"),
+		mod("Jetpack", "", "0.4.15"),
+		mod("Krastorio 2", "1.3.0", "1.4.0"),
+		mod("Rate Calculator", "3.0.0", "3.0.1"),
+	}
+	want := []string{
+		"  Major updates (check the changelogs before applying):",
+		"    Helmod (1.9.0 -> 2.0.0)",
+		"  Minor updates:",
+		"    Krastorio 2 (1.3.0 -> 1.4.0)",
+		"  Patch updates:",
+		"    Flib (0.16.1 -> 0.16.2)",
+		"    Rate Calculator (3.0.0 -> 3.0.1)",
+		"  New installs:",
+		"    Jetpack (new install -> 0.4.15)",
+	}
+	if got := pendingUpdateLines(pending); !slices.Equal(got, want) {
+		t.Errorf("pendingUpdateLines() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
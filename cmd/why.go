@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"factorio-updater/internal/factorio"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// whyCmd defines the "why" subcommand, which explains whether a mod was
+// chosen directly or pulled in, and by which chains of dependents.
+var whyCmd = &cobra.Command{
+	Use:   "why MOD [ROOT_DIR]",
+	Short: "Explain why a mod is part of the installed mod set",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := args[0]
+		cfg := parseConfig(cmd, args[1:])
+		ctx, cancel := runContext(cfg)
+		defer cancel()
+		updater, err := buildUpdater(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		_ = resolveWithUI(ctx, updater, "Why")
+
+		mod, ok := updater.IsTracked(target)
+		if !ok {
+			return fmt.Errorf("mod %q is not installed, listed, or required by any tracked mod", target)
+		}
+		chains := updater.DependentChains(target)
+
+		pterm.Println()
+		if mod.Source == factorio.FromModList {
+			pterm.Printf("%s was chosen directly (listed in mod-list.json or installed by hand).\n", target)
+		} else {
+			pterm.Printf("%s was pulled in as a dependency.\n", target)
+		}
+
+		if len(chains) == 0 {
+			pterm.Printf("No other mod requires %s.\n", target)
+			return nil
+		}
+
+		pterm.Printf("Required through %d chain(s):\n", len(chains))
+		for _, chain := range chains {
+			pterm.Println("  " + strings.Join(chain, " -> "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whyCmd)
+}
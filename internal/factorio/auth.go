@@ -0,0 +1,128 @@
+package factorio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCredentials reports that the mod portal rejected the configured
+// factorio.com username and token.
+var ErrInvalidCredentials = errors.New("invalid credentials: the mod portal rejected the factorio.com username/token")
+
+// ValidateCredentials confirms the username and token are accepted by the mod
+// portal by requesting the newest release of one tracked mod without
+// downloading its body. It returns nil when no tracked mod has a release to
+// probe, an error wrapping ErrInvalidCredentials when authentication fails,
+// and ErrOffline in offline mode.
+// Why: Metadata requests are unauthenticated, so a stale token otherwise only
+// surfaces as a wave of failed downloads after the whole graph is resolved.
+func (u *Updater) ValidateCredentials(ctx context.Context) error {
+	if u.offline {
+		return ErrOffline
+	}
+	probe, err := u.credentialProbe(ctx)
+	if err != nil || probe == nil {
+		return err
+	}
+
+	dlURL, err := u.downloadURL(probe)
+	if err != nil {
+		return fmt.Errorf("building credential check URL: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dlURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating credential check request: %w", err)
+	}
+	u.modifyRequest(req)
+	u.debugf("HEAD %s", redactURL(dlURL))
+
+	// Inspect the portal's own answer rather than following it: a rejected
+	// token is redirected to the login page, which itself returns 200.
+	client := *u.httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("checking credentials: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return ErrInvalidCredentials
+	case resp.StatusCode >= 300 && resp.StatusCode < 400 && strings.Contains(resp.Header.Get("Location"), "/login"):
+		return ErrInvalidCredentials
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("credential check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// credentialProbe returns the newest release of the first tracked mod, in
+// GetMods order, that the portal has a release for, or nil if there is none.
+// Mods installed as directories are skipped: they are often unpublished.
+func (u *Updater) credentialProbe(ctx context.Context) (*ModRelease, error) {
+	for _, m := range u.GetMods() {
+		if m.InstallDir != "" {
+			continue
+		}
+		rel, err := u.probeRelease(ctx, m.Name)
+		if err != nil || rel != nil {
+			return rel, err
+		}
+	}
+	return nil, nil
+}
+
+// probeRelease fetches the short metadata of name and returns its newest
+// release, or nil if the portal does not know the mod or it has none.
+func (u *Updater) probeRelease(ctx context.Context, name string) (*ModRelease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	apiURL := fmt.Sprintf("%s/api/mods/%s", u.modServerURL, url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for mod %q: %w", name, err)
+	}
+	u.modifyRequest(req)
+	u.debugf("GET %s", apiURL)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching metadata for mod %q: %w", name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("mod portal returned status %d for %q", resp.StatusCode, name)
+	}
+
+	var meta ModPortalMetadata
+	if err := json.NewDecoder(limitedBody(resp.Body, maxAPIResponseBytes)).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("decoding metadata for mod %q: %w", name, err)
+	}
+
+	var newest *ModRelease
+	for i := range meta.Releases {
+		rel := &meta.Releases[i]
+		if newest == nil || compareVersions(rel.Version, newest.Version) > 0 {
+			newest = rel
+		}
+	}
+	return newest, nil
+}
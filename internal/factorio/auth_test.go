@@ -0,0 +1,113 @@
+package factorio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestValidateCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/mods/probe":
+			_ = json.NewEncoder(w).Encode(ModPortalMetadata{
+				Releases: []ModRelease{
+					{Version: "1.0.9", DownloadURL: "/download/probe/old"},
+					{Version: "1.0.10", DownloadURL: "/download/probe/new"},
+				},
+			})
+		case "/download/probe/new":
+			if r.Method != http.MethodHead {
+				t.Errorf("credential check used %s; want HEAD", r.Method)
+			}
+			switch r.URL.Query().Get("token") {
+			case "good":
+				w.WriteHeader(http.StatusOK)
+			case "stale":
+				http.Redirect(w, r, "/login?next=/download/probe/new", http.StatusFound)
+			default:
+				w.WriteHeader(http.StatusForbidden)
+			}
+		case "/login":
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid token", "good", nil},
+		{"forbidden token", "bad", ErrInvalidCredentials},
+		{"login redirect", "stale", ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Updater{
+				modServerURL: server.URL,
+				username:     "engineer",
+				token:        tt.token,
+				httpClient:   server.Client(),
+				mods:         map[string]*ModData{"probe": {Name: "probe", Title: "probe"}},
+			}
+			err := u.ValidateCredentials(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateCredentials() = %v; want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCredentialsSkipsUnpublishedMods(t *testing.T) {
+	var probed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probed = append(probed, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/mods/probe":
+			_ = json.NewEncoder(w).Encode(ModPortalMetadata{
+				Releases: []ModRelease{{Version: "1.0.0", DownloadURL: "/download/probe/1.0.0"}},
+			})
+		case "/download/probe/1.0.0":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	u := &Updater{
+		modServerURL: server.URL,
+		username:     "engineer",
+		token:        "good",
+		httpClient:   server.Client(),
+		mods: map[string]*ModData{
+			"a-local":   {Name: "a-local", Title: "a-local", InstallDir: "a-local"},
+			"b-private": {Name: "b-private", Title: "b-private"},
+			"probe":     {Name: "probe", Title: "probe"},
+		},
+	}
+	if err := u.ValidateCredentials(context.Background()); err != nil {
+		t.Fatalf("ValidateCredentials() = %v; want the next published mod probed", err)
+	}
+	want := []string{"/api/mods/b-private", "/api/mods/probe", "/download/probe/1.0.0"}
+	if !slices.Equal(probed, want) {
+		t.Errorf("requests = %v; want %v", probed, want)
+	}
+}
+
+func TestValidateCredentialsNoMods(t *testing.T) {
+	u := &Updater{mods: make(map[string]*ModData)}
+	if err := u.ValidateCredentials(context.Background()); err != nil {
+		t.Errorf("ValidateCredentials() with no tracked mods = %v; want nil", err)
+	}
+}
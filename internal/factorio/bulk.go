@@ -0,0 +1,97 @@
+package factorio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// bulkBatchSize is the most mod names sent in one bulk metadata request,
+// keeping the query string well under common URL length limits.
+const bulkBatchSize = 100
+
+// bulkModResult is one entry of the portal's /api/mods result list. Its
+// releases carry only the factorio_version part of info.json, so they are
+// enough to pick a release but not to follow dependencies.
+type bulkModResult struct {
+	Name string `json:"name"`
+	ModPortalMetadata
+}
+
+// bulkModResponse is the body of a /api/mods?namelist=... request.
+type bulkModResponse struct {
+	Results []bulkModResult `json:"results"`
+}
+
+// prefetchBulkMetadata fetches the short metadata of names from the bulk
+// endpoint, bulkBatchSize mods per request. Mods the portal does not list
+// are absent from the result so their /full fetch reports the real error.
+func (u *Updater) prefetchBulkMetadata(ctx context.Context, names []string) (map[string]ModPortalMetadata, error) {
+	out := make(map[string]ModPortalMetadata, len(names))
+	for start := 0; start < len(names); start += bulkBatchSize {
+		batch := names[start:min(start+bulkBatchSize, len(names))]
+		results, err := u.fetchBulkBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			out[r.Name] = r.ModPortalMetadata
+		}
+	}
+	return out, nil
+}
+
+// fetchBulkBatch sends one bulk metadata request for names.
+func (u *Updater) fetchBulkBatch(ctx context.Context, names []string) ([]bulkModResult, error) {
+	query := url.Values{"namelist": {strings.Join(names, ",")}, "page_size": {"max"}}
+	apiURL := fmt.Sprintf("%s/api/mods?%s", u.modServerURL, query.Encode())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating bulk metadata request: %w", err)
+	}
+	u.modifyRequest(req)
+	u.debugf("GET %s", apiURL)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching bulk metadata: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("mod portal returned status %d for bulk metadata", resp.StatusCode)
+	}
+
+	var body bulkModResponse
+	if err := json.NewDecoder(limitedBody(resp.Body, maxAPIResponseBytes)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding bulk metadata: %w", err)
+	}
+	return body.Results, nil
+}
+
+// bulkCandidates returns the names worth asking the bulk endpoint about:
+// none unless dependencies are skipped and the network is used, and never
+// those a fresh cache entry already answers. Strict dependency checks need
+// the dependency lists that only /full returns, so they disable it too.
+func (u *Updater) bulkCandidates(names []string) []string {
+	if !u.noDeps || u.offline || u.strictDeps {
+		return nil
+	}
+	now := time.Now().UTC()
+	var out []string
+	for _, name := range names {
+		if _, ok := u.metaCache.fresh(name, now); ok && !u.refreshMetadata {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
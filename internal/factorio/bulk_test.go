@@ -0,0 +1,142 @@
+package factorio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// bulkFixture is a trimmed /api/mods?namelist=... response; only the fields
+// the updater reads are kept.
+const bulkFixture = `{
+  "pagination": {"count": 2, "page": 1, "page_count": 1, "page_size": 2},
+  "results": [
+    {
+      "name": "helmod",
+      "title": "Helmod",
+      "latest_release": {"version": "2.2.12"},
+      "releases": [
+        {"version": "1.9.0", "file_name": "helmod_1.9.0.zip", "download_url": "/download/helmod/1", "sha1": "aaa", "info_json": {"factorio_version": "1.1"}},
+        {"version": "2.2.12", "file_name": "helmod_2.2.12.zip", "download_url": "/download/helmod/2", "sha1": "bbb", "info_json": {"factorio_version": "2.0"}}
+      ]
+    },
+    {
+      "name": "jetpack",
+      "title": "Jetpack",
+      "releases": [
+        {"version": "0.4.0", "file_name": "jetpack_0.4.0.zip", "download_url": "/download/jetpack/1", "sha1": "ccc", "info_json": {"factorio_version": "2.0"}}
+      ]
+    }
+  ]
+}`
+
+func TestResolveMetadataUsesBulkEndpoint(t *testing.T) {
+	var bulkHits, fullHits atomic.Int32
+	var namelist atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/mods" {
+			bulkHits.Add(1)
+			namelist.Store(r.URL.Query().Get("namelist"))
+			_, _ = w.Write([]byte(bulkFixture))
+			return
+		}
+		// Only the mod missing from the bulk response falls back to /full.
+		fullHits.Add(1)
+		if !strings.HasSuffix(r.URL.Path, "/gone/full") {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"helmod","enabled":true},{"name":"jetpack","enabled":true},{"name":"gone","enabled":true}]}`), 0644)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), noDeps: true, mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+
+	err := u.ResolveMetadata(context.Background(), nil)
+	var resolveErr *ResolveError
+	if !errors.As(err, &resolveErr) || len(resolveErr.Errs) != 1 {
+		t.Fatalf("ResolveMetadata() error = %v; want only gone to fail", err)
+	}
+
+	if n := bulkHits.Load(); n != 1 {
+		t.Errorf("bulk endpoint was queried %d times; want 1", n)
+	}
+	if got := namelist.Load(); got != "gone,helmod,jetpack" {
+		t.Errorf("namelist = %q; want every tracked mod", got)
+	}
+	if n := fullHits.Load(); n != 1 {
+		t.Errorf("/full was queried %d times; want only for gone", n)
+	}
+	for name, want := range map[string]string{"helmod": "2.2.12", "jetpack": "0.4.0"} {
+		m := u.mods[name]
+		if m.Latest == nil || m.Latest.Version != want {
+			t.Errorf("%s Latest = %+v; want %s", name, m.Latest, want)
+		}
+	}
+	if m := u.mods["helmod"]; m.Title != "Helmod" || m.Latest.Sha1 != "bbb" {
+		t.Errorf("helmod = %+v; want title and hash from the bulk response", m)
+	}
+}
+
+func TestResolveMetadataStrictDepsSkipsBulkEndpoint(t *testing.T) {
+	var bulkHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/mods" {
+			bulkHits.Add(1)
+			_, _ = w.Write([]byte(bulkFixture))
+			return
+		}
+		_, _ = w.Write([]byte(`{"title": "Helmod", "releases": [{"version": "2.2.12", "file_name": "helmod_2.2.12.zip",
+			"download_url": "/download/helmod/2", "sha1": "bbb", "info_json": {"factorio_version": "2.0", "dependencies": ["flib >= 0.16.0"]}}]}`))
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"helmod","enabled":true}]}`), 0644)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(),
+		noDeps: true, strictDeps: true, logLevel: LogQuiet, mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+
+	err := u.ResolveMetadata(context.Background(), nil)
+	var depErr *UnsatisfiedDependenciesError
+	if !errors.As(err, &depErr) || len(depErr.Errs) != 1 || depErr.Errs[0].Dependency != "flib" {
+		t.Errorf("ResolveMetadata() error = %v; want the untracked flib dependency reported", err)
+	}
+	if n := bulkHits.Load(); n != 0 {
+		t.Errorf("bulk endpoint was queried %d times; want none, it omits dependencies", n)
+	}
+}
+
+func TestBulkCandidates(t *testing.T) {
+	names := []string{"a", "b"}
+	tests := []struct {
+		name string
+		u    *Updater
+		want int
+	}{
+		{"dependencies followed", &Updater{}, 0},
+		{"no deps", &Updater{noDeps: true}, 2},
+		{"offline", &Updater{noDeps: true, offline: true}, 0},
+		{"strict dependencies", &Updater{noDeps: true, strictDeps: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.u.bulkCandidates(names); len(got) != tt.want {
+				t.Errorf("bulkCandidates() = %v; want %d names", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package factorio
+
+import "fmt"
+
+// freeSpace reports the bytes available to unprivileged users on the
+// filesystem holding path. It is a variable so tests can stub the platform
+// call.
+var freeSpace = diskFree
+
+// hasEnoughSpace reports whether the filesystem holding path has at least
+// needed bytes available.
+func hasEnoughSpace(path string, needed uint64) (bool, error) {
+	free, err := freeSpace(path)
+	if err != nil {
+		return false, fmt.Errorf("checking free space on %s: %w", path, err)
+	}
+	return free >= needed, nil
+}
+
+// CheckDiskSpace returns an error when the mods directory cannot hold needed
+// more bytes. Failing to query free space is reported as a warning only,
+// since the download itself may still succeed.
+// Why: A partition filling up mid-run leaves a half-written update behind,
+// which is worse than not starting it.
+func (u *Updater) CheckDiskSpace(needed int64) error {
+	if needed <= 0 {
+		return nil
+	}
+	ok, err := hasEnoughSpace(u.modPath, uint64(needed))
+	if err != nil {
+		u.WriteLog("WARNING: %v", err)
+		return nil
+	}
+	if !ok {
+		free, _ := freeSpace(u.modPath)
+		return fmt.Errorf("not enough disk space in %s: the update needs %d bytes but only %d are free; free up space and re-run", u.modPath, needed, free)
+	}
+	return nil
+}
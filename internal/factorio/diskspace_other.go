@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package factorio
+
+import "errors"
+
+// diskFree is unsupported on this platform, which disables the space check.
+func diskFree(string) (uint64, error) {
+	return 0, errors.New("free space query unsupported on this platform")
+}
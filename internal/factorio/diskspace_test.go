@@ -0,0 +1,77 @@
+package factorio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHasEnoughSpace(t *testing.T) {
+	orig := freeSpace
+	t.Cleanup(func() { freeSpace = orig })
+
+	tests := []struct {
+		name    string
+		free    uint64
+		freeErr error
+		needed  uint64
+		want    bool
+		wantErr bool
+	}{
+		{"plenty of space", 10 << 30, nil, 1 << 30, true, false},
+		{"exactly enough", 1 << 30, nil, 1 << 30, true, false},
+		{"one byte short", 1<<30 - 1, nil, 1 << 30, false, false},
+		{"statfs failure", 0, errors.New("no such device"), 1, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queried string
+			freeSpace = func(path string) (uint64, error) {
+				queried = path
+				return tt.free, tt.freeErr
+			}
+
+			got, err := hasEnoughSpace("/srv/factorio/mods", tt.needed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hasEnoughSpace() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("hasEnoughSpace() = %v; want %v", got, tt.want)
+			}
+			if queried != "/srv/factorio/mods" {
+				t.Errorf("queried %q; want the mods path", queried)
+			}
+		})
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	orig := freeSpace
+	t.Cleanup(func() { freeSpace = orig })
+
+	u := &Updater{modPath: "/srv/factorio/mods"}
+
+	freeSpace = func(string) (uint64, error) { return 100, nil }
+	if err := u.CheckDiskSpace(500); err == nil || !strings.Contains(err.Error(), "not enough disk space") {
+		t.Errorf("CheckDiskSpace(500) with 100 free = %v; want a not-enough-space error", err)
+	}
+	if err := u.CheckDiskSpace(50); err != nil {
+		t.Errorf("CheckDiskSpace(50) with 100 free = %v; want nil", err)
+	}
+
+	freeSpace = func(string) (uint64, error) { return 0, errors.New("unsupported") }
+	if err := u.CheckDiskSpace(500); err != nil {
+		t.Errorf("CheckDiskSpace() should not fail when free space is unknown, got %v", err)
+	}
+}
+
+func TestDiskFreeOnTempDir(t *testing.T) {
+	free, err := diskFree(t.TempDir())
+	if err != nil {
+		t.Skipf("free space query unavailable here: %v", err)
+	}
+	if free == 0 {
+		t.Error("expected a temp directory to report some free space")
+	}
+}
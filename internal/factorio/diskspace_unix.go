@@ -0,0 +1,14 @@
+//go:build unix
+
+package factorio
+
+import "golang.org/x/sys/unix"
+
+// diskFree queries statfs for the space available to unprivileged users.
+func diskFree(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil //nolint:unconvert // field types differ per platform
+}
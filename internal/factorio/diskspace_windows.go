@@ -0,0 +1,18 @@
+//go:build windows
+
+package factorio
+
+import "golang.org/x/sys/windows"
+
+// diskFree queries GetDiskFreeSpaceEx for the space available to the caller.
+func diskFree(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &available, nil, nil); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,90 @@
+package factorio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// downloadCachePath returns where a release is kept in the shared download
+// cache, keyed by its published digest and file name.
+func (u *Updater) downloadCachePath(fileName, digest string) string {
+	return filepath.Join(u.downloadCache, strings.ToLower(digest)+"-"+fileName)
+}
+
+// installFromCache places a cached copy of a release at targetPath if the
+// cache holds one that passes hash validation, and reports whether it did.
+func (u *Updater) installFromCache(targetPath string, algo HashAlgo, expected string, log logger) bool {
+	if u.downloadCache == "" || expected == "" {
+		return false
+	}
+	cachePath := u.downloadCachePath(filepath.Base(targetPath), expected)
+	if !validateHash(algo, expected, cachePath) {
+		return false
+	}
+	if err := u.linkOrCopy(cachePath, targetPath); err != nil {
+		log.WriteLog("WARNING: using cached %s: %v", filepath.Base(targetPath), err)
+		return false
+	}
+	return true
+}
+
+// storeInCache adds a freshly downloaded and validated release to the
+// download cache. Failures only warn, since the download itself succeeded.
+func (u *Updater) storeInCache(targetPath, expected string, log logger) {
+	if u.downloadCache == "" || expected == "" {
+		return
+	}
+	if err := os.MkdirAll(u.downloadCache, 0755); err != nil {
+		log.WriteLog("WARNING: creating download cache: %v", err)
+		return
+	}
+	cachePath := u.downloadCachePath(filepath.Base(targetPath), expected)
+	if err := u.linkOrCopy(targetPath, cachePath); err != nil {
+		log.WriteLog("WARNING: adding %s to the download cache: %v", filepath.Base(targetPath), err)
+	}
+}
+
+// linkOrCopy makes dst a hard link to src, copying instead when the two are
+// on different filesystems. The result is put in place with a rename so
+// concurrent runs never see a partial file.
+func (u *Updater) linkOrCopy(src, dst string) error {
+	tmpPath := dst + ".tmp"
+	_ = os.Remove(tmpPath)
+	if err := os.Link(src, tmpPath); err != nil {
+		if err := u.copyFile(src, tmpPath); err != nil {
+			_ = os.Remove(tmpPath)
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("renaming into place: %w", err)
+	}
+	return u.syncDir(filepath.Dir(dst))
+}
+
+// copyFile copies src to a new file at dst and syncs it.
+func (u *Updater) copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", src, err)
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("copying to %s: %w", dst, err)
+	}
+	if err := u.syncFile(out); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("syncing %s: %w", dst, err)
+	}
+	return out.Close()
+}
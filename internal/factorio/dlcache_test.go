@@ -0,0 +1,105 @@
+package factorio
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadLatestUsesDownloadCache(t *testing.T) {
+	content := []byte("cached release")
+	h := sha1.New()
+	h.Write(content)
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	newUpdater := func(cacheDir string) *Updater {
+		return &Updater{modServerURL: server.URL, modPath: t.TempDir(), httpClient: server.Client(), noFsync: true,
+			downloadCache: cacheDir, mods: map[string]*ModData{
+				"helmod": {Name: "helmod", Title: "Helmod", Latest: &ModRelease{
+					Version: "2.2.12", FileName: "helmod_2.2.12.zip", DownloadURL: "/download/helmod", Sha1: digest,
+				}},
+			}}
+	}
+	assertInstalled := func(t *testing.T, u *Updater) {
+		t.Helper()
+		got, err := os.ReadFile(filepath.Join(u.modPath, "helmod_2.2.12.zip"))
+		if err != nil || string(got) != string(content) {
+			t.Errorf("installed release = %q, %v; want the release content", got, err)
+		}
+	}
+
+	t.Run("miss downloads and populates the cache", func(t *testing.T) {
+		hits.Store(0)
+		cacheDir := filepath.Join(t.TempDir(), "cache")
+		u := newUpdater(cacheDir)
+		if err := u.downloadLatest(context.Background(), "helmod", nil, u); err != nil {
+			t.Fatalf("downloadLatest() returned unexpected error: %v", err)
+		}
+		if n := hits.Load(); n != 1 {
+			t.Errorf("portal was queried %d times; want 1", n)
+		}
+		assertInstalled(t, u)
+		if !validateHash(HashSHA1, digest, u.downloadCachePath("helmod_2.2.12.zip", digest)) {
+			t.Error("download cache should hold the validated release")
+		}
+	})
+
+	t.Run("hit makes no request", func(t *testing.T) {
+		hits.Store(0)
+		cacheDir := t.TempDir()
+		u := newUpdater(cacheDir)
+		var events []Event
+		u.onEvent = func(ev Event) {
+			ev.Time = time.Time{}
+			events = append(events, ev)
+		}
+		_ = os.WriteFile(u.downloadCachePath("helmod_2.2.12.zip", digest), content, 0644)
+		if err := u.downloadLatest(context.Background(), "helmod", nil, u); err != nil {
+			t.Fatalf("downloadLatest() returned unexpected error: %v", err)
+		}
+		if n := hits.Load(); n != 0 {
+			t.Errorf("portal was queried %d times; want none", n)
+		}
+		assertInstalled(t, u)
+		want := []Event{
+			{Type: EventDownloadStart, Mod: "helmod", Version: "2.2.12"},
+			{Type: EventDownloadDone, Mod: "helmod", Version: "2.2.12", Bytes: int64(len(content))},
+		}
+		if !slices.Equal(events, want) {
+			t.Errorf("events = %+v; want %+v", events, want)
+		}
+	})
+
+	t.Run("corrupt cache entry is downloaded again", func(t *testing.T) {
+		hits.Store(0)
+		cacheDir := t.TempDir()
+		u := newUpdater(cacheDir)
+		cachePath := u.downloadCachePath("helmod_2.2.12.zip", digest)
+		_ = os.WriteFile(cachePath, []byte("truncated"), 0644)
+		if err := u.downloadLatest(context.Background(), "helmod", nil, u); err != nil {
+			t.Fatalf("downloadLatest() returned unexpected error: %v", err)
+		}
+		if n := hits.Load(); n != 1 {
+			t.Errorf("portal was queried %d times; want 1", n)
+		}
+		assertInstalled(t, u)
+		if !validateHash(HashSHA1, digest, cachePath) {
+			t.Error("the corrupt cache entry should be replaced")
+		}
+	})
+}
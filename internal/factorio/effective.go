@@ -0,0 +1,112 @@
+package factorio
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Concurrency limits and per-request timeouts for mod portal traffic.
+const (
+	metadataWorkers = 10               // concurrent metadata fetches
+	downloadWorkers = 5                // concurrent release downloads
+	metadataTimeout = 15 * time.Second // one metadata request
+	downloadTimeout = 5 * time.Minute  // one release download
+)
+
+// EffectiveConfig is the configuration an Updater resolved from its options,
+// the config files, and the Factorio installation.
+type EffectiveConfig struct {
+	ModPath         string
+	FactPath        string
+	FactorioVersion string
+	SettingsPath    string
+	DataPath        string
+	Username        string
+	UsernameSource  string
+	// MaskedToken shows only enough of the token to tell two apart.
+	MaskedToken string
+	TokenSource string
+	ServerURL   string
+	// MirrorURL is where releases are downloaded from instead of ServerURL,
+	// or "".
+	MirrorURL       string
+	MetadataWorkers int
+	DownloadWorkers int
+	HashWorkers     int
+	MetadataTimeout time.Duration
+	DownloadTimeout time.Duration
+}
+
+// EffectiveConfig reports what the Updater resolved, with the token masked.
+// Why: Path and credential problems are hard to debug without seeing which
+// files and values were actually picked up.
+func (u *Updater) EffectiveConfig() EffectiveConfig {
+	return EffectiveConfig{
+		ModPath:         u.modPath,
+		FactPath:        u.factPath,
+		FactorioVersion: u.factVersion,
+		SettingsPath:    u.settingsPath,
+		DataPath:        u.dataPath,
+		Username:        u.username,
+		UsernameSource:  u.usernameSource,
+		MaskedToken:     maskSecret(u.token),
+		TokenSource:     u.tokenSource,
+		ServerURL:       u.modServerURL,
+		MirrorURL:       u.mirrorURL,
+		MetadataWorkers: metadataWorkers,
+		DownloadWorkers: downloadWorkers,
+		HashWorkers:     runtime.NumCPU(),
+		MetadataTimeout: metadataTimeout,
+		DownloadTimeout: downloadTimeout,
+	}
+}
+
+// ConfigInspection is what InspectConfig resolved, with the failure of each
+// step that could not complete.
+type ConfigInspection struct {
+	EffectiveConfig
+	// CredentialErr reports a server-settings.json or player-data.json that
+	// could not be read.
+	CredentialErr error
+	// VersionErr reports a Factorio version that could not be determined.
+	VersionErr error
+	// MirrorErr reports an invalid Options.Mirror.
+	MirrorErr error
+}
+
+// InspectConfig resolves the credentials, their sources, and the Factorio
+// version the way NewUpdater does, but keeps going past each failure and
+// leaves the mods directory, the caches, and the network alone.
+// Why: "config show" is run when NewUpdater fails, so it must not depend on
+// NewUpdater succeeding.
+func InspectConfig(opts Options) ConfigInspection {
+	u := newUpdater(opts)
+	var insp ConfigInspection
+	if opts.Mirror != "" {
+		u.mirrorURL, insp.MirrorErr = parseMirrorURL(opts.Mirror)
+	}
+	if u.username == "" || u.token == "" {
+		_ = u.parseTokens()
+		insp.CredentialErr = errors.Join(u.credentialErrs...)
+	}
+	switch {
+	case opts.FactorioVersion != "":
+		u.factVersion, insp.VersionErr = majorMinor(opts.FactorioVersion)
+	case u.factPath != "":
+		insp.VersionErr = u.determineVersion()
+	}
+	insp.EffectiveConfig = u.EffectiveConfig()
+	return insp
+}
+
+// maskSecret keeps the last four characters of a secret for recognition
+// and replaces the rest with asterisks. Secrets of eight characters or
+// fewer are masked entirely.
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-4) + secret[len(secret)-4:]
+}
@@ -0,0 +1,65 @@
+package factorio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		secret string
+		want   string
+	}{
+		{"", ""},
+		{"short", "*****"},
+		{"12345678", "********"},
+		{"0123456789abcdef", "************cdef"},
+	}
+	for _, tt := range tests {
+		if got := maskSecret(tt.secret); got != tt.want {
+			t.Errorf("maskSecret(%q) = %q; want %q", tt.secret, got, tt.want)
+		}
+	}
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	u := &Updater{modPath: "/srv/factorio/mods", factVersion: "2.0", modServerURL: "https://mods.factorio.com",
+		username: "alice", usernameSource: "/srv/factorio/player-data.json", token: "0123456789abcdef"}
+	got := u.EffectiveConfig()
+	if got.MaskedToken != "************cdef" {
+		t.Errorf("MaskedToken = %q; want the token masked", got.MaskedToken)
+	}
+	if got.UsernameSource != "/srv/factorio/player-data.json" || got.ModPath != "/srv/factorio/mods" || got.FactorioVersion != "2.0" {
+		t.Errorf("EffectiveConfig() = %+v; want the Updater's resolved values", got)
+	}
+	if got.DownloadWorkers != downloadWorkers || got.DownloadTimeout != downloadTimeout {
+		t.Errorf("download settings = %d, %v; want %d, %v", got.DownloadWorkers, got.DownloadTimeout, downloadWorkers, downloadTimeout)
+	}
+}
+
+func TestInspectConfigKeepsGoingPastFailures(t *testing.T) {
+	root := t.TempDir()
+	modDir := filepath.Join(root, "mods")
+	_ = os.MkdirAll(modDir, 0755)
+	stale := filepath.Join(modDir, "flib_0.16.2.zip.tmp")
+	_ = os.WriteFile(stale, nil, 0644)
+	_ = os.WriteFile(filepath.Join(root, "server-settings.json"), []byte(`{not json`), 0600)
+	_ = os.WriteFile(filepath.Join(root, "player-data.json"), []byte(`{"service-username": "alice", "service-token": "0123456789abcdef"}`), 0600)
+
+	insp := InspectConfig(Options{ModPath: modDir, FactPath: filepath.Join(root, "missing-binary"), LogLevel: LogQuiet})
+	if insp.CredentialErr == nil {
+		t.Error("CredentialErr = nil; want the unparseable server-settings.json reported")
+	}
+	if insp.Username != "alice" || insp.TokenSource != filepath.Join(root, "player-data.json") {
+		t.Errorf("credentials = %q from %q; want alice from player-data.json", insp.Username, insp.TokenSource)
+	}
+	var probeErr *VersionProbeError
+	if !errors.As(insp.VersionErr, &probeErr) {
+		t.Errorf("VersionErr = %v; want a *VersionProbeError", insp.VersionErr)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Errorf("InspectConfig() touched the mods directory: %v", err)
+	}
+}
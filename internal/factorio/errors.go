@@ -0,0 +1,195 @@
+package factorio
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MetadataErrorKind classifies why metadata for a mod could not be fetched.
+type MetadataErrorKind int
+
+const (
+	// MetadataOther covers decoding failures and anything unclassified.
+	MetadataOther MetadataErrorKind = iota
+	// MetadataNotFound means the portal has no mod by that name (HTTP 404).
+	MetadataNotFound
+	// MetadataNetwork means the request never got an answer and may succeed on retry.
+	MetadataNetwork
+	// MetadataBadStatus means the portal answered with a non-404 error status.
+	MetadataBadStatus
+	// MetadataPinMissing means the pinned release does not exist on the portal.
+	MetadataPinMissing
+	// MetadataOffline means offline mode found no cached metadata for the mod.
+	MetadataOffline
+	// MetadataOrphaned means the portal no longer has a mod that is still
+	// installed on disk, as when it was taken down.
+	MetadataOrphaned
+)
+
+// describe renders a group of n failures of this kind for the summary.
+func (k MetadataErrorKind) describe(n int) string {
+	mods := "mods"
+	if n == 1 {
+		mods = "mod"
+	}
+	switch k {
+	case MetadataNotFound:
+		return fmt.Sprintf("%d %s not found on portal", n, mods)
+	case MetadataNetwork:
+		return fmt.Sprintf("%d %s failed with a network error (likely transient, retry later)", n, mods)
+	case MetadataBadStatus:
+		return fmt.Sprintf("%d %s got an error response from the portal", n, mods)
+	case MetadataPinMissing:
+		return fmt.Sprintf("%d %s pinned to a version the portal does not have", n, mods)
+	case MetadataOffline:
+		return fmt.Sprintf("%d %s unknown (offline, no cached metadata)", n, mods)
+	case MetadataOrphaned:
+		return fmt.Sprintf("%d %s orphaned (removed from portal, installed copy kept)", n, mods)
+	default:
+		return fmt.Sprintf("%d %s failed for other reasons", n, mods)
+	}
+}
+
+// MetadataError records a failed metadata fetch for a single mod.
+type MetadataError struct {
+	Mod  string
+	Kind MetadataErrorKind
+	Err  error
+}
+
+func (e *MetadataError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MetadataError) Unwrap() error {
+	return e.Err
+}
+
+// MetadataErrorGroup lists the mods that failed for the same reason.
+type MetadataErrorGroup struct {
+	Kind MetadataErrorKind
+	Mods []string // sorted and deduplicated
+}
+
+// String renders the group as e.g. "3 mods not found on portal: a, b, c".
+func (g MetadataErrorGroup) String() string {
+	return g.Kind.describe(len(g.Mods)) + ": " + strings.Join(g.Mods, ", ")
+}
+
+// ResolveError is returned by ResolveMetadata when some metadata could not be
+// fetched. Its Error text keeps every underlying message, while Groups offers
+// the concise per-cause summary meant for display.
+type ResolveError struct {
+	Errs []error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("encountered %d metadata errors: %v", len(e.Errs), errors.Join(e.Errs...))
+}
+
+func (e *ResolveError) Unwrap() []error {
+	return e.Errs
+}
+
+// Groups buckets the failures by kind; see groupMetadataErrors.
+func (e *ResolveError) Groups() []MetadataErrorGroup {
+	return groupMetadataErrors(e.Errs)
+}
+
+// groupMetadataErrors buckets errors by MetadataErrorKind, with sorted,
+// deduplicated mod names and groups ordered by kind. Errors that are not a
+// *MetadataError land in the MetadataOther group under their message.
+func groupMetadataErrors(errs []error) []MetadataErrorGroup {
+	byKind := make(map[MetadataErrorKind][]string)
+	for _, err := range errs {
+		var metaErr *MetadataError
+		if errors.As(err, &metaErr) {
+			byKind[metaErr.Kind] = append(byKind[metaErr.Kind], metaErr.Mod)
+		} else {
+			byKind[MetadataOther] = append(byKind[MetadataOther], err.Error())
+		}
+	}
+
+	groups := make([]MetadataErrorGroup, 0, len(byKind))
+	for kind, mods := range byKind {
+		slices.Sort(mods)
+		groups = append(groups, MetadataErrorGroup{Kind: kind, Mods: slices.Compact(mods)})
+	}
+	slices.SortFunc(groups, func(a, b MetadataErrorGroup) int {
+		return int(a.Kind) - int(b.Kind)
+	})
+	return groups
+}
+
+// DependencyError describes a required dependency of one mod that the
+// resolved mod set cannot satisfy.
+type DependencyError struct {
+	Mod        string // the depending mod
+	Dependency string
+	Constraint string // e.g. ">= 0.16.0"; empty when unconstrained
+	Reason     string
+}
+
+func (e *DependencyError) Error() string {
+	required := e.Dependency
+	if e.Constraint != "" {
+		required += " " + e.Constraint
+	}
+	return fmt.Sprintf("%s requires %s: %s", e.Mod, required, e.Reason)
+}
+
+// UnsatisfiedDependenciesError is returned by ResolveMetadata in strict
+// dependency mode when any required dependency cannot be satisfied.
+type UnsatisfiedDependenciesError struct {
+	Errs []*DependencyError
+}
+
+func (e *UnsatisfiedDependenciesError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d required dependencies cannot be satisfied: %s", len(e.Errs), strings.Join(msgs, "; "))
+}
+
+// ModListEntryError describes one field of a mod-list.json entry that does
+// not have the type Factorio expects.
+type ModListEntryError struct {
+	Index   int    // 1-based position in the "mods" array
+	Name    string // the entry's name, when it has a readable one
+	Field   string // empty when the entry itself is malformed
+	Problem string // e.g. `is the string "true", want true or false`
+	// Coercible reports whether --lenient would accept the value.
+	Coercible bool
+}
+
+func (e *ModListEntryError) Error() string {
+	where := fmt.Sprintf("entry %d", e.Index)
+	if e.Name != "" {
+		where += fmt.Sprintf(" (%s)", e.Name)
+	}
+	msg := where + " " + e.Problem
+	if e.Field != "" {
+		msg = fmt.Sprintf("%s: %q %s", where, e.Field, e.Problem)
+	}
+	if e.Coercible {
+		msg += " (--lenient accepts it)"
+	}
+	return msg
+}
+
+// ModListError is returned when mod-list.json parses as JSON but some
+// entries are malformed.
+type ModListError struct {
+	Errs []*ModListEntryError
+}
+
+func (e *ModListError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d invalid mod-list.json field(s): %s", len(e.Errs), strings.Join(msgs, "; "))
+}
@@ -0,0 +1,75 @@
+package factorio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestGroupMetadataErrors(t *testing.T) {
+	notFound := func(mod string) error {
+		return &MetadataError{Mod: mod, Kind: MetadataNotFound, Err: errors.New("mod portal returned status 404 for " + mod)}
+	}
+	errs := []error{
+		notFound("typo-c"),
+		&MetadataError{Mod: "flaky", Kind: MetadataNetwork, Err: errors.New("connection reset")},
+		notFound("typo-a"),
+		notFound("typo-b"),
+		notFound("typo-a"), // the same mod can fail twice across resolution rounds
+		errors.New("unattributed failure"),
+	}
+
+	got := groupMetadataErrors(errs)
+	want := []MetadataErrorGroup{
+		{Kind: MetadataOther, Mods: []string{"unattributed failure"}},
+		{Kind: MetadataNotFound, Mods: []string{"typo-a", "typo-b", "typo-c"}},
+		{Kind: MetadataNetwork, Mods: []string{"flaky"}},
+	}
+	if !slices.EqualFunc(got, want, func(a, b MetadataErrorGroup) bool {
+		return a.Kind == b.Kind && slices.Equal(a.Mods, b.Mods)
+	}) {
+		t.Fatalf("groupMetadataErrors() = %+v; want %+v", got, want)
+	}
+
+	if s := got[1].String(); s != "3 mods not found on portal: typo-a, typo-b, typo-c" {
+		t.Errorf("String() = %q", s)
+	}
+	if s := got[2].String(); s != "1 mod failed with a network error (likely transient, retry later): flaky" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestResolveMetadataReturnsResolveError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	u := &Updater{
+		modServerURL: server.URL,
+		factVersion:  "2.0",
+		httpClient:   server.Client(),
+		mods: map[string]*ModData{
+			"missing-b": {Name: "missing-b"},
+			"missing-a": {Name: "missing-a"},
+		},
+	}
+
+	err := u.ResolveMetadata(context.Background(), nil)
+	var resolveErr *ResolveError
+	if !errors.As(err, &resolveErr) {
+		t.Fatalf("ResolveMetadata() = %v; want a *ResolveError", err)
+	}
+	groups := resolveErr.Groups()
+	if len(groups) != 1 || groups[0].Kind != MetadataNotFound || !slices.Equal(groups[0].Mods, []string{"missing-a", "missing-b"}) {
+		t.Errorf("Groups() = %+v; want one not-found group with both mods", groups)
+	}
+
+	var metaErr *MetadataError
+	if !errors.As(err, &metaErr) {
+		t.Error("individual MetadataErrors should remain reachable through errors.As")
+	}
+}
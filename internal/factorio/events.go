@@ -0,0 +1,65 @@
+package factorio
+
+import "time"
+
+// EventType names a progress event reported through Options.OnEvent.
+type EventType string
+
+const (
+	// EventResolveStart is sent once before metadata resolution begins.
+	EventResolveStart EventType = "resolve_start"
+	// EventModFetched is sent after each metadata fetch, successful or not.
+	EventModFetched EventType = "mod_fetched"
+	// EventDownloadStart is sent when a release download begins.
+	EventDownloadStart EventType = "download_start"
+	// EventDownloadProgress is sent periodically while a release downloads.
+	EventDownloadProgress EventType = "download_progress"
+	// EventDownloadDone is sent when a release download finishes or fails.
+	EventDownloadDone EventType = "download_done"
+	// EventDone is sent by the caller once the whole run has finished.
+	EventDone EventType = "done"
+)
+
+// progressEventBytes is the minimum number of bytes between two
+// download_progress events for the same file.
+const progressEventBytes = 256 << 10
+
+// Event is one structured progress report. Fields that do not apply to the
+// event type are left zero and omitted from its JSON form.
+type Event struct {
+	Type EventType `json:"event"`
+	Time time.Time `json:"time"`
+	Mod  string    `json:"mod,omitempty"`
+	// Version is the release being downloaded.
+	Version string `json:"version,omitempty"`
+	// Resolved and Total count mods during resolution; Total is also the
+	// expected download size in bytes for download events, when known.
+	Resolved int   `json:"resolved,omitempty"`
+	Total    int64 `json:"total,omitempty"`
+	// Bytes counts the bytes downloaded so far.
+	Bytes int64 `json:"bytes,omitempty"`
+	// Updated counts the mods downloaded by the run, for EventDone.
+	Updated int `json:"updated,omitempty"`
+	// Error describes a failure of the fetch, download, or run.
+	Error string `json:"error,omitempty"`
+}
+
+// emit stamps ev and passes it to the OnEvent callback, if any. Calls are
+// serialized so the callback needs no locking of its own.
+func (u *Updater) emit(ev Event) {
+	if u.onEvent == nil {
+		return
+	}
+	ev.Time = time.Now().UTC()
+	u.eventMu.Lock()
+	defer u.eventMu.Unlock()
+	u.onEvent(ev)
+}
+
+// errorText returns err's message, or "" for nil.
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,82 @@
+package factorio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEventStreamForMockedRun(t *testing.T) {
+	content := bytes.Repeat([]byte("z"), 3*progressEventBytes)
+	sum := sha1.Sum(content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/download/") {
+			_, _ = w.Write(content)
+			return
+		}
+		rel := ModRelease{Version: "1.1.0", FileName: "helmod_1.1.0.zip", DownloadURL: "/download/helmod", Sha1: hex.EncodeToString(sum[:])}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: "Helmod", Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"helmod","enabled":true}]}`), 0644)
+
+	// Encode like a wrapping GUI would receive it, then parse it back.
+	var mu sync.Mutex
+	var stream bytes.Buffer
+	enc := json.NewEncoder(&stream)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), noFsync: true,
+		logLevel: LogQuiet, mods: make(map[string]*ModData),
+		onEvent: func(ev Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			_ = enc.Encode(ev)
+		}}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	if err := u.ResolveMetadata(context.Background(), nil); err != nil {
+		t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+	}
+	if _, err := u.UpdateMods(context.Background()); err != nil {
+		t.Fatalf("UpdateMods() returned unexpected error: %v", err)
+	}
+
+	var types []EventType
+	var last Event
+	dec := json.NewDecoder(&stream)
+	for dec.More() {
+		var ev Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("decoding event stream: %v", err)
+		}
+		if ev.Time.IsZero() {
+			t.Errorf("%s event has no timestamp", ev.Type)
+		}
+		if len(types) == 0 || types[len(types)-1] != ev.Type {
+			types = append(types, ev.Type)
+		}
+		last = ev
+	}
+
+	want := []EventType{EventResolveStart, EventModFetched, EventDownloadStart, EventDownloadProgress, EventDownloadDone}
+	if !slices.Equal(types, want) {
+		t.Errorf("event sequence = %v; want %v", types, want)
+	}
+	if last.Mod != "helmod" || last.Version != "1.1.0" || last.Bytes != int64(len(content)) || last.Error != "" {
+		t.Errorf("download_done = %+v; want helmod 1.1.0 with %d bytes", last, len(content))
+	}
+}
@@ -0,0 +1,168 @@
+package factorio
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DependencyEdge is a required dependency link from one tracked mod to another,
+// as declared by the parent's selected release.
+type DependencyEdge struct {
+	Parent string
+	Child  string
+	// Constraint is the declared version requirement (e.g. ">= 1.0.0"), or
+	// empty when the dependency is unconstrained.
+	Constraint string
+}
+
+// DependencyGraph returns every required dependency edge between tracked mods,
+// sorted by parent then child. Dependencies on untracked mods are omitted.
+// Why: Gives presentation commands (tree, why) a flat, deterministic view of
+// the resolved graph without exposing the internal mods map.
+func (u *Updater) DependencyGraph() []DependencyEdge {
+	u.modsMu.RLock()
+	defer u.modsMu.RUnlock()
+
+	var edges []DependencyEdge
+	for name, data := range u.mods {
+		for _, dep := range u.requiredDependencies(data.Latest) {
+			if _, ok := u.mods[dep.name]; !ok {
+				continue
+			}
+			edge := DependencyEdge{Parent: name, Child: dep.name}
+			if dep.op != "" {
+				edge.Constraint = dep.op + " " + dep.version
+			}
+			edges = append(edges, edge)
+		}
+	}
+
+	slices.SortFunc(edges, func(a, b DependencyEdge) int {
+		return cmp.Or(strings.Compare(a.Parent, b.Parent), strings.Compare(a.Child, b.Child))
+	})
+	return edges
+}
+
+// DependentChains returns every chain of required dependencies that leads to
+// target, each ordered from a top-level mod (one nothing else depends on)
+// down to target itself. An empty result means no tracked mod requires it.
+func (u *Updater) DependentChains(target string) [][]string {
+	parents := make(map[string][]string)
+	for _, e := range u.DependencyGraph() {
+		parents[e.Child] = append(parents[e.Child], e.Parent)
+	}
+
+	var chains [][]string
+	var climb func(name string, path []string)
+	climb = func(name string, path []string) {
+		path = append(path, name)
+		extended := false
+		for _, p := range parents[name] {
+			if slices.Contains(path, p) {
+				continue // cycle back into the current chain
+			}
+			extended = true
+			climb(p, path)
+		}
+		if !extended && len(path) > 1 {
+			chain := slices.Clone(path)
+			slices.Reverse(chain)
+			chains = append(chains, chain)
+		}
+	}
+	climb(target, nil)
+
+	return chains
+}
+
+// requiredClosure returns roots plus every mod reachable from them through
+// required dependencies. Untracked dependencies are included but not walked.
+func (u *Updater) requiredClosure(tracked map[string]*ModData, roots []string) map[string]bool {
+	keep := make(map[string]bool, len(roots))
+	queue := make([]string, 0, len(roots))
+	for _, name := range roots {
+		if !keep[name] {
+			keep[name] = true
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		m, ok := tracked[name]
+		if !ok {
+			continue
+		}
+		for _, dep := range u.requiredDependencies(m.Latest) {
+			if !keep[dep.name] {
+				keep[dep.name] = true
+				queue = append(queue, dep.name)
+			}
+		}
+	}
+	return keep
+}
+
+// RequiredDependencies returns every tracked mod that name requires,
+// directly or transitively, sorted by name and excluding name itself.
+func (u *Updater) RequiredDependencies(name string) []string {
+	mods := u.trackedMods()
+	var deps []string
+	for dep := range u.requiredClosure(mods, []string{name}) {
+		if _, ok := mods[dep]; ok && dep != name {
+			deps = append(deps, dep)
+		}
+	}
+	slices.Sort(deps)
+	return deps
+}
+
+// Orphans returns the tracked mods that no user-chosen mod still requires,
+// directly or transitively, sorted by name. Mods with Source FromDependency
+// always qualify; the names in assumeDependency are treated the same way.
+// Why: Source is not persisted across runs, so every mod read back from
+// mod-list.json looks user-chosen. Callers that know a mod was only
+// installed for another (e.g. the dependencies of a mod being removed) pass
+// it in so it can be recognised as dead weight.
+func (u *Updater) Orphans(assumeDependency []string) []string {
+	mods := u.trackedMods()
+	assumed := make(map[string]bool, len(assumeDependency))
+	for _, name := range assumeDependency {
+		assumed[name] = true
+	}
+
+	var roots []string
+	for name, m := range mods {
+		if m.Source == FromModList && !assumed[name] {
+			roots = append(roots, name)
+		}
+	}
+	keep := u.requiredClosure(mods, roots)
+
+	var orphans []string
+	for name := range mods {
+		if !keep[name] {
+			orphans = append(orphans, name)
+		}
+	}
+	slices.Sort(orphans)
+	return orphans
+}
+
+// trackedMods returns a snapshot of the tracked mods keyed by name.
+func (u *Updater) trackedMods() map[string]*ModData {
+	u.modsMu.RLock()
+	defer u.modsMu.RUnlock()
+	return maps.Clone(u.mods)
+}
+
+// IsTracked reports whether the named mod is part of the tracked mod set and
+// returns its state.
+func (u *Updater) IsTracked(name string) (*ModData, bool) {
+	u.modsMu.RLock()
+	defer u.modsMu.RUnlock()
+	m, ok := u.mods[name]
+	return m, ok
+}
@@ -0,0 +1,149 @@
+package factorio
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	depRelease := func(deps ...string) *ModRelease {
+		rel := &ModRelease{Version: "1.0.0"}
+		rel.InfoJSON.Dependencies = deps
+		return rel
+	}
+
+	u := &Updater{
+		mods: map[string]*ModData{
+			"bobplates":  {Name: "bobplates", Latest: depRelease("base >= 2.0.0", "boblibrary >= 1.0.0", "? bobores", "~ bobwarfare")},
+			"bobwarfare": {Name: "bobwarfare", Latest: depRelease("boblibrary")},
+			"boblibrary": {Name: "boblibrary", Source: FromDependency, Latest: depRelease()},
+			"bobores":    {Name: "bobores", Latest: depRelease()},
+			"helmod":     {Name: "helmod", Latest: depRelease("untracked-dep")},
+			"unresolved": {Name: "unresolved"},
+		},
+	}
+
+	want := []DependencyEdge{
+		{Parent: "bobplates", Child: "boblibrary", Constraint: ">= 1.0.0"},
+		{Parent: "bobplates", Child: "bobwarfare"},
+		{Parent: "bobwarfare", Child: "boblibrary"},
+	}
+	if got := u.DependencyGraph(); !slices.Equal(got, want) {
+		t.Errorf("DependencyGraph() = %+v; want %+v", got, want)
+	}
+}
+
+func TestDependentChains(t *testing.T) {
+	depRelease := func(deps ...string) *ModRelease {
+		rel := &ModRelease{Version: "1.0.0"}
+		rel.InfoJSON.Dependencies = deps
+		return rel
+	}
+
+	u := &Updater{
+		mods: map[string]*ModData{
+			"bobplates":  {Name: "bobplates", Source: FromModList, Latest: depRelease("boblibrary", "bobwarfare")},
+			"bobwarfare": {Name: "bobwarfare", Source: FromModList, Latest: depRelease("boblibrary")},
+			"boblibrary": {Name: "boblibrary", Source: FromDependency, Latest: depRelease()},
+			"helmod":     {Name: "helmod", Source: FromModList, Latest: depRelease()},
+			"circle-a":   {Name: "circle-a", Source: FromDependency, Latest: depRelease("circle-b")},
+			"circle-b":   {Name: "circle-b", Source: FromDependency, Latest: depRelease("circle-a")},
+		},
+	}
+
+	t.Run("transitive dependency lists every chain", func(t *testing.T) {
+		m, ok := u.IsTracked("boblibrary")
+		if !ok || m.Source != FromDependency {
+			t.Fatal("boblibrary should be tracked as a pulled-in dependency")
+		}
+
+		got := u.DependentChains("boblibrary")
+		want := [][]string{
+			{"bobplates", "boblibrary"},
+			{"bobplates", "bobwarfare", "boblibrary"},
+		}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("DependentChains() = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("direct user choice with no dependents", func(t *testing.T) {
+		m, ok := u.IsTracked("helmod")
+		if !ok || m.Source != FromModList {
+			t.Fatal("helmod should be tracked as a direct user choice")
+		}
+		if got := u.DependentChains("helmod"); len(got) != 0 {
+			t.Errorf("DependentChains() = %v; want none", got)
+		}
+	})
+
+	t.Run("direct user choice that is also a dependency", func(t *testing.T) {
+		got := u.DependentChains("bobwarfare")
+		want := [][]string{{"bobplates", "bobwarfare"}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("DependentChains() = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("cycles terminate", func(t *testing.T) {
+		got := u.DependentChains("circle-a")
+		want := [][]string{{"circle-b", "circle-a"}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("DependentChains() = %v; want %v", got, want)
+		}
+	})
+}
+
+func TestOrphans(t *testing.T) {
+	depRelease := func(deps ...string) *ModRelease {
+		rel := &ModRelease{Version: "1.0.0"}
+		rel.InfoJSON.Dependencies = deps
+		return rel
+	}
+	newUpdater := func() *Updater {
+		return &Updater{
+			mods: map[string]*ModData{
+				"bobplates":  {Name: "bobplates", Source: FromModList, Latest: depRelease("boblibrary", "bobores")},
+				"bobwarfare": {Name: "bobwarfare", Source: FromModList, Latest: depRelease("boblibrary")},
+				"boblibrary": {Name: "boblibrary", Source: FromModList, Latest: depRelease()},
+				"bobores":    {Name: "bobores", Source: FromModList, Latest: depRelease("bobconfig")},
+				"bobconfig":  {Name: "bobconfig", Source: FromDependency, Latest: depRelease()},
+				"stray":      {Name: "stray", Source: FromDependency, Latest: depRelease()},
+				"helmod":     {Name: "helmod", Source: FromModList, Latest: depRelease()},
+			},
+		}
+	}
+
+	t.Run("unreferenced dependency-sourced mods", func(t *testing.T) {
+		if got, want := newUpdater().Orphans(nil), []string{"stray"}; !slices.Equal(got, want) {
+			t.Errorf("Orphans() = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("dependencies of a removed mod", func(t *testing.T) {
+		u := newUpdater()
+		deps := u.RequiredDependencies("bobplates")
+		if want := []string{"bobconfig", "boblibrary", "bobores"}; !slices.Equal(deps, want) {
+			t.Fatalf("RequiredDependencies() = %v; want %v", deps, want)
+		}
+		delete(u.mods, "bobplates")
+
+		// boblibrary is still required by bobwarfare and must stay.
+		if got, want := u.Orphans(deps), []string{"bobconfig", "bobores", "stray"}; !slices.Equal(got, want) {
+			t.Errorf("Orphans() = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("dependency cycle without a user-chosen root", func(t *testing.T) {
+		u := &Updater{
+			mods: map[string]*ModData{
+				"circle-a": {Name: "circle-a", Source: FromDependency, Latest: depRelease("circle-b")},
+				"circle-b": {Name: "circle-b", Source: FromDependency, Latest: depRelease("circle-a")},
+				"helmod":   {Name: "helmod", Source: FromModList, Latest: depRelease()},
+			},
+		}
+		if got, want := u.Orphans(nil), []string{"circle-a", "circle-b"}; !slices.Equal(got, want) {
+			t.Errorf("Orphans() = %v; want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package factorio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Digests of "hello world".
+const (
+	helloSHA1   = "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed"
+	helloSHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+)
+
+func TestValidateHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.zip")
+	_ = os.WriteFile(testFile, []byte("hello world"), 0644)
+
+	tests := []struct {
+		name     string
+		algo     HashAlgo
+		expected string
+		path     string
+		want     bool
+	}{
+		{"sha1 match", HashSHA1, helloSHA1, testFile, true},
+		{"sha1 uppercase match", HashSHA1, strings.ToUpper(helloSHA1), testFile, true},
+		{"sha1 mismatch", HashSHA1, "deadbeef1234567890abcdef1234567890abcdef", testFile, false},
+		{"sha256 match", HashSHA256, helloSHA256, testFile, true},
+		{"sha256 mismatch", HashSHA256, helloSHA1, testFile, false},
+		{"sha1 digest checked as sha256", HashSHA1, helloSHA256, testFile, false},
+		{"empty expected", HashSHA1, "", testFile, false},
+		{"missing file", HashSHA256, helloSHA256, filepath.Join(tmpDir, "nonexistent.zip"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateHash(tt.algo, tt.expected, tt.path); got != tt.want {
+				t.Errorf("validateHash(%v, %q) = %v; want %v", tt.algo, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		rel      ModRelease
+		wantAlgo HashAlgo
+		wantHash string
+	}{
+		{"sha1 only", ModRelease{Sha1: helloSHA1}, HashSHA1, helloSHA1},
+		{"sha256 preferred", ModRelease{Sha1: helloSHA1, Sha256: helloSHA256}, HashSHA256, helloSHA256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algo, hash := tt.rel.checksum()
+			if algo != tt.wantAlgo || hash != tt.wantHash {
+				t.Errorf("checksum() = (%v, %q); want (%v, %q)", algo, hash, tt.wantAlgo, tt.wantHash)
+			}
+		})
+	}
+}
+
+func TestDownloadFileHashAlgorithms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello world"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		algo     HashAlgo
+		expected string
+		wantErr  string
+	}{
+		{"sha1 fixture", HashSHA1, helloSHA1, ""},
+		{"sha256 fixture", HashSHA256, helloSHA256, ""},
+		{"sha256 mismatch", HashSHA256, strings.Repeat("0", 64), "SHA-256 validation failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "hello_1.0.0.zip")
+			err := (&Updater{httpClient: server.Client()}).downloadFile(context.Background(), target, server.URL, nil, tt.algo, tt.expected)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("downloadFile() error = %v; want one containing %q", err, tt.wantErr)
+				}
+				if _, statErr := os.Stat(target); !os.IsNotExist(statErr) {
+					t.Error("file should not exist after a failed validation")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("downloadFile() returned unexpected error: %v", err)
+			}
+			if data, _ := os.ReadFile(target); string(data) != "hello world" {
+				t.Errorf("downloaded content = %q; want %q", data, "hello world")
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package factorio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HashCacheFileName is the cache of release zip validation results kept
+// inside the mods directory.
+const HashCacheFileName = ".hash-cache.json"
+
+// cachedHash is the digest of one release zip together with the size and
+// modification time the file had when it was hashed.
+type cachedHash struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Algo    string    `json:"algo"`
+	Digest  string    `json:"digest"`
+}
+
+// hashCache remembers the digests of installed release zips so unchanged
+// files are not hashed again on every run. A nil cache stores nothing.
+type hashCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedHash
+	dirty   bool
+}
+
+// loadHashCache reads the cache file at path. A missing file yields an empty
+// cache; an unreadable or corrupt one yields an empty cache and an error so
+// the caller can warn before it is overwritten.
+func loadHashCache(path string) (*hashCache, error) {
+	c := &hashCache{entries: make(map[string]cachedHash)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("reading hash cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]cachedHash)
+		return c, fmt.Errorf("decoding hash cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// matches reports whether the cached digest of fileName equals expected and
+// was computed with algo while the file had info's size and mtime.
+func (c *hashCache) matches(fileName string, info fs.FileInfo, algo HashAlgo, expected string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[fileName]
+	return ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) &&
+		entry.Algo == algo.String() && strings.EqualFold(entry.Digest, expected)
+}
+
+// put records the digest of fileName as of info.
+func (c *hashCache) put(fileName string, info fs.FileInfo, algo HashAlgo, digest string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[fileName] = cachedHash{Size: info.Size(), ModTime: info.ModTime(), Algo: algo.String(), Digest: digest}
+	c.dirty = true
+}
+
+// drop forgets fileName, as when it failed validation or was removed.
+func (c *hashCache) drop(fileName string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[fileName]; ok {
+		delete(c.entries, fileName)
+		c.dirty = true
+	}
+}
+
+// validateInstalled reports whether the release zip fileName in the mods
+// directory has the expected digest. The cached result is trusted while the
+// file keeps the size and mtime it had when hashed; otherwise the file is
+// hashed again and the cache updated, or cleared for it on a mismatch.
+func (u *Updater) validateInstalled(fileName string, algo HashAlgo, expected string) bool {
+	path := filepath.Join(u.modPath, fileName)
+	info, err := os.Stat(path)
+	if err != nil || expected == "" {
+		return false
+	}
+	if u.hashCache.matches(fileName, info, algo, expected) {
+		return true
+	}
+	if !validateHash(algo, expected, path) {
+		u.hashCache.drop(fileName)
+		return false
+	}
+	u.hashCache.put(fileName, info, algo, expected)
+	return true
+}
+
+// rememberHash records the digest of a release zip that was just downloaded
+// or installed from the download cache and validated.
+func (u *Updater) rememberHash(fileName string, algo HashAlgo, digest string) {
+	if u.hashCache == nil {
+		return
+	}
+	info, err := os.Stat(filepath.Join(u.modPath, fileName))
+	if err != nil {
+		return
+	}
+	u.hashCache.put(fileName, info, algo, digest)
+}
+
+// hashCachePath returns where the hash cache is stored.
+func (u *Updater) hashCachePath() string {
+	return filepath.Join(u.modPath, HashCacheFileName)
+}
+
+// saveHashCache writes the cache back to disk if any entry changed.
+func (u *Updater) saveHashCache() error {
+	c := u.hashCache
+	if c == nil || u.readOnly {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("encoding hash cache: %w", err)
+	}
+	if err := u.writeFileAtomic(u.hashCachePath(), data, 0644); err != nil {
+		return fmt.Errorf("writing hash cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,76 @@
+package factorio
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateInstalledUsesHashCache(t *testing.T) {
+	modDir := t.TempDir()
+	content := []byte("release contents")
+	sum := sha1.Sum(content)
+	expected := hex.EncodeToString(sum[:])
+	path := filepath.Join(modDir, "helmod_2.2.12.zip")
+	_ = os.WriteFile(path, content, 0644)
+	mtime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	_ = os.Chtimes(path, mtime, mtime)
+
+	u := &Updater{modPath: modDir, hashCache: &hashCache{entries: make(map[string]cachedHash)}}
+	if !u.validateInstalled("helmod_2.2.12.zip", HashSHA1, expected) {
+		t.Fatal("validateInstalled() = false for a matching file; want true")
+	}
+
+	// Same size and mtime: the corrupted content is not read again.
+	_ = os.WriteFile(path, []byte("RELEASE CONTENTS"), 0644)
+	_ = os.Chtimes(path, mtime, mtime)
+	if !u.validateInstalled("helmod_2.2.12.zip", HashSHA1, expected) {
+		t.Error("validateInstalled() = false for an unchanged file; want the cached result")
+	}
+
+	// A touched file is hashed again and its stale entry dropped.
+	touched := mtime.Add(time.Hour)
+	_ = os.Chtimes(path, touched, touched)
+	if u.validateInstalled("helmod_2.2.12.zip", HashSHA1, expected) {
+		t.Error("validateInstalled() = true for a touched corrupt file; want a re-hash to fail")
+	}
+	if _, ok := u.hashCache.entries["helmod_2.2.12.zip"]; ok {
+		t.Error("hash cache still holds the entry after validation failed")
+	}
+}
+
+func TestSaveHashCacheRoundTrip(t *testing.T) {
+	modDir := t.TempDir()
+	content := []byte("release contents")
+	sum := sha1.Sum(content)
+	expected := hex.EncodeToString(sum[:])
+	_ = os.WriteFile(filepath.Join(modDir, "helmod_2.2.12.zip"), content, 0644)
+
+	u := &Updater{modPath: modDir, noFsync: true, hashCache: &hashCache{entries: make(map[string]cachedHash)}}
+	if !u.validateInstalled("helmod_2.2.12.zip", HashSHA1, expected) {
+		t.Fatal("validateInstalled() = false for a matching file; want true")
+	}
+	if err := u.saveHashCache(); err != nil {
+		t.Fatalf("saveHashCache() returned unexpected error: %v", err)
+	}
+
+	loaded, err := loadHashCache(filepath.Join(modDir, HashCacheFileName))
+	if err != nil {
+		t.Fatalf("loadHashCache() returned unexpected error: %v", err)
+	}
+	info, _ := os.Stat(filepath.Join(modDir, "helmod_2.2.12.zip"))
+	if !loaded.matches("helmod_2.2.12.zip", info, HashSHA1, expected) {
+		t.Errorf("reloaded cache = %+v; want an entry matching the file", loaded.entries)
+	}
+	if loaded.matches("helmod_2.2.12.zip", info, HashSHA256, expected) {
+		t.Error("matches() = true for a different algorithm; want false")
+	}
+
+	_ = os.WriteFile(filepath.Join(modDir, HashCacheFileName), []byte("{not json"), 0644)
+	if c, err := loadHashCache(filepath.Join(modDir, HashCacheFileName)); err == nil || len(c.entries) != 0 {
+		t.Errorf("loadHashCache() on a corrupt file = %v, %v; want an empty cache and an error", c.entries, err)
+	}
+}
@@ -0,0 +1,42 @@
+package factorio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// utf8BOM is the byte order mark some Windows editors put at the start of
+// files saved as UTF-8.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// unmarshalConfig decodes a hand-edited JSON config file into v, ignoring a
+// leading UTF-8 BOM. Syntax and type errors name the line and column.
+func unmarshalConfig(data []byte, v any) error {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	err := json.Unmarshal(data, v)
+
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+	line, col := lineColumn(data, offset)
+	return fmt.Errorf("line %d, column %d: %w", line, col, err)
+}
+
+// lineColumn converts the offset encoding/json reports, the count of bytes
+// read up to and including the offending one, to a 1-based line and column.
+func lineColumn(data []byte, offset int64) (line, col int) {
+	before := data[:max(0, min(offset, int64(len(data))))]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = len(before) - bytes.LastIndexByte(before, '\n') - 1
+	return line, max(col, 1)
+}
@@ -0,0 +1,59 @@
+package factorio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{"plain", `{"token": "abc"}`, "abc", ""},
+		{"leading BOM", "\xEF\xBB\xBF{\"token\": \"abc\"}", "abc", ""},
+		{"trailing comma", "{\n  \"token\": \"abc\",\n}", "", "line 3, column 1: invalid character '}'"},
+		{"wrong type", "{\n  \"token\": 42\n}", "", "line 2, column 13: json: cannot unmarshal number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Token string `json:"token"`
+			}
+			err := unmarshalConfig([]byte(tt.data), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("unmarshalConfig() error = %v; want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshalConfig() returned unexpected error: %v", err)
+			}
+			if got.Token != tt.want {
+				t.Errorf("token = %q; want %q", got.Token, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewUpdaterReportsMalformedSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "server-settings.json")
+	_ = os.WriteFile(settingsPath, []byte("{\n  \"username\": \"server_user\",\n  \"token\": \"server_token\",\n}\n"), 0644)
+
+	_, err := NewUpdater(Options{SettingsPath: settingsPath, ModPath: filepath.Join(tmpDir, "mods"), LogLevel: LogQuiet})
+	if err == nil {
+		t.Fatal("NewUpdater() returned nil error for a settings file with a trailing comma")
+	}
+	for _, want := range []string{"username or token not found", settingsPath, "line 4, column 1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("NewUpdater() error = %q; want it to contain %q", err, want)
+		}
+	}
+}
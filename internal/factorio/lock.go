@@ -0,0 +1,148 @@
+package factorio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockFileName is the lock file created inside the mods directory.
+const LockFileName = ".updater.lock"
+
+// staleLockAge is how old a lock may get before it is considered abandoned
+// even if its PID still appears to be running, since PIDs are recycled.
+const staleLockAge = 24 * time.Hour
+
+// lockInfo is the JSON content of a lock file.
+type lockInfo struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Started  time.Time `json:"started"`
+}
+
+// LockedError reports that another run holds the mods directory lock.
+type LockedError struct {
+	Path    string
+	PID     int
+	Started time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is held by PID %d since %s; another update is running (use --force-lock if it is not)",
+		e.Path, e.PID, e.Started.Format(time.RFC3339))
+}
+
+// Lock is an acquired mods directory lock.
+type Lock struct {
+	path string
+	data []byte
+}
+
+// processAlive reports whether a process with the given PID is running on
+// this host. It is a variable so tests can stub the platform call.
+var processAlive = pidAlive
+
+// lockSettle waits before an unreadable lock is read again. A run that just
+// created the file has not written its PID yet. It is a variable so tests
+// can play that run.
+var lockSettle = func() { time.Sleep(100 * time.Millisecond) }
+
+// AcquireLock creates the lock file in modPath, recording the current PID
+// and start time. A lock left behind by a process that is no longer running,
+// or older than staleLockAge, is replaced; with force any existing lock is.
+// It returns a *LockedError when a live run holds the lock.
+func AcquireLock(modPath string, force bool) (*Lock, error) {
+	path := filepath.Join(modPath, LockFileName)
+	host, _ := os.Hostname()
+	data, err := json.Marshal(lockInfo{PID: os.Getpid(), Hostname: host, Started: time.Now().UTC()})
+	if err != nil {
+		return nil, fmt.Errorf("encoding lock file: %w", err)
+	}
+
+	// A second attempt follows removing a stale or forced lock.
+	for range 2 {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, writeErr := f.Write(data)
+			closeErr := f.Close()
+			if err := errors.Join(writeErr, closeErr); err != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("writing lock file %s: %w", path, err)
+			}
+			return &Lock{path: path, data: data}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("creating lock file %s: %w", path, err)
+		}
+
+		held, raw, readErr := readLock(path)
+		if readErr != nil && !force {
+			lockSettle()
+			held, raw, readErr = readLock(path)
+		}
+		if errors.Is(readErr, fs.ErrNotExist) {
+			continue
+		}
+		if readErr == nil && !force && !lockStale(held, host, time.Now()) {
+			return nil, &LockedError{Path: path, PID: held.PID, Started: held.Started}
+		}
+		// Another run may have replaced the stale lock since it was read.
+		if current, err := os.ReadFile(path); err != nil || !bytes.Equal(current, raw) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("removing stale lock file %s: %w", path, err)
+		}
+	}
+
+	return nil, fmt.Errorf("could not acquire %s: it was recreated by another run", path)
+}
+
+// Release removes the lock file if it still holds this run's PID; a lock
+// taken over with --force-lock belongs to the other run. It is safe to call
+// on a nil Lock.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	current, err := os.ReadFile(l.path)
+	if err != nil || !bytes.Equal(current, l.data) {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing lock file %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// readLock parses an existing lock file and returns its raw content too.
+// Unreadable or malformed content is returned as an error so the caller
+// treats the lock as stale.
+func readLock(path string) (lockInfo, []byte, error) {
+	var info lockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, nil, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, data, fmt.Errorf("parsing lock file %s: %w", path, err)
+	}
+	return info, data, nil
+}
+
+// lockStale reports whether a held lock can be taken over: it is older than
+// staleLockAge, or it was written on this host by a process that has exited.
+func lockStale(info lockInfo, host string, now time.Time) bool {
+	if now.Sub(info.Started) > staleLockAge {
+		return true
+	}
+	if info.Hostname != host {
+		return false
+	}
+	return info.PID <= 0 || !processAlive(info.PID)
+}
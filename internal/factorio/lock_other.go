@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package factorio
+
+// pidAlive cannot probe processes on this platform, so locks only go stale
+// by age.
+func pidAlive(int) bool {
+	return true
+}
@@ -0,0 +1,127 @@
+package factorio
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireLockExcludesSecondRun(t *testing.T) {
+	modDir := t.TempDir()
+
+	first, err := AcquireLock(modDir, false)
+	if err != nil {
+		t.Fatalf("first AcquireLock() returned unexpected error: %v", err)
+	}
+
+	_, err = AcquireLock(modDir, false)
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("second AcquireLock() error = %v; want a *LockedError", err)
+	}
+	if locked.PID != os.Getpid() {
+		t.Errorf("LockedError.PID = %d; want %d", locked.PID, os.Getpid())
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release() returned unexpected error: %v", err)
+	}
+	second, err := AcquireLock(modDir, false)
+	if err != nil {
+		t.Fatalf("AcquireLock() after Release returned unexpected error: %v", err)
+	}
+	_ = second.Release()
+}
+
+func TestAcquireLockReplacesStaleLock(t *testing.T) {
+	host, _ := os.Hostname()
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name    string
+		content any
+		alive   bool
+		force   bool
+		wantErr bool
+	}{
+		{"live holder", lockInfo{PID: 4242, Hostname: host, Started: now}, true, false, true},
+		{"live holder forced", lockInfo{PID: 4242, Hostname: host, Started: now}, true, true, false},
+		{"exited holder", lockInfo{PID: 4242, Hostname: host, Started: now}, false, false, false},
+		{"too old", lockInfo{PID: 4242, Hostname: host, Started: now.Add(-2 * staleLockAge)}, true, false, false},
+		{"other host not probed", lockInfo{PID: 4242, Hostname: host + "-other", Started: now}, false, false, true},
+		{"malformed", "not a lock", true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := processAlive
+			processAlive = func(int) bool { return tt.alive }
+			defer func() { processAlive = orig }()
+
+			modDir := t.TempDir()
+			data, _ := json.Marshal(tt.content)
+			_ = os.WriteFile(filepath.Join(modDir, LockFileName), data, 0644)
+
+			lock, err := AcquireLock(modDir, tt.force)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("AcquireLock() should fail while the lock is held")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AcquireLock() returned unexpected error: %v", err)
+			}
+			defer func() { _ = lock.Release() }()
+
+			held, _, err := readLock(filepath.Join(modDir, LockFileName))
+			if err != nil || held.PID != os.Getpid() {
+				t.Errorf("lock file = %+v, %v; want it rewritten with PID %d", held, err, os.Getpid())
+			}
+		})
+	}
+}
+
+func TestAcquireLockWaitsForLockBeingWritten(t *testing.T) {
+	modDir := t.TempDir()
+	path := filepath.Join(modDir, LockFileName)
+	_ = os.WriteFile(path, nil, 0644)
+
+	host, _ := os.Hostname()
+	live, _ := json.Marshal(lockInfo{PID: 4242, Hostname: host, Started: time.Now().UTC()})
+	origSettle, origAlive := lockSettle, processAlive
+	lockSettle = func() { _ = os.WriteFile(path, live, 0644) }
+	processAlive = func(int) bool { return true }
+	defer func() { lockSettle, processAlive = origSettle, origAlive }()
+
+	_, err := AcquireLock(modDir, false)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.PID != 4242 {
+		t.Fatalf("AcquireLock() error = %v; want a *LockedError for PID 4242", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(live) {
+		t.Errorf("lock file = %q; want the other run's lock left in place", data)
+	}
+}
+
+func TestReleaseKeepsLockTakenOver(t *testing.T) {
+	modDir := t.TempDir()
+	lock, err := AcquireLock(modDir, false)
+	if err != nil {
+		t.Fatalf("AcquireLock() returned unexpected error: %v", err)
+	}
+
+	path := filepath.Join(modDir, LockFileName)
+	other, _ := json.Marshal(lockInfo{PID: 4242, Started: time.Now().UTC()})
+	_ = os.WriteFile(path, other, 0644)
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() returned unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Release() removed a lock held by another run: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package factorio
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// pidAlive probes the process with signal 0. EPERM still means it exists,
+// just under another user.
+func pidAlive(pid int) bool {
+	err := unix.Kill(pid, 0)
+	return err == nil || errors.Is(err, unix.EPERM)
+}
@@ -0,0 +1,116 @@
+package factorio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UpdateMagnitude classifies how far an update moves a mod's version, by
+// the most significant version segment that changes.
+type UpdateMagnitude int
+
+const (
+	// MagnitudeNone means both versions are equal.
+	MagnitudeNone UpdateMagnitude = iota
+	// MagnitudePatch means only the third or a later segment changes.
+	MagnitudePatch
+	// MagnitudeMinor means the second segment changes.
+	MagnitudeMinor
+	// MagnitudeMajor means the first segment changes, which for Factorio
+	// mods usually signals breaking changes or a save migration.
+	MagnitudeMajor
+)
+
+func (m UpdateMagnitude) String() string {
+	switch m {
+	case MagnitudePatch:
+		return "patch"
+	case MagnitudeMinor:
+		return "minor"
+	case MagnitudeMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText encodes the magnitude by name, e.g. in UpdateResult JSON.
+func (m UpdateMagnitude) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// ParseUpdateMagnitude parses "patch", "minor", or "major".
+func ParseUpdateMagnitude(s string) (UpdateMagnitude, error) {
+	for _, m := range []UpdateMagnitude{MagnitudePatch, MagnitudeMinor, MagnitudeMajor} {
+		if strings.EqualFold(s, m.String()) {
+			return m, nil
+		}
+	}
+	return MagnitudeNone, fmt.Errorf("invalid update magnitude %q: want patch, minor, or major", s)
+}
+
+// ClassifyUpdate returns the magnitude of moving from one version to
+// another. Segments are compared numerically with compareVersions, so 1.9.0
+// to 1.10.0 is a minor update; a downgrade is classified the same way.
+func ClassifyUpdate(from, to string) UpdateMagnitude {
+	fs, ts := strings.Split(from, "."), strings.Split(to, ".")
+	for i := range max(len(fs), len(ts)) {
+		if compareVersions(versionSegment(fs, i), versionSegment(ts, i)) == 0 {
+			continue
+		}
+		switch i {
+		case 0:
+			return MagnitudeMajor
+		case 1:
+			return MagnitudeMinor
+		default:
+			return MagnitudePatch
+		}
+	}
+	return MagnitudeNone
+}
+
+// versionSegment returns the i-th segment of a split version, "0" past
+// its end.
+func versionSegment(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return "0"
+}
+
+// HeldMod describes an update UpdateMods left alone because it exceeds the
+// auto-approved magnitude.
+type HeldMod struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	FromVersion string          `json:"from_version"`
+	ToVersion   string          `json:"to_version"`
+	Magnitude   UpdateMagnitude `json:"magnitude"`
+}
+
+// exceedsAutoApprove reports whether updating data to its latest release is
+// a bigger step than Options.AutoApprove allows. New installs and pinned
+// versions, both explicit choices, are always approved.
+func (u *Updater) exceedsAutoApprove(data *ModData) bool {
+	if u.autoApprove == MagnitudeNone || data.Latest == nil || !data.Installed || data.PinnedVersion != "" {
+		return false
+	}
+	return ClassifyUpdate(data.Version, data.Latest.Version) > u.autoApprove
+}
+
+// heldUpdates lists, and logs, the updates among mods that exceedsAutoApprove
+// holds back.
+func (u *Updater) heldUpdates(mods []*ModData) []HeldMod {
+	var held []HeldMod
+	for _, data := range mods {
+		if !u.exceedsAutoApprove(data) {
+			continue
+		}
+		h := HeldMod{Name: data.Name, Title: data.Title, FromVersion: data.Version, ToVersion: data.Latest.Version,
+			Magnitude: ClassifyUpdate(data.Version, data.Latest.Version)}
+		u.WriteLog("Held %s (%s -> %s): %s update exceeds --auto-approve %s", h.Name, h.FromVersion, h.ToVersion, h.Magnitude, u.autoApprove)
+		held = append(held, h)
+	}
+	return held
+}
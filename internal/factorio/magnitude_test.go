@@ -0,0 +1,77 @@
+package factorio
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyUpdate(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     UpdateMagnitude
+	}{
+		{"1.0.0", "2.0.0", MagnitudeMajor},
+		{"1.0.0", "1.1.0", MagnitudeMinor},
+		{"1.0.0", "1.0.1", MagnitudePatch},
+		{"1.0.0", "1.0.0", MagnitudeNone},
+		{"1.9.0", "1.10.0", MagnitudeMinor},
+		{"1.2.9", "2.0.0", MagnitudeMajor},
+		{"2.0.0", "1.9.9", MagnitudeMajor},
+		{"1.0", "1.0.0", MagnitudeNone},
+		{"1.0.0", "1.0.0.1", MagnitudePatch},
+	}
+	for _, tc := range tests {
+		if got := ClassifyUpdate(tc.from, tc.to); got != tc.want {
+			t.Errorf("ClassifyUpdate(%q, %q) = %v; want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestUpdateModsAutoApprove(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/download/")))
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	u := &Updater{modServerURL: server.URL, modPath: modDir, httpClient: server.Client(), noFsync: true,
+		logLevel: LogQuiet, autoApprove: MagnitudeMinor, keepVersions: 1, mods: make(map[string]*ModData)}
+	for name, to := range map[string]string{"bigmod": "2.0.0", "smallmod": "1.1.0"} {
+		_ = os.WriteFile(filepath.Join(modDir, name+"_1.0.0.zip"), []byte("old"), 0644)
+		content := name + "/" + to
+		sum := sha1.Sum([]byte(content))
+		u.mods[name] = &ModData{Name: name, Title: name, Enabled: true, Installed: true, Version: "1.0.0",
+			Latest: &ModRelease{Version: to, FileName: fmt.Sprintf("%s_%s.zip", name, to), DownloadURL: "/download/" + content, Sha1: hex.EncodeToString(sum[:])}}
+	}
+
+	result, err := u.UpdateMods(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateMods() returned unexpected error: %v", err)
+	}
+	if len(result.Updated) != 1 || result.Updated[0].Name != "smallmod" || result.Updated[0].ToVersion != "1.1.0" {
+		t.Errorf("Updated = %+v; want only the minor smallmod update", result.Updated)
+	}
+	wantHeld := []HeldMod{{Name: "bigmod", Title: "bigmod", FromVersion: "1.0.0", ToVersion: "2.0.0", Magnitude: MagnitudeMajor}}
+	if fmt.Sprint(result.Held) != fmt.Sprint(wantHeld) {
+		t.Errorf("Held = %+v; want %+v", result.Held, wantHeld)
+	}
+	if fmt.Sprint(requested) != "[/download/smallmod/1.1.0]" {
+		t.Errorf("downloads = %v; want only smallmod", requested)
+	}
+	if _, err := os.Stat(filepath.Join(modDir, "bigmod_1.0.0.zip")); err != nil {
+		t.Errorf("held bigmod_1.0.0.zip was touched: %v", err)
+	}
+	if !strings.Contains(u.logBuf.String(), "Held bigmod (1.0.0 -> 2.0.0): major update exceeds --auto-approve minor") {
+		t.Errorf("log = %q; want the held update recorded", u.logBuf.String())
+	}
+}
@@ -0,0 +1,157 @@
+package factorio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest declares the desired mod set for an installation, typically kept
+// under version control as modpack.json or modpack.yaml.
+// Why: Lets server operators describe their mods declaratively and have the
+// updater reconcile the installation against it.
+type Manifest struct {
+	Mods []ManifestMod `json:"mods" yaml:"mods"`
+}
+
+// ManifestMod is a single desired mod, optionally pinned to an exact version.
+type ManifestMod struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// ManifestDiff is the set of changes required to reconcile the tracked mods
+// with a Manifest.
+type ManifestDiff struct {
+	// ToInstall lists manifest mods that are not tracked yet.
+	ToInstall []string
+	// ToRemove lists tracked mods absent from the manifest and not required by
+	// any manifest mod. It is only populated when pruning.
+	ToRemove []string
+	// ToPin maps mods to the version they must be pinned to. An empty version
+	// clears an existing pin.
+	ToPin map[string]string
+}
+
+// Empty reports whether the diff contains no changes.
+func (d ManifestDiff) Empty() bool {
+	return len(d.ToInstall) == 0 && len(d.ToRemove) == 0 && len(d.ToPin) == 0
+}
+
+// LoadManifest reads a manifest from path, choosing the YAML or JSON decoder
+// from the file extension, and validates that every entry has a unique name.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest %s: %w", path, err)
+	}
+
+	var m Manifest
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+		}
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&m); err != nil {
+			return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+		}
+	}
+
+	seen := make(map[string]bool, len(m.Mods))
+	for i, mod := range m.Mods {
+		if mod.Name == "" {
+			return nil, fmt.Errorf("manifest %s: entry %d has no name", path, i+1)
+		}
+		if seen[mod.Name] {
+			return nil, fmt.Errorf("manifest %s: mod %q is listed more than once", path, mod.Name)
+		}
+		seen[mod.Name] = true
+	}
+
+	return &m, nil
+}
+
+// ExportManifest snapshots every enabled tracked mod into a Manifest, pinning
+// each entry to its installed version (or its existing pin when not installed)
+// so the set can be reproduced exactly elsewhere.
+func (u *Updater) ExportManifest() *Manifest {
+	u.modsMu.RLock()
+	defer u.modsMu.RUnlock()
+
+	m := &Manifest{Mods: make([]ManifestMod, 0, len(u.mods))}
+	for name, data := range u.mods {
+		if !data.Enabled {
+			continue
+		}
+		entry := ManifestMod{Name: name, Version: data.PinnedVersion}
+		if data.Installed {
+			entry.Version = data.Version
+		}
+		m.Mods = append(m.Mods, entry)
+	}
+
+	slices.SortFunc(m.Mods, func(a, b ManifestMod) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return m
+}
+
+// DiffManifest computes the changes needed to bring the tracked mods in line
+// with the manifest. Mods reachable through the required dependencies of a
+// manifest mod are never scheduled for removal, so the diff should be taken
+// after ResolveMetadata when prune is set.
+func (u *Updater) DiffManifest(manifest *Manifest, prune bool) ManifestDiff {
+	diff := ManifestDiff{ToPin: make(map[string]string)}
+
+	mods := u.GetMods()
+	tracked := make(map[string]*ModData, len(mods))
+	for _, m := range mods {
+		tracked[m.Name] = m
+	}
+
+	wanted := make(map[string]bool, len(manifest.Mods))
+	for _, want := range manifest.Mods {
+		if u.isBuiltInMod(want.Name) {
+			continue
+		}
+		wanted[want.Name] = true
+
+		current, ok := tracked[want.Name]
+		if !ok {
+			diff.ToInstall = append(diff.ToInstall, want.Name)
+			if want.Version != "" {
+				diff.ToPin[want.Name] = want.Version
+			}
+			continue
+		}
+		if current.PinnedVersion != want.Version {
+			diff.ToPin[want.Name] = want.Version
+		}
+	}
+
+	if prune {
+		// Walk required dependencies out from the manifest mods so transitive
+		// deps the portal pulled in are kept.
+		keep := u.requiredClosure(tracked, slices.Collect(maps.Keys(wanted)))
+
+		for name := range tracked {
+			if !keep[name] {
+				diff.ToRemove = append(diff.ToRemove, name)
+			}
+		}
+	}
+
+	slices.Sort(diff.ToInstall)
+	slices.Sort(diff.ToRemove)
+	return diff
+}
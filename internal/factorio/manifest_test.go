@@ -0,0 +1,148 @@
+package factorio
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	t.Run("json manifest", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "modpack.json")
+		_ = os.WriteFile(path, []byte(`{"mods":[{"name":"helmod"},{"name":"jetpack","version":"0.4.15"}]}`), 0644)
+
+		m, err := LoadManifest(path)
+		if err != nil {
+			t.Fatalf("LoadManifest() returned unexpected error: %v", err)
+		}
+		want := []ManifestMod{{Name: "helmod"}, {Name: "jetpack", Version: "0.4.15"}}
+		if !slices.Equal(m.Mods, want) {
+			t.Errorf("mods = %+v; want %+v", m.Mods, want)
+		}
+	})
+
+	t.Run("yaml manifest", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "modpack.yaml")
+		_ = os.WriteFile(path, []byte("mods:\n  - name: helmod\n  - name: jetpack\n    version: 1.0\n"), 0644)
+
+		m, err := LoadManifest(path)
+		if err != nil {
+			t.Fatalf("LoadManifest() returned unexpected error: %v", err)
+		}
+		want := []ManifestMod{{Name: "helmod"}, {Name: "jetpack", Version: "1.0"}}
+		if !slices.Equal(m.Mods, want) {
+			t.Errorf("mods = %+v; want %+v", m.Mods, want)
+		}
+	})
+
+	t.Run("duplicate names are rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "modpack.json")
+		_ = os.WriteFile(path, []byte(`{"mods":[{"name":"helmod"},{"name":"helmod"}]}`), 0644)
+
+		_, err := LoadManifest(path)
+		if err == nil || !strings.Contains(err.Error(), "more than once") {
+			t.Errorf("LoadManifest() error = %v; want duplicate error", err)
+		}
+	})
+
+	t.Run("entry without name is rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "modpack.json")
+		_ = os.WriteFile(path, []byte(`{"mods":[{"version":"1.0.0"}]}`), 0644)
+
+		if _, err := LoadManifest(path); err == nil {
+			t.Error("LoadManifest() should reject an entry without a name")
+		}
+	})
+}
+
+func TestDiffManifest(t *testing.T) {
+	depRelease := func(deps ...string) *ModRelease {
+		rel := &ModRelease{Version: "1.0.0"}
+		rel.InfoJSON.Dependencies = deps
+		return rel
+	}
+
+	u := &Updater{mods: map[string]*ModData{
+		"helmod":           {Name: "helmod", Latest: depRelease("base >= 2.0.0")},
+		"jetpack":          {Name: "jetpack", PinnedVersion: "0.4.14", Latest: depRelease()},
+		"pinned-elsewhere": {Name: "pinned-elsewhere", PinnedVersion: "1.0.0", Latest: depRelease()},
+		"bobplates":        {Name: "bobplates", Latest: depRelease("boblibrary >= 1.0.0", "? bobores")},
+		"boblibrary":       {Name: "boblibrary", Latest: depRelease()},
+		"bobores":          {Name: "bobores", Latest: depRelease()},
+		"extra":            {Name: "extra", Latest: depRelease()},
+	}}
+	manifest := &Manifest{Mods: []ManifestMod{
+		{Name: "base"},
+		{Name: "helmod"},
+		{Name: "jetpack", Version: "0.4.15"},
+		{Name: "pinned-elsewhere"},
+		{Name: "bobplates"},
+		{Name: "new-mod", Version: "2.0.0"},
+		{Name: "another-new-mod"},
+	}}
+
+	t.Run("without prune", func(t *testing.T) {
+		diff := u.DiffManifest(manifest, false)
+
+		if want := []string{"another-new-mod", "new-mod"}; !slices.Equal(diff.ToInstall, want) {
+			t.Errorf("ToInstall = %v; want %v", diff.ToInstall, want)
+		}
+		if len(diff.ToRemove) != 0 {
+			t.Errorf("ToRemove = %v; want none without prune", diff.ToRemove)
+		}
+
+		wantPins := map[string]string{"jetpack": "0.4.15", "pinned-elsewhere": "", "new-mod": "2.0.0"}
+		if len(diff.ToPin) != len(wantPins) {
+			t.Fatalf("ToPin = %v; want %v", diff.ToPin, wantPins)
+		}
+		for name, version := range wantPins {
+			if got, ok := diff.ToPin[name]; !ok || got != version {
+				t.Errorf("ToPin[%q] = %q (present=%v); want %q", name, got, ok, version)
+			}
+		}
+	})
+
+	t.Run("prune keeps required dependencies", func(t *testing.T) {
+		diff := u.DiffManifest(manifest, true)
+
+		// boblibrary is a required dep of bobplates; bobores is only optional.
+		if want := []string{"bobores", "extra"}; !slices.Equal(diff.ToRemove, want) {
+			t.Errorf("ToRemove = %v; want %v", diff.ToRemove, want)
+		}
+	})
+
+	t.Run("matching state is empty", func(t *testing.T) {
+		single := &Updater{mods: map[string]*ModData{"helmod": {Name: "helmod"}}}
+		diff := single.DiffManifest(&Manifest{Mods: []ManifestMod{{Name: "helmod"}}}, true)
+		if !diff.Empty() {
+			t.Errorf("diff = %+v; want empty", diff)
+		}
+	})
+}
+
+func TestExportManifest(t *testing.T) {
+	u := &Updater{
+		mods: map[string]*ModData{
+			"zebra":     {Name: "zebra", Enabled: true, Installed: true, Version: "1.2.3"},
+			"alpha":     {Name: "alpha", Enabled: true, Installed: true, Version: "0.1.0", PinnedVersion: "0.0.9"},
+			"disabled":  {Name: "disabled", Enabled: false, Installed: true, Version: "5.0.0"},
+			"pending":   {Name: "pending", Enabled: true},
+			"pinned":    {Name: "pinned", Enabled: true, PinnedVersion: "2.0.0"},
+			"installed": {Name: "installed", Enabled: true, Installed: true, Version: "3.1.4"},
+		},
+	}
+
+	got := u.ExportManifest().Mods
+	want := []ManifestMod{
+		{Name: "alpha", Version: "0.1.0"},
+		{Name: "installed", Version: "3.1.4"},
+		{Name: "pending"},
+		{Name: "pinned", Version: "2.0.0"},
+		{Name: "zebra", Version: "1.2.3"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExportManifest() = %+v; want %+v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package factorio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MetadataCacheFileName is the portal metadata cache kept inside the mods
+// directory.
+const MetadataCacheFileName = ".metadata-cache.json"
+
+// metadataCacheMaxAge is how long an online run trusts a cached entry
+// instead of asking the portal again.
+const metadataCacheMaxAge = 10 * time.Minute
+
+// ErrOffline is returned by operations that need the network in offline mode.
+var ErrOffline = errors.New("offline mode: network access is disabled")
+
+// cachedMetadata is one mod's portal response as stored in the cache file.
+type cachedMetadata struct {
+	Fetched time.Time         `json:"fetched"`
+	Meta    ModPortalMetadata `json:"meta"`
+}
+
+// metadataCache holds the last successful portal response for each mod so
+// later runs can work without the network. A nil cache stores nothing.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedMetadata
+	dirty   bool
+}
+
+// loadMetadataCache reads the cache file at path. A missing file yields an
+// empty cache; an unreadable or corrupt one yields an empty cache and an
+// error so the caller can warn before it is overwritten.
+func loadMetadataCache(path string) (*metadataCache, error) {
+	c := &metadataCache{entries: make(map[string]cachedMetadata)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("reading metadata cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]cachedMetadata)
+		return c, fmt.Errorf("decoding metadata cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// get returns the cached metadata for mod, if any.
+func (c *metadataCache) get(mod string) (ModPortalMetadata, bool) {
+	if c == nil {
+		return ModPortalMetadata{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[mod]
+	return entry.Meta, ok
+}
+
+// fresh returns the cached metadata for mod if it was fetched no longer than
+// metadataCacheMaxAge before now.
+func (c *metadataCache) fresh(mod string, now time.Time) (ModPortalMetadata, bool) {
+	if c == nil {
+		return ModPortalMetadata{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[mod]
+	if !ok || now.Sub(entry.Fetched) > metadataCacheMaxAge {
+		return ModPortalMetadata{}, false
+	}
+	return entry.Meta, true
+}
+
+// put records a fresh portal response for mod.
+func (c *metadataCache) put(mod string, meta ModPortalMetadata, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[mod] = cachedMetadata{Fetched: now, Meta: meta}
+	c.dirty = true
+}
+
+// metadataCachePath returns where the metadata cache is stored.
+func (u *Updater) metadataCachePath() string {
+	return filepath.Join(u.modPath, MetadataCacheFileName)
+}
+
+// saveMetadataCache writes the cache back to disk if any entry changed.
+func (u *Updater) saveMetadataCache() error {
+	c := u.metaCache
+	if c == nil || u.readOnly {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("encoding metadata cache: %w", err)
+	}
+	if err := u.writeFileAtomic(u.metadataCachePath(), data, 0644); err != nil {
+		return fmt.Errorf("writing metadata cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
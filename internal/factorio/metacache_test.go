@@ -0,0 +1,184 @@
+package factorio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadMetadataCache(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	_ = os.WriteFile(valid, []byte(`{"helmod":{"fetched":"2026-01-02T03:04:05Z","meta":{"title":"Helmod","releases":[{"version":"2.2.12"}]}}}`), 0644)
+	corrupt := filepath.Join(dir, "corrupt.json")
+	_ = os.WriteFile(corrupt, []byte("{not json"), 0644)
+
+	tests := []struct {
+		name      string
+		path      string
+		wantErr   bool
+		wantCache bool
+	}{
+		{"missing file is empty", filepath.Join(dir, "missing.json"), false, false},
+		{"valid file", valid, false, true},
+		{"corrupt file is empty with error", corrupt, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := loadMetadataCache(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMetadataCache() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			meta, ok := c.get("helmod")
+			if ok != tt.wantCache {
+				t.Fatalf("get(helmod) ok = %v; want %v", ok, tt.wantCache)
+			}
+			if ok && (meta.Title != "Helmod" || len(meta.Releases) != 1) {
+				t.Errorf("cached metadata = %+v; want the stored entry", meta)
+			}
+		})
+	}
+}
+
+func TestResolveMetadataOfflineUsesCache(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		rel := ModRelease{Version: "2.2.12", FileName: "helmod_2.2.12.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: "Helmod", Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"helmod","enabled":true}]}`), 0644)
+	newUpdater := func(offline bool) *Updater {
+		t.Helper()
+		cache, err := loadMetadataCache(filepath.Join(modDir, MetadataCacheFileName))
+		if err != nil {
+			t.Fatalf("loadMetadataCache() returned unexpected error: %v", err)
+		}
+		u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(),
+			noFsync: true, offline: offline, metaCache: cache, mods: make(map[string]*ModData)}
+		if err := u.parseModList(); err != nil {
+			t.Fatalf("parseModList() returned unexpected error: %v", err)
+		}
+		return u
+	}
+
+	// An online run populates the cache file.
+	if err := newUpdater(false).ResolveMetadata(context.Background(), nil); err != nil {
+		t.Fatalf("online ResolveMetadata() returned unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(modDir, MetadataCacheFileName)); err != nil {
+		t.Fatalf("metadata cache should be written after an online run: %v", err)
+	}
+
+	hits.Store(0)
+	u := newUpdater(true)
+	_ = u.AddMod("uncached")
+	err := u.ResolveMetadata(context.Background(), nil)
+
+	if n := hits.Load(); n != 0 {
+		t.Errorf("offline mode made %d HTTP requests; want none", n)
+	}
+	if m := u.mods["helmod"]; m.Latest == nil || m.Latest.Version != "2.2.12" || m.Title != "Helmod" {
+		t.Errorf("helmod = %+v; want it resolved from the cache", m)
+	}
+	if got := u.mods["uncached"].UnresolvedReason(); u.mods["uncached"].Latest != nil || got != UnknownOffline {
+		t.Errorf("uncached mod reason = %v; want %v", got, UnknownOffline)
+	}
+	var resolveErr *ResolveError
+	if !errors.As(err, &resolveErr) || !errors.Is(err, ErrOffline) {
+		t.Errorf("ResolveMetadata() error = %v; want a ResolveError wrapping ErrOffline", err)
+	}
+
+	if _, err := u.UpdateMods(context.Background()); !errors.Is(err, ErrOffline) {
+		t.Errorf("UpdateMods() error = %v; want ErrOffline", err)
+	}
+	if hits.Load() != 0 {
+		t.Error("UpdateMods() should not download in offline mode")
+	}
+}
+
+func TestRetrieveModMetadataRefreshBypassesFreshCache(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		rel := ModRelease{Version: "2.3.0", FileName: "helmod_2.3.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: "Helmod", Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	for _, refresh := range []bool{false, true} {
+		t.Run(fmt.Sprintf("refresh=%v", refresh), func(t *testing.T) {
+			hits.Store(0)
+			modDir := t.TempDir()
+			cached := ModRelease{Version: "2.2.12", FileName: "helmod_2.2.12.zip"}
+			cached.InfoJSON.FactorioVersion = "2.0"
+			cache := &metadataCache{entries: map[string]cachedMetadata{
+				"helmod": {Fetched: time.Now().UTC().Add(-time.Minute), Meta: ModPortalMetadata{Title: "Helmod", Releases: []ModRelease{cached}}},
+			}}
+			u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), noFsync: true,
+				refreshMetadata: refresh, metaCache: cache, mods: map[string]*ModData{"helmod": {Name: "helmod", Enabled: true}}}
+
+			if err := u.ResolveMetadata(context.Background(), nil); err != nil {
+				t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+			}
+
+			wantHits, wantVersion := int32(0), "2.2.12"
+			if refresh {
+				wantHits, wantVersion = 1, "2.3.0"
+			}
+			if n := hits.Load(); n != wantHits {
+				t.Errorf("portal was queried %d times; want %d", n, wantHits)
+			}
+			if got := u.mods["helmod"].Latest.Version; got != wantVersion {
+				t.Errorf("Latest = %s; want %s", got, wantVersion)
+			}
+
+			saved, err := loadMetadataCache(filepath.Join(modDir, MetadataCacheFileName))
+			if err != nil {
+				t.Fatalf("loadMetadataCache() returned unexpected error: %v", err)
+			}
+			meta, ok := saved.get("helmod")
+			if refresh && (!ok || meta.Releases[0].Version != "2.3.0") {
+				t.Errorf("cache entry = %+v; want the refreshed response written back", meta)
+			}
+			if !refresh && ok {
+				t.Error("an unchanged cache should not be rewritten")
+			}
+		})
+	}
+}
+
+func TestMetadataCacheFresh(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &metadataCache{entries: map[string]cachedMetadata{
+		"recent": {Fetched: now.Add(-time.Minute)},
+		"stale":  {Fetched: now.Add(-time.Hour)},
+	}}
+	if _, ok := c.fresh("recent", now); !ok {
+		t.Error("fresh(recent) should be served from the cache")
+	}
+	if _, ok := c.fresh("stale", now); ok {
+		t.Error("fresh(stale) should be refetched")
+	}
+	if _, ok := c.fresh("missing", now); ok {
+		t.Error("fresh(missing) should not be found")
+	}
+	var nilCache *metadataCache
+	if _, ok := nilCache.fresh("recent", now); ok {
+		t.Error("a nil cache should hold nothing")
+	}
+}
@@ -0,0 +1,71 @@
+package factorio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// maxInfoJSONBytes caps how much of a directory mod's info.json is read.
+const maxInfoJSONBytes = 1 << 20
+
+// dirModInfo is the part of an unpacked mod's info.json the updater needs.
+type dirModInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// readDirMod reads the info.json of a mod unpacked at dir, which may be a
+// symlink to the mod's source tree. It reports false when dir holds no
+// usable info.json, so unrelated folders in the mods directory are ignored.
+func readDirMod(dir string) (dirModInfo, bool) {
+	f, err := os.Open(filepath.Join(dir, "info.json"))
+	if err != nil {
+		return dirModInfo{}, false
+	}
+	defer func() { _ = f.Close() }()
+
+	var info dirModInfo
+	if err := json.NewDecoder(io.LimitReader(f, maxInfoJSONBytes)).Decode(&info); err != nil {
+		return dirModInfo{}, false
+	}
+	if info.Name == "" || !versionRe.MatchString(info.Version) {
+		return dirModInfo{}, false
+	}
+	return info, true
+}
+
+// isDirEntry reports whether entry in the mods directory is a directory or a
+// symlink to one.
+func (u *Updater) isDirEntry(entry os.DirEntry) bool {
+	if entry.IsDir() {
+		return true
+	}
+	if entry.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(u.modPath, entry.Name()))
+	return err == nil && info.IsDir()
+}
+
+// removeDirMod takes a directory mod out of the mods folder once its zip
+// replaced it under --force. Only symlinks are removed; an unpacked copy
+// may hold uncommitted work, so it is left for the user with a warning.
+func (u *Updater) removeDirMod(data *ModData) error {
+	path := filepath.Join(u.modPath, data.InstallDir)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("inspecting %s: %w", data.InstallDir, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		u.WriteLog("WARNING: %s is still installed as the directory %s next to the new zip; remove one of them", data.Name, data.InstallDir)
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("removing %s: %w", data.InstallDir, err)
+	}
+	u.WriteLog("Removed directory mod link: %s", data.InstallDir)
+	return nil
+}
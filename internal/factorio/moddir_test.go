@@ -0,0 +1,125 @@
+package factorio
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// writeDirMod creates an unpacked mod at dir with the given info.json.
+func writeDirMod(t *testing.T, dir, infoJSON string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "info.json"), []byte(infoJSON), 0644)
+}
+
+func TestParseModListDetectsDirectoryMods(t *testing.T) {
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"my-dev-mod","enabled":false}]}`), 0644)
+	writeDirMod(t, filepath.Join(modDir, "my-dev-mod"), `{"name":"my-dev-mod","version":"0.3.1","factorio_version":"2.0"}`)
+	_ = os.WriteFile(filepath.Join(modDir, "my-dev-mod_0.2.0.zip"), []byte("old zip"), 0644)
+
+	// A symlinked source tree, as developers usually install their mods.
+	src := filepath.Join(t.TempDir(), "linked-src")
+	writeDirMod(t, src, `{"name":"linked","version":"1.0.0"}`)
+	if err := os.Symlink(src, filepath.Join(modDir, "linked_1.0.0")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	// Folders without a usable info.json are not mods.
+	_ = os.Mkdir(filepath.Join(modDir, "screenshots"), 0o755)
+	writeDirMod(t, filepath.Join(modDir, "broken"), `{"name":"broken"}`)
+
+	u := &Updater{modPath: modDir, mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		version    string
+		installDir string
+		enabled    bool
+	}{
+		{"my-dev-mod", "0.3.1", "my-dev-mod", false},
+		{"linked", "1.0.0", "linked_1.0.0", true},
+	}
+	for _, tt := range tests {
+		m := u.mods[tt.name]
+		if m == nil {
+			t.Errorf("%s was not detected", tt.name)
+			continue
+		}
+		if !m.Installed || m.Version != tt.version || m.InstallDir != tt.installDir || m.Enabled != tt.enabled {
+			t.Errorf("%s = %+v; want installed %s from %s, enabled %v", tt.name, m, tt.version, tt.installDir, tt.enabled)
+		}
+	}
+	if len(u.mods) != 2 {
+		t.Errorf("tracked %d mods; want only the 2 with info.json", len(u.mods))
+	}
+}
+
+func TestUpdateModsSkipsDirectoryModsUnlessForced(t *testing.T) {
+	content := []byte("release")
+	h := sha1.New()
+	h.Write(content)
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	for _, force := range []bool{false, true} {
+		t.Run(fmt.Sprintf("force=%v", force), func(t *testing.T) {
+			modDir := t.TempDir()
+			src := filepath.Join(t.TempDir(), "helmod-src")
+			writeDirMod(t, src, `{"name":"helmod","version":"2.0.0"}`)
+			if err := os.Symlink(src, filepath.Join(modDir, "helmod")); err != nil {
+				t.Skipf("symlinks unavailable: %v", err)
+			}
+			_ = os.WriteFile(filepath.Join(modDir, "helmod_1.0.0.zip"), []byte("old zip"), 0644)
+
+			hits.Store(0)
+			u := &Updater{modServerURL: server.URL, modPath: modDir, httpClient: server.Client(), noFsync: true, logLevel: LogQuiet, force: force, mods: make(map[string]*ModData)}
+			if err := u.parseModList(); err != nil {
+				t.Fatalf("parseModList() returned unexpected error: %v", err)
+			}
+			u.mods["helmod"].Latest = &ModRelease{Version: "2.1.0", FileName: "helmod_2.1.0.zip", DownloadURL: "/download/helmod", Sha1: digest}
+			if _, err := u.UpdateMods(context.Background()); err != nil {
+				t.Fatalf("UpdateMods() returned unexpected error: %v", err)
+			}
+
+			exists := func(name string) bool {
+				_, err := os.Lstat(filepath.Join(modDir, name))
+				return err == nil
+			}
+			if got := hits.Load() > 0; got != force {
+				t.Errorf("download attempted = %v; want %v", got, force)
+			}
+			if exists("helmod_2.1.0.zip") != force {
+				t.Errorf("new zip present = %v; want %v", !force, force)
+			}
+			if exists("helmod") == force {
+				t.Errorf("directory link present = %v; want %v", force, !force)
+			}
+			if exists("helmod_1.0.0.zip") == force {
+				t.Errorf("old zip present = %v; want %v", force, !force)
+			}
+			if _, err := os.Stat(filepath.Join(src, "info.json")); err != nil {
+				t.Errorf("the linked source tree must never be touched: %v", err)
+			}
+		})
+	}
+}
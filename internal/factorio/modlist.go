@@ -0,0 +1,247 @@
+package factorio
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// modListEntry is a single entry of mod-list.json.
+type modListEntry struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Version string `json:"version,omitempty"`
+}
+
+// ModListChange describes a mod present both before and after a run whose
+// mod-list.json entry differs.
+type ModListChange struct {
+	Name                        string
+	EnabledBefore, EnabledAfter bool
+	VersionBefore, VersionAfter string
+}
+
+// ModListDiff summarizes how mod-list.json changes between two states.
+// Enabled toggles and pin changes are tracked separately so a single mod can
+// appear in both.
+type ModListDiff struct {
+	Added   []string
+	Removed []string
+	Toggled []ModListChange
+	Pinned  []ModListChange
+}
+
+// Empty reports whether the diff contains no changes.
+func (d ModListDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Toggled) == 0 && len(d.Pinned) == 0
+}
+
+// Lines renders the diff as one summary line followed by one line per
+// change, or nil when there is nothing to report.
+func (d ModListDiff) Lines() []string {
+	if d.Empty() {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("mod-list.json changes: %d added, %d removed, %d toggled, %d pin changes",
+		len(d.Added), len(d.Removed), len(d.Toggled), len(d.Pinned))}
+	for _, name := range d.Added {
+		lines = append(lines, "  + "+name)
+	}
+	for _, name := range d.Removed {
+		lines = append(lines, "  - "+name)
+	}
+	for _, c := range d.Toggled {
+		lines = append(lines, fmt.Sprintf("  ~ %s: %s -> %s", c.Name, enabledLabel(c.EnabledBefore), enabledLabel(c.EnabledAfter)))
+	}
+	for _, c := range d.Pinned {
+		lines = append(lines, fmt.Sprintf("  ~ %s: %s -> %s", c.Name, pinLabel(c.VersionBefore), pinLabel(c.VersionAfter)))
+	}
+	return lines
+}
+
+func enabledLabel(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func pinLabel(version string) string {
+	if version == "" {
+		return "unpinned"
+	}
+	return "pinned " + version
+}
+
+// diffModLists compares two mod-list.json states. Every slice in the result
+// is sorted by mod name.
+func diffModLists(before, after []modListEntry) ModListDiff {
+	var diff ModListDiff
+
+	old := make(map[string]modListEntry, len(before))
+	for _, e := range before {
+		old[e.Name] = e
+	}
+	seen := make(map[string]bool, len(after))
+
+	for _, e := range after {
+		seen[e.Name] = true
+		prev, ok := old[e.Name]
+		if !ok {
+			diff.Added = append(diff.Added, e.Name)
+			continue
+		}
+		change := ModListChange{
+			Name:          e.Name,
+			EnabledBefore: prev.Enabled, EnabledAfter: e.Enabled,
+			VersionBefore: prev.Version, VersionAfter: e.Version,
+		}
+		if prev.Enabled != e.Enabled {
+			diff.Toggled = append(diff.Toggled, change)
+		}
+		if prev.Version != e.Version {
+			diff.Pinned = append(diff.Pinned, change)
+		}
+	}
+	for _, e := range before {
+		if !seen[e.Name] {
+			seen[e.Name] = true
+			diff.Removed = append(diff.Removed, e.Name)
+		}
+	}
+
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	byName := func(a, b ModListChange) int { return cmp.Compare(a.Name, b.Name) }
+	slices.SortFunc(diff.Toggled, byName)
+	slices.SortFunc(diff.Pinned, byName)
+	return diff
+}
+
+// ReconcileResult lists the mod-list.json entries Reconcile changed, each
+// sorted by name.
+type ReconcileResult struct {
+	// Added are installed mods that mod-list.json did not list.
+	Added []string
+	// Removed are listed mods that are neither installed nor available for
+	// the target Factorio version.
+	Removed []string
+}
+
+// Empty reports whether reconciling changed nothing.
+func (r ReconcileResult) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0
+}
+
+// Reconcile brings the tracked mods in line with the mods directory for the
+// next SaveModList. Installed mods missing from mod-list.json are listed,
+// enabled; with removeMissing, listed mods that are not installed and that
+// the portal cannot provide are dropped. removeMissing needs ResolveMetadata
+// to have run first, since only a completed fetch proves a mod unavailable.
+// Why: Hand edits leave zips the list never mentions and entries whose mod
+// is long gone, and Factorio reports both only one at a time at startup.
+func (u *Updater) Reconcile(removeMissing bool) ReconcileResult {
+	listed := make(map[string]bool, len(u.savedModList))
+	for _, e := range u.savedModList {
+		listed[e.Name] = true
+	}
+
+	var result ReconcileResult
+	u.modsMu.Lock()
+	defer u.modsMu.Unlock()
+	for name, m := range u.mods {
+		switch {
+		case m.Installed && !listed[name]:
+			m.Enabled = true
+			result.Added = append(result.Added, name)
+		case removeMissing && listed[name] && !m.Installed && m.Source == FromModList && m.unavailable():
+			delete(u.mods, name)
+			result.Removed = append(result.Removed, name)
+		}
+	}
+	slices.Sort(result.Added)
+	slices.Sort(result.Removed)
+	return result
+}
+
+// unavailable reports whether a completed metadata fetch showed the portal
+// has no usable release of the mod: it does not exist, or nothing matches the
+// target Factorio version. Portal, offline, and depth-limit failures prove
+// nothing, and a missing pinned release is left for the user to fix.
+func (m *ModData) unavailable() bool {
+	if m.Latest != nil {
+		return false
+	}
+	var metaErr *MetadataError
+	if errors.As(m.ResolveErr, &metaErr) {
+		return metaErr.Kind == MetadataNotFound
+	}
+	return m.ResolveErr == nil
+}
+
+// DisabledDependency is a disabled mod that an enabled mod requires.
+type DisabledDependency struct {
+	Name       string
+	RequiredBy []string // sorted; includes disabled mods that must be enabled too
+}
+
+// DisabledDependencies returns, sorted by name, the disabled mods that must
+// be enabled for every enabled mod to load. Requirements are followed through
+// the disabled mods found, since enabling one brings its own required
+// dependencies along. It needs ResolveMetadata to have selected releases.
+// Why: Factorio refuses to start when an enabled mod requires a disabled one,
+// and names only the first offender.
+func (u *Updater) DisabledDependencies() []DisabledDependency {
+	u.modsMu.RLock()
+	defer u.modsMu.RUnlock()
+
+	var queue []string
+	for name, m := range u.mods {
+		if m.Enabled {
+			queue = append(queue, name)
+		}
+	}
+	slices.Sort(queue)
+
+	requiredBy := make(map[string][]string)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dep := range u.requiredDependencies(u.mods[name].Latest) {
+			target, ok := u.mods[dep.name]
+			if !ok || target.Enabled {
+				continue
+			}
+			if _, seen := requiredBy[dep.name]; !seen {
+				queue = append(queue, dep.name)
+			}
+			requiredBy[dep.name] = append(requiredBy[dep.name], name)
+		}
+	}
+
+	out := make([]DisabledDependency, 0, len(requiredBy))
+	for name, by := range requiredBy {
+		slices.Sort(by)
+		out = append(out, DisabledDependency{Name: name, RequiredBy: slices.Compact(by)})
+	}
+	slices.SortFunc(out, func(a, b DisabledDependency) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+// EnableMods marks the named tracked mods enabled so the next saveModList
+// persists them.
+func (u *Updater) EnableMods(names []string) error {
+	u.modsMu.Lock()
+	defer u.modsMu.Unlock()
+	for _, name := range names {
+		m, ok := u.mods[name]
+		if !ok {
+			return fmt.Errorf("mod %q not found in tracking map", name)
+		}
+		m.Enabled = true
+	}
+	return nil
+}
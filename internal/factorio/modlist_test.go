@@ -0,0 +1,221 @@
+package factorio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffModLists(t *testing.T) {
+	before := []modListEntry{
+		{Name: "helmod", Enabled: true},
+		{Name: "jetpack", Enabled: true, Version: "0.4.15"},
+		{Name: "oldmod", Enabled: true},
+		{Name: "quickbar", Enabled: false},
+		{Name: "steady", Enabled: true},
+	}
+	after := []modListEntry{
+		{Name: "steady", Enabled: true},
+		{Name: "helmod", Enabled: false, Version: "2.2.12"},
+		{Name: "jetpack", Enabled: true},
+		{Name: "quickbar", Enabled: true},
+		{Name: "flib", Enabled: true},
+		{Name: "bob", Enabled: true},
+	}
+
+	got := diffModLists(before, after)
+	want := ModListDiff{
+		Added:   []string{"bob", "flib"},
+		Removed: []string{"oldmod"},
+		Toggled: []ModListChange{
+			{Name: "helmod", EnabledBefore: true, EnabledAfter: false, VersionAfter: "2.2.12"},
+			{Name: "quickbar", EnabledBefore: false, EnabledAfter: true},
+		},
+		Pinned: []ModListChange{
+			{Name: "helmod", EnabledBefore: true, EnabledAfter: false, VersionAfter: "2.2.12"},
+			{Name: "jetpack", EnabledBefore: true, EnabledAfter: true, VersionBefore: "0.4.15"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffModLists() = %+v\nwant %+v", got, want)
+	}
+
+	wantLines := []string{
+		"mod-list.json changes: 2 added, 1 removed, 2 toggled, 2 pin changes",
+		"  + bob",
+		"  + flib",
+		"  - oldmod",
+		"  ~ helmod: enabled -> disabled",
+		"  ~ quickbar: disabled -> enabled",
+		"  ~ helmod: unpinned -> pinned 2.2.12",
+		"  ~ jetpack: pinned 0.4.15 -> unpinned",
+	}
+	if lines := got.Lines(); !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("Lines() =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(wantLines, "\n"))
+	}
+}
+
+func TestDiffModListsUnchanged(t *testing.T) {
+	entries := []modListEntry{{Name: "helmod", Enabled: true}, {Name: "jetpack", Version: "0.4.15"}}
+	diff := diffModLists(entries, []modListEntry{entries[1], entries[0]})
+	if !diff.Empty() || diff.Lines() != nil {
+		t.Errorf("diffModLists() of reordered entries = %+v; want no changes", diff)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	notFound := &MetadataError{Mod: "deleted", Kind: MetadataNotFound, Err: errors.New("status 404")}
+	portalDown := &MetadataError{Mod: "flaky", Kind: MetadataBadStatus, Err: errors.New("status 502")}
+	compatible := &ModRelease{Version: "1.0.0"}
+
+	tests := []struct {
+		name          string
+		removeMissing bool
+		wantAdded     []string
+		wantRemoved   []string
+	}{
+		{"disk to list only", false, []string{"unlisted"}, nil},
+		{"both directions", true, []string{"unlisted"}, []string{"deleted", "legacy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modDir := t.TempDir()
+			_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[
+				{"name":"helmod","enabled":true},
+				{"name":"deleted","enabled":true},
+				{"name":"legacy","enabled":false},
+				{"name":"flaky","enabled":true},
+				{"name":"reinstall","enabled":true}
+			]}`), 0644)
+			_ = os.WriteFile(filepath.Join(modDir, "helmod_2.2.12.zip"), []byte("zip"), 0644)
+			_ = os.WriteFile(filepath.Join(modDir, "unlisted_0.1.0.zip"), []byte("zip"), 0644)
+
+			u := &Updater{modPath: modDir, noFsync: true, logLevel: LogQuiet, mods: make(map[string]*ModData)}
+			if err := u.parseModList(); err != nil {
+				t.Fatalf("parseModList() returned unexpected error: %v", err)
+			}
+			// Stand in for ResolveMetadata: legacy has no compatible release,
+			// reinstall is simply not downloaded yet.
+			u.mods["helmod"].Latest = compatible
+			u.mods["deleted"].ResolveErr = notFound
+			u.mods["flaky"].ResolveErr = portalDown
+			u.mods["reinstall"].Latest = compatible
+			u.mods["unlisted"].Enabled = false
+
+			result := u.Reconcile(tt.removeMissing)
+			if !slices.Equal(result.Added, tt.wantAdded) || !slices.Equal(result.Removed, tt.wantRemoved) {
+				t.Fatalf("Reconcile() = %+v; want added %v, removed %v", result, tt.wantAdded, tt.wantRemoved)
+			}
+			if err := u.saveModList(); err != nil {
+				t.Fatalf("saveModList() returned unexpected error: %v", err)
+			}
+
+			u2 := &Updater{modPath: modDir, mods: make(map[string]*ModData)}
+			if err := u2.parseModList(); err != nil {
+				t.Fatalf("parseModList() returned unexpected error: %v", err)
+			}
+			var names []string
+			for _, e := range u2.savedModList {
+				names = append(names, e.Name)
+			}
+			slices.Sort(names)
+			want := []string{"deleted", "flaky", "helmod", "legacy", "reinstall", "unlisted"}
+			if tt.removeMissing {
+				want = []string{"flaky", "helmod", "reinstall", "unlisted"}
+			}
+			if !slices.Equal(names, want) {
+				t.Errorf("saved mod-list = %v; want %v", names, want)
+			}
+			if m := u2.mods["unlisted"]; m == nil || !m.Enabled {
+				t.Error("a mod added from disk should be listed as enabled")
+			}
+		})
+	}
+}
+
+func TestSaveModListLogsDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "mod-list.json"), []byte(`{"mods":[{"name":"helmod","enabled":true}]}`), 0644)
+
+	u := &Updater{modPath: tmpDir, noFsync: true, logLevel: LogQuiet, mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	_ = u.AddMod("flib")
+	if err := u.saveModList(); err != nil {
+		t.Fatalf("saveModList() returned unexpected error: %v", err)
+	}
+	if log := u.logBuf.String(); !strings.Contains(log, "1 added") || !strings.Contains(log, "+ flib") {
+		t.Errorf("log = %q; want the added mod reported", log)
+	}
+
+	// A second save with no changes has nothing new to report.
+	u.logBuf.Reset()
+	if err := u.saveModList(); err != nil {
+		t.Fatalf("saveModList() returned unexpected error: %v", err)
+	}
+	if log := u.logBuf.String(); strings.Contains(log, "mod-list.json changes") {
+		t.Errorf("log = %q; want no diff after an unchanged save", log)
+	}
+}
+
+func TestDisabledDependencies(t *testing.T) {
+	release := func(deps ...string) *ModRelease {
+		rel := &ModRelease{Version: "1.0.0"}
+		rel.InfoJSON.Dependencies = deps
+		return rel
+	}
+	u := &Updater{modPath: t.TempDir(), noFsync: true, logLevel: LogQuiet, mods: map[string]*ModData{
+		// app needs lib and (? optional) extras; lib in turn needs core-lib.
+		"app":      {Name: "app", Enabled: true, Latest: release("base >= 2.0", "lib", "? extras", "~ shared")},
+		"tool":     {Name: "tool", Enabled: true, Latest: release("shared >= 1.0")},
+		"lib":      {Name: "lib", Latest: release("core-lib")},
+		"core-lib": {Name: "core-lib", Latest: release()},
+		"shared":   {Name: "shared", Latest: release()},
+		"extras":   {Name: "extras", Latest: release()},
+		// unused stays disabled: nothing enabled requires it.
+		"unused":     {Name: "unused", Latest: release("orphan-dep")},
+		"orphan-dep": {Name: "orphan-dep"},
+	}}
+
+	got := u.DisabledDependencies()
+	want := []DisabledDependency{
+		{Name: "core-lib", RequiredBy: []string{"lib"}},
+		{Name: "lib", RequiredBy: []string{"app"}},
+		{Name: "shared", RequiredBy: []string{"app", "tool"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DisabledDependencies() = %+v; want %+v", got, want)
+	}
+
+	names := make([]string, len(got))
+	for i, d := range got {
+		names[i] = d.Name
+	}
+	if err := u.EnableMods(names); err != nil {
+		t.Fatalf("EnableMods() returned unexpected error: %v", err)
+	}
+	if rest := u.DisabledDependencies(); len(rest) != 0 {
+		t.Errorf("DisabledDependencies() after enabling = %+v; want none", rest)
+	}
+	if err := u.saveModList(); err != nil {
+		t.Fatalf("saveModList() returned unexpected error: %v", err)
+	}
+	u2 := &Updater{modPath: u.modPath, mods: make(map[string]*ModData)}
+	if err := u2.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	for name, enabled := range map[string]bool{"lib": true, "core-lib": true, "shared": true, "extras": false, "unused": false} {
+		if m := u2.mods[name]; m == nil || m.Enabled != enabled {
+			t.Errorf("saved %s = %+v; want enabled %v", name, m, enabled)
+		}
+	}
+	if err := u.EnableMods([]string{"missing"}); err == nil {
+		t.Error("EnableMods() should reject an untracked mod")
+	}
+}
@@ -0,0 +1,137 @@
+package factorio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+// decodeModList parses the entries of mod-list.json, checking each field's
+// type. With lenientModList common hand-editing mistakes (a quoted or
+// numeric "enabled", spaces around a name) are coerced with a warning;
+// otherwise every bad field is reported in one *ModListError.
+func (u *Updater) decodeModList(data []byte) ([]modListEntry, error) {
+	var modList struct {
+		Mods []json.RawMessage `json:"mods"`
+	}
+	if err := unmarshalConfig(data, &modList); err != nil {
+		return nil, err
+	}
+
+	entries := make([]modListEntry, 0, len(modList.Mods))
+	var errs []*ModListEntryError
+	for i, raw := range modList.Mods {
+		entry, problems := decodeModListEntry(i+1, raw)
+		for _, p := range problems {
+			if !p.Coercible || !u.lenientModList {
+				errs = append(errs, p)
+				continue
+			}
+			accepted := *p
+			accepted.Coercible = false
+			u.WriteLog("WARNING: mod-list.json %v; accepted with --lenient", &accepted)
+			if u.logLevel != LogQuiet {
+				pterm.Warning.Printf("mod-list.json %v; accepted with --lenient\n", &accepted)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if len(errs) > 0 {
+		return nil, &ModListError{Errs: errs}
+	}
+	return entries, nil
+}
+
+// decodeModListEntry decodes the index-th entry of the "mods" array. The
+// returned entry holds the coerced values of any Coercible problems.
+func decodeModListEntry(index int, raw json.RawMessage) (modListEntry, []*ModListEntryError) {
+	var entry modListEntry
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return entry, []*ModListEntryError{{Index: index, Problem: fmt.Sprintf("is %s, want an object with \"name\" and \"enabled\"", jsonKind(raw))}}
+	}
+
+	var problems []*ModListEntryError
+	problem := func(field, format string, args ...any) *ModListEntryError {
+		p := &ModListEntryError{Index: index, Name: entry.Name, Field: field, Problem: fmt.Sprintf(format, args...)}
+		problems = append(problems, p)
+		return p
+	}
+
+	switch v := fields["name"]; {
+	case isJSONNull(v):
+		problem("name", "is missing")
+	case json.Unmarshal(v, &entry.Name) != nil:
+		problem("name", "is %s, want a string", jsonKind(v))
+	case strings.TrimSpace(entry.Name) == "":
+		entry.Name = ""
+		problem("name", "is empty")
+	case strings.TrimSpace(entry.Name) != entry.Name:
+		trimmed := strings.TrimSpace(entry.Name)
+		p := problem("name", "%q has surrounding spaces", entry.Name)
+		p.Name, p.Coercible = trimmed, true
+		entry.Name = trimmed
+	}
+
+	if v := fields["enabled"]; !isJSONNull(v) && json.Unmarshal(v, &entry.Enabled) != nil {
+		var s string
+		var n float64
+		switch {
+		case json.Unmarshal(v, &s) == nil:
+			enabled, ok := parseLooseBool(s)
+			problem("enabled", "is the string %q, want true or false", s).Coercible = ok
+			entry.Enabled = enabled
+		case json.Unmarshal(v, &n) == nil:
+			problem("enabled", "is the number %v, want true or false", n).Coercible = n == 0 || n == 1
+			entry.Enabled = n == 1
+		default:
+			problem("enabled", "is %s, want true or false", jsonKind(v))
+		}
+	}
+
+	if v := fields["version"]; !isJSONNull(v) && json.Unmarshal(v, &entry.Version) != nil {
+		problem("version", "is %s, want a string such as \"1.2.3\"", jsonKind(v))
+	}
+	return entry, problems
+}
+
+// parseLooseBool interprets the quoted booleans people type into
+// mod-list.json by hand.
+func parseLooseBool(s string) (value, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "1":
+		return true, true
+	case "false", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// isJSONNull reports whether v is absent or the JSON literal null.
+func isJSONNull(v json.RawMessage) bool {
+	return len(v) == 0 || bytes.Equal(bytes.TrimSpace(v), []byte("null"))
+}
+
+// jsonKind names the type of the JSON value v for error messages.
+func jsonKind(v json.RawMessage) string {
+	v = bytes.TrimSpace(v)
+	if len(v) == 0 {
+		return "empty"
+	}
+	switch v[0] {
+	case '"':
+		return "a string"
+	case '{':
+		return "an object"
+	case '[':
+		return "an array"
+	case 't', 'f':
+		return "a boolean"
+	case 'n':
+		return "null"
+	}
+	return "a number"
+}
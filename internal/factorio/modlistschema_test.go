@@ -0,0 +1,119 @@
+package factorio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseModListValidatesEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		modList string
+		lenient bool
+		// wantErrs are substrings of the error, in order; none means success.
+		wantErrs    []string
+		wantEnabled map[string]bool
+	}{
+		{
+			name:     "quoted enabled",
+			modList:  `{"mods": [{"name": "base", "enabled": true}, {"name": "helmod", "enabled": "true"}]}`,
+			wantErrs: []string{`entry 2 (helmod): "enabled" is the string "true", want true or false (--lenient accepts it)`},
+		},
+		{
+			name:     "numeric enabled",
+			modList:  `{"mods": [{"name": "helmod", "enabled": 1}]}`,
+			wantErrs: []string{`entry 1 (helmod): "enabled" is the number 1, want true or false (--lenient accepts it)`},
+		},
+		{
+			name:     "missing name",
+			modList:  `{"mods": [{"enabled": true}]}`,
+			wantErrs: []string{`entry 1: "name" is missing`},
+		},
+		{
+			name:     "entry is not an object",
+			modList:  `{"mods": ["helmod"]}`,
+			wantErrs: []string{`entry 1 is a string, want an object with "name" and "enabled"`},
+		},
+		{
+			name:     "numeric version",
+			modList:  `{"mods": [{"name": "helmod", "enabled": true, "version": 2.2}]}`,
+			wantErrs: []string{`entry 1 (helmod): "version" is a number, want a string such as "1.2.3"`},
+		},
+		{
+			name:    "every bad field is reported",
+			modList: `{"mods": [{"name": 7, "enabled": true}, {"name": "flib", "enabled": "yes"}, {"name": "helmod", "enabled": [true]}]}`,
+			wantErrs: []string{
+				"3 invalid mod-list.json field(s)",
+				`entry 1: "name" is a number, want a string`,
+				`entry 2 (flib): "enabled" is the string "yes", want true or false;`,
+				`entry 3 (helmod): "enabled" is an array, want true or false`,
+			},
+		},
+		{
+			name:     "syntax error names the line",
+			modList:  "{\"mods\": [\n  {\"name\": \"helmod\", \"enabled\": true},\n]}",
+			wantErrs: []string{"line 3, column 1"},
+		},
+		{
+			name:        "lenient coerces common mistakes",
+			modList:     `{"mods": [{"name": " helmod ", "enabled": "True"}, {"name": "flib", "enabled": 0}]}`,
+			lenient:     true,
+			wantEnabled: map[string]bool{"helmod": true, "flib": false},
+		},
+		{
+			name:     "lenient still rejects what it cannot coerce",
+			modList:  `{"mods": [{"name": "helmod", "enabled": "maybe"}]}`,
+			lenient:  true,
+			wantErrs: []string{`entry 1 (helmod): "enabled" is the string "maybe", want true or false`},
+		},
+		{
+			name:        "null fields keep their defaults",
+			modList:     `{"mods": [{"name": "helmod", "enabled": null, "version": null}]}`,
+			wantEnabled: map[string]bool{"helmod": false},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			_ = os.WriteFile(filepath.Join(tmpDir, "mod-list.json"), []byte(tc.modList), 0644)
+
+			u := &Updater{modPath: tmpDir, mods: make(map[string]*ModData), logLevel: LogQuiet, lenientModList: tc.lenient}
+			err := u.parseModList()
+			if len(tc.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("parseModList() returned unexpected error: %v", err)
+				}
+				for name, want := range tc.wantEnabled {
+					if m, ok := u.mods[name]; !ok || m.Enabled != want {
+						t.Errorf("mods[%q] = %+v; want tracked with Enabled %v", name, m, want)
+					}
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("parseModList() returned nil error; want a validation error")
+			}
+			msg := err.Error()
+			rest := msg
+			for _, want := range tc.wantErrs {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("error = %q; want it to contain %q in order", msg, want)
+				}
+				rest = rest[i+len(want):]
+			}
+			if strings.Contains(tc.modList, "\n") {
+				return // syntax errors are not ModListErrors
+			}
+			var listErr *ModListError
+			if !errors.As(err, &listErr) || len(listErr.Errs) == 0 {
+				t.Errorf("error %v is not a *ModListError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package factorio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// PruneInstalled removes older release zips from the mods directory using
+// only what is on disk: for each mod the opts.KeepVersions highest versions
+// are kept (at least one), plus any version pinned in mod-list.json. It
+// returns the removed file names, sorted; with dryRun nothing is deleted
+// and the names are those that would be.
+// Why: pruneOld only runs after an update resolved the latest release, so
+// servers that skipped updates or ran --no-prune accumulated old zips that
+// could only be cleaned by hand.
+func PruneInstalled(opts Options, dryRun bool) ([]string, error) {
+	u := &Updater{
+		modPath:          opts.ModPath,
+		modListFile:      opts.ModListPath,
+		factPath:         opts.FactPath,
+		logLevel:         opts.LogLevel,
+		extraBuiltInMods: opts.BuiltInMods,
+		noFsync:          opts.NoFsync,
+		keepVersions:     opts.KeepVersions,
+		lenientModList:   opts.LenientModList,
+		readOnly:         opts.ReadOnly,
+		mods:             make(map[string]*ModData),
+	}
+	if err := ValidateModPath(u.modPath); err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := u.checkWritable(u.modPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.parseModList(); err != nil {
+		return nil, fmt.Errorf("parsing mod list: %w", err)
+	}
+	entries, err := readDir(u.modPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading mod directory: %w", err)
+	}
+
+	pinned := make(map[string]string)
+	for name, data := range u.mods {
+		if data.PinnedVersion != "" {
+			pinned[modKey(name)] = data.PinnedVersion
+		}
+	}
+
+	var removed []string
+	for key, zips := range buildZipIndex(entries) {
+		for _, z := range u.staleZips(zips, pinned[key]) {
+			if !dryRun {
+				if err := os.Remove(filepath.Join(u.modPath, z.name)); err != nil {
+					return removed, fmt.Errorf("removing %s: %w", z.name, err)
+				}
+				u.debugf("Removed old release: %s", z.name)
+			}
+			removed = append(removed, z.name)
+		}
+	}
+	if len(removed) > 0 && !dryRun {
+		_ = u.syncDir(u.modPath)
+	}
+	slices.Sort(removed)
+	return removed, nil
+}
+
+// staleZips returns the zips of one mod that fall outside the keepVersions
+// highest versions and are not the pinned release.
+func (u *Updater) staleZips(zips []zipFile, pinnedVersion string) []zipFile {
+	slices.SortFunc(zips, func(a, b zipFile) int {
+		return compareVersions(b.version, a.version)
+	})
+	var stale []zipFile
+	for i, z := range zips {
+		if i < max(u.keepVersions, 1) || (pinnedVersion != "" && compareVersions(z.version, pinnedVersion) == 0) {
+			continue
+		}
+		stale = append(stale, z)
+	}
+	return stale
+}
@@ -0,0 +1,74 @@
+package factorio
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPruneInstalled(t *testing.T) {
+	zips := []string{
+		"helmod_2.2.9.zip", "helmod_2.2.10.zip", "helmod_2.2.12.zip",
+		"flib_0.15.0.zip", "flib_0.16.2.zip",
+		"Krastorio2_1.3.0.zip", "Krastorio2_1.3.24.zip",
+		"boblibrary_1.0.0.zip", "boblibrary_1.1.0.zip", "boblibrary_1.2.0.zip",
+		"notes.zip",
+	}
+	modList := `{"mods": [{"name": "boblibrary", "enabled": true, "version": "1.0.0"}]}`
+
+	tests := []struct {
+		name        string
+		keep        int
+		dryRun      bool
+		wantRemoved []string
+	}{
+		{
+			name:        "keeps the highest version per mod",
+			keep:        1,
+			wantRemoved: []string{"Krastorio2_1.3.0.zip", "boblibrary_1.1.0.zip", "flib_0.15.0.zip", "helmod_2.2.10.zip", "helmod_2.2.9.zip"},
+		},
+		{
+			name:        "respects keep-versions",
+			keep:        2,
+			wantRemoved: []string{"helmod_2.2.9.zip"},
+		},
+		{
+			name:        "keep-versions below one keeps one",
+			keep:        0,
+			wantRemoved: []string{"Krastorio2_1.3.0.zip", "boblibrary_1.1.0.zip", "flib_0.15.0.zip", "helmod_2.2.10.zip", "helmod_2.2.9.zip"},
+		},
+		{
+			name:        "dry run deletes nothing",
+			keep:        1,
+			dryRun:      true,
+			wantRemoved: []string{"Krastorio2_1.3.0.zip", "boblibrary_1.1.0.zip", "flib_0.15.0.zip", "helmod_2.2.10.zip", "helmod_2.2.9.zip"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			modDir := t.TempDir()
+			_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(modList), 0644)
+			for _, name := range zips {
+				_ = os.WriteFile(filepath.Join(modDir, name), []byte(name), 0644)
+			}
+
+			removed, err := PruneInstalled(Options{ModPath: modDir, KeepVersions: tc.keep, LogLevel: LogQuiet, NoFsync: true}, tc.dryRun)
+			if err != nil {
+				t.Fatalf("PruneInstalled() returned unexpected error: %v", err)
+			}
+			if !slices.Equal(removed, tc.wantRemoved) {
+				t.Errorf("removed = %v; want %v", removed, tc.wantRemoved)
+			}
+
+			for _, name := range zips {
+				_, err := os.Stat(filepath.Join(modDir, name))
+				wantGone := !tc.dryRun && slices.Contains(tc.wantRemoved, name)
+				if gone := os.IsNotExist(err); gone != wantGone {
+					t.Errorf("%s removed = %v; want %v", name, gone, wantGone)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package factorio
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrReadOnly is returned by operations that would write to disk when the
+// Updater was built with Options.ReadOnly.
+var ErrReadOnly = errors.New("read-only mode: writing is disabled")
+
+// checkWritable returns an error wrapping ErrReadOnly that names what would
+// have been written, or nil when writes are allowed.
+func (u *Updater) checkWritable(what string) error {
+	if !u.readOnly {
+		return nil
+	}
+	return fmt.Errorf("refusing to write %s: %w", what, ErrReadOnly)
+}
@@ -0,0 +1,91 @@
+package factorio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// staleTempAge is how long a .tmp file must go unmodified before it counts
+// as left behind. No download outlives downloadTimeout, so a concurrent
+// run's file in progress is never swept.
+const staleTempAge = downloadTimeout
+
+// sweepStaleTemps deals with the .tmp files an interrupted run left in the
+// mods directory. A release zip whose digest matches the cached portal
+// metadata is renamed into place; anything else is removed.
+func (u *Updater) sweepStaleTemps(now time.Time) {
+	if u.readOnly {
+		return
+	}
+	entries, err := os.ReadDir(u.modPath)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < staleTempAge {
+			continue
+		}
+		if u.finalizeTemp(name) {
+			u.infof("Recovered %s from an interrupted download\n", strings.TrimSuffix(name, ".tmp"))
+			u.WriteLog("Recovered %s from an interrupted download", strings.TrimSuffix(name, ".tmp"))
+			continue
+		}
+		if err := os.Remove(filepath.Join(u.modPath, name)); err != nil {
+			u.WriteLog("WARNING: removing stale %s: %v", name, err)
+			continue
+		}
+		u.debugf("Removed stale %s left by an interrupted run", name)
+		u.WriteLog("Removed stale %s left by an interrupted run", name)
+	}
+}
+
+// finalizeTemp renames the leftover tmpName to the release zip it was meant
+// to become, provided that zip is not already installed and the file matches
+// the digest of a release in the metadata cache.
+func (u *Updater) finalizeTemp(tmpName string) bool {
+	fileName := strings.TrimSuffix(tmpName, ".tmp")
+	rel, ok := u.cachedRelease(fileName)
+	if !ok {
+		return false
+	}
+	target := filepath.Join(u.modPath, fileName)
+	if _, err := os.Stat(target); err == nil {
+		return false
+	}
+	algo, expected := rel.checksum()
+	if !validateHash(algo, expected, filepath.Join(u.modPath, tmpName)) {
+		return false
+	}
+	if err := os.Rename(filepath.Join(u.modPath, tmpName), target); err != nil {
+		return false
+	}
+	_ = u.syncDir(u.modPath)
+	u.rememberHash(fileName, algo, expected)
+	return true
+}
+
+// cachedRelease looks up the release whose zip is named fileName in the
+// metadata cache.
+func (u *Updater) cachedRelease(fileName string) (*ModRelease, bool) {
+	match := modZipRe.FindStringSubmatch(fileName)
+	if len(match) != 3 {
+		return nil, false
+	}
+	meta, ok := u.metaCache.get(match[1])
+	if !ok {
+		return nil, false
+	}
+	for i := range meta.Releases {
+		if meta.Releases[i].FileName == fileName {
+			return &meta.Releases[i], true
+		}
+	}
+	return nil, false
+}
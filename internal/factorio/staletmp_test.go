@@ -0,0 +1,60 @@
+package factorio
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewUpdaterSweepsStaleTemps(t *testing.T) {
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods": [{"name": "helmod", "enabled": true}]}`), 0644)
+
+	content := []byte("helmod 2.2.12")
+	sum := sha1.Sum(content)
+	meta := map[string]cachedMetadata{"helmod": {Fetched: time.Now().UTC(), Meta: ModPortalMetadata{
+		Title:    "Helmod",
+		Releases: []ModRelease{{FileName: "helmod_2.2.12.zip", Version: "2.2.12", Sha1: hex.EncodeToString(sum[:])}},
+	}}}
+	data, _ := json.Marshal(meta)
+	_ = os.WriteFile(filepath.Join(modDir, MetadataCacheFileName), data, 0644)
+
+	stale := time.Now().Add(-time.Hour)
+	leftovers := map[string][]byte{
+		"helmod_2.2.12.zip.tmp": content,           // complete download: finalized
+		"helmod_2.2.11.zip.tmp": []byte("partial"), // unknown release: removed
+		"mod-list.json.tmp":     []byte(`{"mods"`), // interrupted save: removed
+		"flib_0.16.2.zip.tmp":   []byte("growing"), // recently written: left alone
+	}
+	for name, body := range leftovers {
+		path := filepath.Join(modDir, name)
+		_ = os.WriteFile(path, body, 0644)
+		if name != "flib_0.16.2.zip.tmp" {
+			_ = os.Chtimes(path, stale, stale)
+		}
+	}
+
+	u, err := NewUpdater(Options{ModPath: modDir, Username: "user", Token: "token", FactorioVersion: "2.0", LogLevel: LogQuiet, NoFsync: true})
+	if err != nil {
+		t.Fatalf("NewUpdater() returned unexpected error: %v", err)
+	}
+
+	if got, err := os.ReadFile(filepath.Join(modDir, "helmod_2.2.12.zip")); err != nil || string(got) != string(content) {
+		t.Errorf("helmod_2.2.12.zip = %q, %v; want the recovered download", got, err)
+	}
+	for _, name := range []string{"helmod_2.2.12.zip.tmp", "helmod_2.2.11.zip.tmp", "mod-list.json.tmp"} {
+		if _, err := os.Stat(filepath.Join(modDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after startup (err %v); want it swept", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(modDir, "flib_0.16.2.zip.tmp")); err != nil {
+		t.Errorf("recent flib_0.16.2.zip.tmp was removed: %v", err)
+	}
+	if m := u.GetMods()[0]; !m.Installed || m.Version != "2.2.12" {
+		t.Errorf("helmod = installed %v, version %q; want the recovered 2.2.12 detected", m.Installed, m.Version)
+	}
+}
@@ -0,0 +1,178 @@
+package factorio
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TransportOptions tunes the HTTP transport built by NewHTTPClient.
+type TransportOptions struct {
+	// ForceIPv4 dials the portal over IPv4 only, for networks where its IPv6
+	// address resolves but does not answer.
+	ForceIPv4 bool
+	// InsecureSkipVerify accepts any TLS certificate. It exists only as a
+	// last resort behind TLS-intercepting proxies; prefer CAFile.
+	InsecureSkipVerify bool
+	// CAFile names a PEM bundle whose certificates are trusted for TLS in
+	// addition to the system roots.
+	CAFile string
+}
+
+// NewHTTPClient returns the client an Updater uses when Options.HTTPClient
+// is nil, with dial, TLS, and response header timeouts suited to the portal.
+// Dual-stack hosts are dialed Happy Eyeballs style: IPv4 is tried in
+// parallel once IPv6 has not connected within a short delay.
+func NewHTTPClient(opts TransportOptions) (*http.Client, error) {
+	tlsConfig, err := tlsConfigFor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:       10 * time.Second,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: 300 * time.Millisecond,
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, dialNetwork(network, opts.ForceIPv4), addr)
+			},
+			TLSClientConfig:       tlsConfig,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+// dialNetwork returns the network to dial for a transport request, pinning
+// TCP to IPv4 when forceIPv4 is set.
+func dialNetwork(network string, forceIPv4 bool) string {
+	if forceIPv4 && strings.HasPrefix(network, "tcp") {
+		return "tcp4"
+	}
+	return network
+}
+
+// tlsConfigFor builds the TLS settings selected by opts, or nil when the
+// defaults apply.
+func tlsConfigFor(opts TransportOptions) (*tls.Config, error) {
+	if !opts.InsecureSkipVerify && opts.CAFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify} //nolint:gosec // Opt-in via --insecure
+	if opts.CAFile != "" {
+		pool, err := loadCAPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// loadCAPool returns the system roots extended with every certificate in the
+// PEM file at path. A file without certificates, or with one that fails to
+// parse, is an error.
+func loadCAPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate %d in CA file %s: %w", len(certs)+1, path, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("CA file %s contains no PEM certificates", path)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		// Not every platform exposes its roots; trust just the bundle then.
+		pool = x509.NewCertPool()
+	}
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// decodedBody returns the body of resp with a gzip Content-Encoding
+// removed; an identity body is passed through unchanged.
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	if resp.Uncompressed || !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		return resp.Body, nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading gzip response: %w", err)
+	}
+	return zr, nil
+}
+
+// limitedBody returns a reader over r that fails with ErrResponseTooLarge
+// once more than limit bytes have been read.
+func limitedBody(r io.Reader, limit int64) io.Reader {
+	return &cappedReader{r: r, left: limit}
+}
+
+// cappedReader is the reader behind limitedBody.
+type cappedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.left <= 0 {
+		// Only a byte beyond the limit proves the body is too large; one
+		// that ends exactly at it still decodes.
+		var probe [1]byte
+		if n, err := c.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	return n, err
+}
+
+// modifyRequest applies Options.RequestModifier to req, if one was given.
+func (u *Updater) modifyRequest(req *http.Request) {
+	if u.requestModifier != nil {
+		u.requestModifier(req)
+	}
+}
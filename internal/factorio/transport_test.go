@@ -0,0 +1,232 @@
+package factorio
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDialNetwork(t *testing.T) {
+	tests := []struct {
+		network   string
+		forceIPv4 bool
+		want      string
+	}{
+		{"tcp", false, "tcp"},
+		{"tcp6", false, "tcp6"},
+		{"tcp", true, "tcp4"},
+		{"tcp6", true, "tcp4"},
+		{"unix", true, "unix"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s forceIPv4=%v", tt.network, tt.forceIPv4), func(t *testing.T) {
+			if got := dialNetwork(tt.network, tt.forceIPv4); got != tt.want {
+				t.Errorf("dialNetwork(%q, %v) = %q; want %q", tt.network, tt.forceIPv4, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientForceIPv4(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(TransportOptions{ForceIPv4: true})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() returned unexpected error: %v", err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET over IPv4 returned unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+// writeServerCA writes the certificate of a TLS test server to a PEM file.
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "proxy-ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	_ = os.WriteFile(path, pem.EncodeToMemory(block), 0644)
+	return path
+}
+
+func TestNewHTTPClientTLSOptions(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		opts         TransportOptions
+		wantInsecure bool
+		wantRootCAs  bool
+		wantErr      bool
+	}{
+		{"system roots reject the test CA", TransportOptions{}, false, false, true},
+		{"ca file trusts the test CA", TransportOptions{CAFile: writeServerCA(t, server)}, false, true, false},
+		{"insecure skips verification", TransportOptions{InsecureSkipVerify: true}, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(tt.opts)
+			if err != nil {
+				t.Fatalf("NewHTTPClient() returned unexpected error: %v", err)
+			}
+
+			tlsConfig := client.Transport.(*http.Transport).TLSClientConfig
+			var insecure, hasRoots bool
+			if tlsConfig != nil {
+				insecure, hasRoots = tlsConfig.InsecureSkipVerify, tlsConfig.RootCAs != nil
+			}
+			if insecure != tt.wantInsecure || hasRoots != tt.wantRootCAs {
+				t.Errorf("TLS config InsecureSkipVerify=%v RootCAs set=%v; want %v and %v", insecure, hasRoots, tt.wantInsecure, tt.wantRootCAs)
+			}
+
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GET error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientMissingCAFile(t *testing.T) {
+	if _, err := NewHTTPClient(TransportOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("NewHTTPClient() should fail when the CA file cannot be read")
+	}
+}
+
+func TestLoadCAPool(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join("testdata", "proxy-ca.pem")
+	fixturePEM, _ := os.ReadFile(fixture)
+	empty := filepath.Join(dir, "empty.pem")
+	_ = os.WriteFile(empty, []byte("not a certificate\n"), 0644)
+	corrupt := filepath.Join(dir, "corrupt.pem")
+	_ = os.WriteFile(corrupt, append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}), fixturePEM...), 0644)
+	keyOnly := filepath.Join(dir, "key.pem")
+	_ = os.WriteFile(keyOnly, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("secret")}), 0644)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"fixture bundle", fixture, ""},
+		{"no pem blocks", empty, "contains no PEM certificates"},
+		{"only a private key", keyOnly, "contains no PEM certificates"},
+		{"unparseable certificate", corrupt, "parsing certificate 1"},
+		{"missing file", filepath.Join(dir, "missing.pem"), "reading CA file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadCAPool(tt.path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("loadCAPool() returned unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadCAPool() error = %v; want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientCAFileFixture(t *testing.T) {
+	fixture := filepath.Join("testdata", "proxy-ca.pem")
+	client, err := NewHTTPClient(TransportOptions{CAFile: fixture})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() returned unexpected error: %v", err)
+	}
+
+	roots := client.Transport.(*http.Transport).TLSClientConfig.RootCAs
+	if roots == nil {
+		t.Fatal("RootCAs should be set from the CA file")
+	}
+	data, _ := os.ReadFile(fixture)
+	block, _ := pem.Decode(data)
+	cert, _ := x509.ParseCertificate(block.Bytes)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots}); err != nil {
+		t.Errorf("fixture CA should verify against the transport's RootCAs: %v", err)
+	}
+	if sys, err := x509.SystemCertPool(); err == nil && roots.Equal(sys) {
+		t.Error("RootCAs should extend the system roots, not equal them")
+	}
+}
+
+func TestLimitedBody(t *testing.T) {
+	tests := []struct {
+		body    string
+		limit   int64
+		wantErr error
+	}{
+		{"12345", 5, nil},
+		{"123456", 5, ErrResponseTooLarge},
+		{"", 0, nil},
+	}
+	for _, tc := range tests {
+		got, err := io.ReadAll(limitedBody(strings.NewReader(tc.body), tc.limit))
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("limitedBody(%q, %d) error = %v; want %v", tc.body, tc.limit, err, tc.wantErr)
+		}
+		if tc.wantErr == nil && string(got) != tc.body {
+			t.Errorf("limitedBody(%q, %d) read %q; want the whole body", tc.body, tc.limit, got)
+		}
+	}
+}
+
+func TestRequestModifier(t *testing.T) {
+	content := []byte("helmod 2.2.12")
+	sum := sha1.Sum(content)
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.URL.Path+" "+r.Header.Get("X-Proxy-Auth"))
+		switch r.URL.Path {
+		case "/proxy/api/mods/helmod/full":
+			_, _ = w.Write([]byte(`{"title": "Helmod"}`))
+		case "/proxy/download/helmod/2.2.12":
+			_, _ = w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	u := &Updater{modServerURL: server.URL, factVersion: "2.0", httpClient: server.Client(), noFsync: true,
+		mods: map[string]*ModData{"helmod": {Name: "helmod"}},
+		requestModifier: func(req *http.Request) {
+			req.Header.Set("X-Proxy-Auth", "secret")
+			req.URL.Path = "/proxy" + req.URL.Path
+		}}
+
+	if err := u.RetrieveModMetadata(t.Context(), "helmod"); err != nil {
+		t.Fatalf("RetrieveModMetadata() returned unexpected error: %v", err)
+	}
+	target := filepath.Join(t.TempDir(), "helmod_2.2.12.zip")
+	if err := u.downloadFile(t.Context(), target, server.URL+"/download/helmod/2.2.12", nil, HashSHA1, hex.EncodeToString(sum[:])); err != nil {
+		t.Fatalf("downloadFile() returned unexpected error: %v", err)
+	}
+
+	want := []string{"/proxy/api/mods/helmod/full secret", "/proxy/download/helmod/2.2.12 secret"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("requests = %q; want %q", seen, want)
+	}
+}
@@ -3,24 +3,24 @@ package factorio
 import (
 	"cmp"
 	"context"
-	"crypto/sha1" // #nosec G505 - SHA-1 is mandated by the Factorio Mod Portal API for download validation.
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/pterm/pterm"
@@ -29,54 +29,234 @@ import (
 
 // Package-level compiled regexps to avoid repeated compilation on every call.
 var (
-	versionRe = regexp.MustCompile(`(?P<major>\d+)\.(?P<minor>\d+)(?:\.(?P<sub>\d+))?`)
-	factVerRe = regexp.MustCompile(`Version: (\d+)\.(\d+)\.\d+`)
-	modZipRe  = regexp.MustCompile(`^(.*)_(\d+\.\d+\.\d+)\.zip$`)
-	depRe     = regexp.MustCompile(`^(?:[~!?](?:\(\))? )?(?P<name>[\w -]+)(?: (?P<arg>(?:[<>]=?)|=) (?P<ver>\d+\.\d+\.\d+))?$`)
+	versionRe         = regexp.MustCompile(`(?P<major>\d+)\.(?P<minor>\d+)(?:\.(?P<sub>\d+))?`)
+	factVerRe         = regexp.MustCompile(`Version: (\d+)\.(\d+)\.\d+`)
+	factBannerRe      = regexp.MustCompile(`Factorio (\d+)\.(\d+)\.\d+ \(build`)
+	factVerOverrideRe = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.\d+)?$`)
+	modZipRe          = regexp.MustCompile(`^(.*)_(\d+\.\d+\.\d+)\.zip$`)
+	depRe             = regexp.MustCompile(`^(?:(?P<prefix>\(\?\)|[~!?])\s*)?(?P<name>[\w -]+?)(?:\s+(?P<op>[<>]=?|=)\s+(?P<ver>\d+\.\d+(?:\.\d+)?))?$`)
 )
 
 // maxAPIResponseBytes caps JSON response body reads to prevent memory exhaustion
 // from malicious or malformed API responses.
 const maxAPIResponseBytes = 10 * 1024 * 1024 // 10 MB
 
+// ErrResponseTooLarge is returned when a decoded API response exceeds
+// maxAPIResponseBytes.
+var ErrResponseTooLarge = errors.New("API response exceeds the size limit")
+
+// DefaultMaxDownloadBytes is the per-file download ceiling used when
+// Options.MaxDownloadSize is zero.
+const DefaultMaxDownloadBytes = 1 << 30 // 1 GiB
+
+// LogLevel controls how much console output the Updater produces. The zero
+// value is the default level.
+type LogLevel int
+
+const (
+	// LogNormal prints progress and informational messages.
+	LogNormal LogLevel = iota
+	// LogQuiet suppresses informational messages, leaving warnings to the caller.
+	LogQuiet
+	// LogVerbose additionally traces HTTP requests and skip decisions.
+	LogVerbose
+)
+
 // Updater orchestrates the lifecycle of Factorio mod management, including
 // authentication, version detection, metadata resolution, and download execution.
 // Why: Acts as the central domain model for all mod operations, decoupling the
 // CLI presentation layer from HTTP interactions and filesystem mutations.
 type Updater struct {
 	modServerURL string
+	mirrorURL    string // base URL release zips are fetched from instead of the portal, or ""
 	settingsPath string
 	dataPath     string
 	modPath      string
+	modListFile  string // mod-list.json outside modPath, or "" for the default
 	factPath     string
 	username     string
 	token        string
+	// usernameSource and tokenSource describe where each credential was
+	// read from, e.g. the path of player-data.json.
+	usernameSource string
+	tokenSource    string
+	// fallbackUsername and fallbackToken fill whichever credential is still
+	// missing once parseTokens has read the config files.
+	fallbackUsername string
+	fallbackToken    string
+	fallbackSource   string
+	// credentialErrs are the config files parseTokens could not read,
+	// reported if no credentials were found elsewhere.
+	credentialErrs []error
 
 	factVersion string
 	mods        map[string]*ModData
 	modsMu      sync.RWMutex // guards concurrent access to the mods map
 	httpClient  *http.Client
+	// requestModifier, when set, decorates every outgoing portal request.
+	requestModifier func(*http.Request)
+	logLevel        LogLevel
+
+	ignoreVersionCheck bool            // select the newest release regardless of factorio_version
+	bundledMods        []string        // detected from the data directory; nil means defaultBuiltInMods
+	extraBuiltInMods   []string        // treated as built-in on top of the bundled mods
+	noFsync            bool            // skip fsync after writes, trading crash safety for speed
+	maxDownloadBytes   int64           // per-file download ceiling; zero means DefaultMaxDownloadBytes
+	downloadCache      string          // directory of validated zips shared between installs, or ""
+	keepVersions       int             // releases per mod kept on disk by pruneOld; values below 1 mean 1
+	noPrune            bool            // leave older releases on disk after downloading
+	force              bool            // replace and prune mods installed as directories too
+	preserveOrder      bool            // write mod-list.json in listOrder instead of sorting by name
+	lenientModList     bool            // coerce common mod-list.json type mistakes instead of failing
+	readOnly           bool            // refuse every write; caches are silently not saved
+	listOrder          []string        // mod names in the order parseModList read them from mod-list.json
+	savedModList       []modListEntry  // mod-list.json as last read or written, for diffing
+	zipsMu             sync.Mutex      // guards zips
+	zips               zipIndex        // release zips in modPath; nil until first scanned
+	offline            bool            // answer metadata from metaCache and make no network calls
+	metaCache          *metadataCache  // last portal response per mod; nil disables caching
+	hashCache          *hashCache      // digests of installed zips by size and mtime; nil disables caching
+	refreshMetadata    bool            // fetch every mod from the portal even if its cache entry is fresh
+	noDeps             bool            // skip discovering dependencies not already tracked
+	maxDepth           int             // dependency hops to follow when discovering, 0 for unlimited
+	maxMods            int             // tracked mods at which ResolveMetadata gives up, 0 for unlimited
+	allowPrerelease    bool            // let selectRelease pick pre-release versions
+	strictDeps         bool            // fail ResolveMetadata on unsatisfiable required dependencies
+	autoApprove        UpdateMagnitude // largest update UpdateMods applies; MagnitudeNone approves all
+	onEvent            func(Event)     // structured progress callback; may be nil
+	eventMu            sync.Mutex      // serializes onEvent calls
 
 	logBuf strings.Builder
 	logMu  sync.Mutex
+	runID  string // identifies this run's log lines; set on first use, guarded by logMu
 }
 
-// WriteLog appends a detailed trace line to the persistent log buffer in a thread-safe manner.
+// WriteLog appends a detailed trace line to the persistent log buffer in a
+// thread-safe manner. Every line is prefixed with the run ID and, once
+// known, the Factorio version.
+// Why: Servers that append their logs to one aggregated file need to grep
+// a single run back out.
 func (u *Updater) WriteLog(format string, args ...any) {
 	u.logMu.Lock()
 	defer u.logMu.Unlock()
-	fmt.Fprintf(&u.logBuf, format, args...)
-	if !strings.HasSuffix(format, "\n") {
+	prefix := u.logPrefix()
+	msg := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+	for _, line := range strings.Split(msg, "\n") {
+		u.logBuf.WriteString(prefix)
+		u.logBuf.WriteString(line)
 		u.logBuf.WriteString("\n")
 	}
 }
 
+// logPrefix returns the prefix of WriteLog lines. The caller holds logMu.
+func (u *Updater) logPrefix() string {
+	if u.runID == "" {
+		u.runID = newRunID()
+	}
+	if u.factVersion == "" {
+		return fmt.Sprintf("[run %s] ", u.runID)
+	}
+	return fmt.Sprintf("[run %s factorio %s] ", u.runID, u.factVersion)
+}
+
+// RunID returns the short random ID that tags this Updater's log lines.
+func (u *Updater) RunID() string {
+	u.logMu.Lock()
+	defer u.logMu.Unlock()
+	if u.runID == "" {
+		u.runID = newRunID()
+	}
+	return u.runID
+}
+
+// newRunID returns 8 random hex digits.
+func newRunID() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// logger receives log lines. *Updater writes them straight to the log
+// buffer and console; *modLog holds them back.
+type logger interface {
+	WriteLog(format string, args ...any)
+	debugf(format string, args ...any)
+}
+
+// modLog holds the log lines of one mod's download until flush writes them
+// to the Updater, so concurrent downloads can be logged in mod order.
+type modLog struct {
+	entries []modLogEntry
+}
+
+// modLogEntry is one held-back line and whether it is a debug trace.
+type modLogEntry struct {
+	text  string
+	debug bool
+}
+
+// WriteLog holds a line for the persistent log.
+func (l *modLog) WriteLog(format string, args ...any) {
+	l.entries = append(l.entries, modLogEntry{text: strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")})
+}
+
+// debugf holds a verbose trace line.
+func (l *modLog) debugf(format string, args ...any) {
+	l.entries = append(l.entries, modLogEntry{text: fmt.Sprintf(format, args...), debug: true})
+}
+
+// flush writes the held lines to u in the order they were logged.
+func (l *modLog) flush(u *Updater) {
+	for _, e := range l.entries {
+		if e.debug {
+			u.debugf("%s", e.text)
+		} else {
+			u.WriteLog("%s", e.text)
+		}
+	}
+	l.entries = nil
+}
+
+// infof prints an informational console message unless the Updater is quiet.
+func (u *Updater) infof(format string, args ...any) {
+	if u.logLevel != LogQuiet {
+		pterm.Info.Printf(format, args...)
+	}
+}
+
+// debugf traces a message to the console and the log buffer when verbose.
+func (u *Updater) debugf(format string, args ...any) {
+	if u.logLevel != LogVerbose {
+		return
+	}
+	pterm.Debug.WithDebugger(false).Printf(format+"\n", args...)
+	u.WriteLog("DEBUG: "+format, args...)
+}
+
+// redactURL masks the token query parameter of a URL for safe logging.
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "<unparseable URL>"
+	}
+	q := parsed.Query()
+	if q.Has("token") {
+		q.Set("token", "REDACTED")
+		parsed.RawQuery = q.Encode()
+	}
+	return parsed.String()
+}
+
 // SaveLog dumps the accumulated detailed activity trace buffer to last-mod-update.log
 // located in the root Factorio directory.
 func (u *Updater) SaveLog(cliSummary string) error {
 	logPath := filepath.Join(filepath.Dir(u.modPath), "last-mod-update.log")
-	finalLog := fmt.Sprintf("=== Factorio Mod Updater Log (%s) ===\n%s\n\n%s",
-		time.Now().Format(time.RFC3339), cliSummary, u.logBuf.String())
+	if err := u.checkWritable(logPath); err != nil {
+		return err
+	}
+	runID := u.RunID()
+	finalLog := fmt.Sprintf("=== Factorio Mod Updater Log (%s, run %s, Factorio %s) ===\n%s\n\n%s",
+		time.Now().Format(time.RFC3339), runID, u.factVersion, cliSummary, u.logBuf.String())
 	return os.WriteFile(logPath, []byte(finalLog), 0600)
 }
 
@@ -90,14 +270,135 @@ type ModData struct {
 	Title string
 	// Enabled reflects the mod-list.json enabled flag.
 	Enabled bool
-	// Installed is true when a matching zip file exists on disk.
+	// Installed is true when a matching zip file or mod directory exists on disk.
 	Installed bool
+	// InstallDir names the unpacked directory, or symlink to one, that the
+	// mod is installed as instead of a zip. Such dev installs are neither
+	// replaced nor pruned unless the Updater is forced.
+	InstallDir string
 	// Version is the currently installed semver string (e.g. "2.2.12").
 	Version string
 	// Latest points to the most recent compatible release from the Mod Portal, or nil.
 	Latest *ModRelease
+	// Newest points to the most recent release regardless of its Factorio
+	// version, or nil. It differs from Latest when newer releases target a
+	// Factorio version other than the installed one.
+	Newest *ModRelease
 	// Deprecated is true when the Mod Portal marks the mod as deprecated.
 	Deprecated bool
+	// PinnedVersion, when set, selects that exact release instead of the latest
+	// compatible one. It round-trips through the mod-list.json "version" key.
+	PinnedVersion string
+	// Source records whether the user chose the mod or it was pulled in as a
+	// dependency during resolution.
+	Source ModSource
+	// ResolveErr holds the error from the mod's last metadata fetch, or nil
+	// if the fetch succeeded or has not run.
+	ResolveErr error
+	// Depth is how many dependency hops separate a discovered mod from the
+	// mods the user chose, which have depth 0.
+	Depth int
+}
+
+// NewerIncompatible returns the newest release when it is newer than Latest
+// but was passed over because it targets another Factorio version, and nil
+// otherwise. Pinned mods never report one.
+func (m *ModData) NewerIncompatible() *ModRelease {
+	if m.Newest == nil || m.PinnedVersion != "" {
+		return nil
+	}
+	if m.Latest != nil && compareVersions(m.Newest.Version, m.Latest.Version) <= 0 {
+		return nil
+	}
+	return m.Newest
+}
+
+// UnresolvedReason explains why a tracked mod has no Latest release.
+type UnresolvedReason int
+
+const (
+	// NoCompatibleRelease means the portal answered but lists no release for
+	// the target Factorio version, or not the pinned one.
+	NoCompatibleRelease UnresolvedReason = iota
+	// PortalError means the metadata fetch failed, so compatibility is unknown.
+	PortalError
+	// UnknownOffline means offline mode had no cached metadata for the mod.
+	UnknownOffline
+	// BeyondMaxDepth means the dependency was discovered past the depth
+	// limit, so its metadata was never fetched.
+	BeyondMaxDepth
+	// Orphaned means the mod is installed but the portal no longer has it,
+	// so the local copy is all there is.
+	Orphaned
+)
+
+// String returns a short label for the reason, e.g. "portal error".
+func (r UnresolvedReason) String() string {
+	switch r {
+	case PortalError:
+		return "portal error"
+	case UnknownOffline:
+		return "unknown (offline)"
+	case BeyondMaxDepth:
+		return "beyond depth limit"
+	case Orphaned:
+		return "orphaned (removed from portal)"
+	default:
+		return "no compatible release"
+	}
+}
+
+// UnresolvedReason classifies why the mod has no Latest release from the
+// error stored by its last metadata fetch.
+func (m *ModData) UnresolvedReason() UnresolvedReason {
+	if errors.Is(m.ResolveErr, ErrDepthLimit) {
+		return BeyondMaxDepth
+	}
+	var metaErr *MetadataError
+	if m.ResolveErr == nil || (errors.As(m.ResolveErr, &metaErr) && metaErr.Kind == MetadataPinMissing) {
+		return NoCompatibleRelease
+	}
+	if metaErr != nil && metaErr.Kind == MetadataOffline {
+		return UnknownOffline
+	}
+	if metaErr != nil && metaErr.Kind == MetadataOrphaned {
+		return Orphaned
+	}
+	return PortalError
+}
+
+// ErrDepthLimit is stored as the ResolveErr of dependencies discovered
+// beyond Options.MaxDepth.
+var ErrDepthLimit = errors.New("dependency is beyond the resolution depth limit")
+
+// ErrTooManyMods is returned by ResolveMetadata when the tracked set would
+// grow past Options.MaxMods.
+// Why: A malformed modpack or a misparsed dependency list could otherwise
+// make resolution query the portal for an unbounded number of mods.
+var ErrTooManyMods = errors.New("too many mods tracked")
+
+// ModSource records why a mod is tracked.
+// Why: Dependency-only views, orphan cleanup, and "why" all need to tell a
+// user's own choices apart from mods that only exist to satisfy others.
+type ModSource int
+
+const (
+	// FromModList marks a mod the user chose: listed in mod-list.json, found
+	// as a zip in the mods directory, or added explicitly.
+	FromModList ModSource = iota
+	// FromDependency marks a mod discovered while resolving the required
+	// dependencies of another tracked mod.
+	FromDependency
+)
+
+// String returns a short label for the source, e.g. "dependency".
+func (s ModSource) String() string {
+	switch s {
+	case FromDependency:
+		return "dependency"
+	default:
+		return "mod-list"
+	}
 }
 
 // ModRelease represents a single versioned release artifact from the Mod Portal API.
@@ -115,8 +416,51 @@ type ModRelease struct {
 	} `json:"info_json"`
 	// Sha1 is the hex-encoded SHA-1 digest for download validation.
 	Sha1 string `json:"sha1"`
+	// Sha256 is the hex-encoded SHA-256 digest, preferred over Sha1 when the
+	// portal provides it.
+	Sha256 string `json:"sha256,omitempty"`
 	// Version is the semver string for this release.
 	Version string `json:"version"`
+	// ReleasedAt is when the release was published on the portal, in UTC;
+	// zero when the portal did not say.
+	ReleasedAt time.Time `json:"released_at,omitzero"`
+}
+
+// releasedAtLayouts are the timestamp formats accepted for released_at. The
+// portal sends RFC 3339 with microseconds; older responses and mirrors have
+// omitted the zone, which is then taken as UTC.
+var releasedAtLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05"}
+
+// UnmarshalJSON decodes a release, tolerating a missing, null, empty, or
+// unparseable released_at by leaving ReleasedAt zero.
+// Why: The date only drives display and filters; one odd timestamp must not
+// make a mod's whole metadata undecodable.
+func (r *ModRelease) UnmarshalJSON(data []byte) error {
+	type plain ModRelease
+	aux := struct {
+		*plain
+		ReleasedAt json.RawMessage `json:"released_at"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.ReleasedAt = parseReleasedAt(aux.ReleasedAt)
+	return nil
+}
+
+// parseReleasedAt converts a raw released_at value to a UTC time, or the
+// zero time when it is absent or not a timestamp.
+func parseReleasedAt(raw json.RawMessage) time.Time {
+	var s string
+	if len(raw) == 0 || json.Unmarshal(raw, &s) != nil || s == "" {
+		return time.Time{}
+	}
+	for _, layout := range releasedAtLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
 }
 
 // ModPortalMetadata represents the JSON response from the /api/mods/{name}/full endpoint.
@@ -128,36 +472,245 @@ type ModPortalMetadata struct {
 	Releases   []ModRelease `json:"releases"`
 }
 
+// Options configures a new Updater. Zero values select the defaults.
+type Options struct {
+	// SettingsPath and DataPath locate server-settings.json and
+	// player-data.json; empty paths are auto-discovered next to ModPath.
+	SettingsPath string
+	DataPath     string
+	// ModPath is the mods directory and FactPath the Factorio executable.
+	ModPath  string
+	FactPath string
+	// ModListPath, when set, is read and written instead of
+	// mod-list.json inside ModPath; release zips stay in ModPath.
+	ModListPath string
+	// Username and Token take priority over credentials in the config files.
+	Username string
+	Token    string
+	// UsernameSource and TokenSource describe where Username and Token came
+	// from, such as "the --token flag", for the verbose credential report.
+	// Left empty, a given credential is reported as coming from the options.
+	UsernameSource string
+	TokenSource    string
+	// FallbackUsername and FallbackToken are used only when neither the
+	// options nor server-settings.json and player-data.json provide that
+	// credential. FallbackSource describes where they came from.
+	FallbackUsername string
+	FallbackToken    string
+	FallbackSource   string
+	// LogLevel selects the console verbosity.
+	LogLevel LogLevel
+	// FactorioVersion, when set, replaces the version reported by the
+	// Factorio binary. Only the major.minor part is used.
+	FactorioVersion string
+	// PreferVersion, when set, is matched against release factorio_version
+	// fields instead of the installed version, which is still detected.
+	// Use it to prepare mods for a server running another Factorio version.
+	PreferVersion string
+	// IgnoreVersionCheck selects the newest release of every mod even when
+	// its factorio_version does not match.
+	IgnoreVersionCheck bool
+	// BuiltInMods names extra mods to treat like the bundled base game mods:
+	// never queried on the portal, downloaded, or resolved as dependencies.
+	BuiltInMods []string
+	// NoFsync skips flushing downloads and mod-list.json to stable storage.
+	NoFsync bool
+	// MaxDownloadSize caps the size of a single download in bytes. Zero
+	// selects DefaultMaxDownloadBytes.
+	MaxDownloadSize int64
+	// DownloadCache names a directory of release zips, keyed by digest and
+	// file name, that cache-hit downloads are linked or copied from and fresh
+	// downloads are added to. Several installs can share one.
+	DownloadCache string
+	// KeepVersions is how many releases of each mod, including the latest,
+	// stay on disk after an update. Zero keeps only the latest.
+	KeepVersions int
+	// NoPrune leaves every older release on disk, overriding KeepVersions.
+	NoPrune bool
+	// Force lets updates replace, and prune around, mods installed as
+	// unpacked directories, which are otherwise left alone as dev installs.
+	Force bool
+	// PreserveOrder keeps mod-list.json entries in the order they were read,
+	// appending newly tracked mods at the end, instead of sorting by name.
+	PreserveOrder bool
+	// LenientModList accepts common mod-list.json mistakes, such as
+	// "enabled": "true", with a warning instead of failing to parse.
+	LenientModList bool
+	// ReadOnly makes every operation that would write to disk fail with
+	// ErrReadOnly, for mods directories mounted read-only. The metadata and
+	// hash caches are simply not saved, and interrupted downloads are not
+	// swept.
+	ReadOnly bool
+	// HTTPClient, when set, is used for every portal request instead of a
+	// client of the Updater's own, so several Updaters can share one pool of
+	// connections. Nil selects NewHTTPClient with the transport options below.
+	HTTPClient *http.Client
+	// RequestModifier, when set, is called on every metadata, credential
+	// check, and download request just before it is sent, e.g. to add the
+	// header an authenticating proxy expects or to rewrite the URL.
+	RequestModifier func(*http.Request)
+	// Mirror, when set, is an http(s) base URL that release zips are
+	// downloaded from, at the portal's download path. Metadata and hashes
+	// still come from the portal, and the mirror never sees the credentials.
+	Mirror string
+	// Transport tunes the client built when HTTPClient is nil.
+	Transport TransportOptions
+	// Offline answers metadata queries from the cache in the mods directory
+	// and makes no network calls; mods without a cache entry stay unresolved.
+	Offline bool
+	// RefreshMetadata fetches every mod from the portal even when the cache
+	// holds a recent entry; the fresh responses are still cached.
+	RefreshMetadata bool
+	// Init creates the mods directory and a mod-list.json enabling only base
+	// when they are missing, for a fresh server.
+	Init bool
+	// AllowPrerelease lets release selection pick versions that look like
+	// pre-releases (e.g. "1.2.0-beta"), which are skipped by default.
+	AllowPrerelease bool
+	// NoDeps resolves only the mods already tracked, without pulling in
+	// their missing dependencies.
+	NoDeps bool
+	// StrictDependencies makes ResolveMetadata fail with an
+	// *UnsatisfiedDependenciesError when a required dependency of an enabled
+	// mod cannot be satisfied, instead of leaving it as a warning.
+	StrictDependencies bool
+	// AutoApprove, when set, makes UpdateMods apply only updates up to this
+	// magnitude and report bigger ones in UpdateResult.Held. MagnitudeNone
+	// approves every update.
+	AutoApprove UpdateMagnitude
+	// MaxDepth limits how many dependency hops transitive resolution
+	// follows from the tracked mods; zero means no limit. Dependencies past
+	// the limit are tracked but left unresolved.
+	MaxDepth int
+	// MaxMods makes ResolveMetadata fail with ErrTooManyMods instead of
+	// tracking more than this many mods; zero means no limit.
+	MaxMods int
+	// OnEvent, when set, receives structured progress events during
+	// resolution and downloads. Calls are serialized.
+	OnEvent func(Event)
+}
+
+// majorMinor normalizes a user-supplied Factorio version such as "2.0" or
+// "2.0.28" to its major.minor part.
+func majorMinor(version string) (string, error) {
+	match := factVerOverrideRe.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("invalid factorio version %q: expected major.minor, e.g. 2.0", version)
+	}
+	return fmt.Sprintf("%s.%s", match[1], match[2]), nil
+}
+
+// ValidateModPath checks that path names an existing directory, returning
+// an error that says what is wrong otherwise.
+// Why: Reading mod-list.json from a file or a typo'd path otherwise fails
+// with a bare "not a directory" or is silently treated as an empty install.
+func ValidateModPath(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("mods directory %s does not exist; check ROOT_DIR or --mod-path, or pass --init to create it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("checking mods directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("mod path %s is a file, not a directory; --mod-path must point at the mods folder", path)
+	}
+	return nil
+}
+
+// scaffoldModDir creates the mods directory and an initial mod-list.json
+// enabling just the base game, leaving anything that already exists alone.
+func (u *Updater) scaffoldModDir() error {
+	if err := u.checkWritable(u.modPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(u.modPath, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", u.modPath, err)
+	}
+
+	modListPath := u.ModListPath()
+	if _, err := os.Stat(modListPath); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	initial := struct {
+		Mods []modListEntry `json:"mods"`
+	}{Mods: []modListEntry{{Name: "base", Enabled: true}}}
+	bytes, err := json.MarshalIndent(initial, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling mod-list: %w", err)
+	}
+	if err := u.writeFileAtomic(modListPath, bytes, 0600); err != nil {
+		return fmt.Errorf("writing mod-list: %w", err)
+	}
+	u.WriteLog("Created %s", modListPath)
+	u.infof("Created %s\n", modListPath)
+	return nil
+}
+
+// newUpdater copies opts into an Updater without reading or checking
+// anything.
+func newUpdater(opts Options) *Updater {
+	return &Updater{
+		modServerURL:       "https://mods.factorio.com",
+		settingsPath:       opts.SettingsPath,
+		dataPath:           opts.DataPath,
+		modPath:            opts.ModPath,
+		modListFile:        opts.ModListPath,
+		factPath:           opts.FactPath,
+		username:           opts.Username,
+		token:              opts.Token,
+		usernameSource:     credentialSource(opts.Username, opts.UsernameSource),
+		tokenSource:        credentialSource(opts.Token, opts.TokenSource),
+		fallbackUsername:   opts.FallbackUsername,
+		fallbackToken:      opts.FallbackToken,
+		fallbackSource:     opts.FallbackSource,
+		logLevel:           opts.LogLevel,
+		ignoreVersionCheck: opts.IgnoreVersionCheck,
+		extraBuiltInMods:   opts.BuiltInMods,
+		noFsync:            opts.NoFsync,
+		maxDownloadBytes:   opts.MaxDownloadSize,
+		downloadCache:      opts.DownloadCache,
+		keepVersions:       opts.KeepVersions,
+		noPrune:            opts.NoPrune,
+		force:              opts.Force,
+		preserveOrder:      opts.PreserveOrder,
+		lenientModList:     opts.LenientModList,
+		readOnly:           opts.ReadOnly,
+		offline:            opts.Offline,
+		refreshMetadata:    opts.RefreshMetadata,
+		noDeps:             opts.NoDeps,
+		maxDepth:           opts.MaxDepth,
+		maxMods:            opts.MaxMods,
+		allowPrerelease:    opts.AllowPrerelease,
+		strictDeps:         opts.StrictDependencies,
+		autoApprove:        opts.AutoApprove,
+		onEvent:            opts.OnEvent,
+		mods:               make(map[string]*ModData),
+		httpClient:         opts.HTTPClient,
+		requestModifier:    opts.RequestModifier,
+	}
+}
+
 // NewUpdater hydrates the foundational configurations, attempting to ingest
 // authentication tokens from explicit CLI flags, then falling back to
 // server-settings.json or player-data.json.
 // Why: Centralizes instantiation and enforces fail-fast credential, version,
 // and local mod resolution before permitting any network operations.
-func NewUpdater(settingsPath, dataPath, modPath, factPath, username, token string) (*Updater, error) {
-	u := &Updater{
-		modServerURL: "https://mods.factorio.com",
-		settingsPath: settingsPath,
-		dataPath:     dataPath,
-		modPath:      modPath,
-		factPath:     factPath,
-		username:     username,
-		token:        token,
-		mods:         make(map[string]*ModData),
-		httpClient: &http.Client{
-			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				DialContext: (&net.Dialer{
-					Timeout:   10 * time.Second,
-					KeepAlive: 30 * time.Second,
-				}).DialContext,
-				ForceAttemptHTTP2:     true,
-				MaxIdleConns:          100,
-				IdleConnTimeout:       90 * time.Second,
-				TLSHandshakeTimeout:   10 * time.Second,
-				ExpectContinueTimeout: 1 * time.Second,
-				ResponseHeaderTimeout: 10 * time.Second,
-			},
-		},
+func NewUpdater(opts Options) (*Updater, error) {
+	u := newUpdater(opts)
+	if u.httpClient == nil {
+		client, err := NewHTTPClient(opts.Transport)
+		if err != nil {
+			return nil, fmt.Errorf("configuring HTTP client: %w", err)
+		}
+		u.httpClient = client
+	}
+	if opts.Mirror != "" {
+		mirror, err := parseMirrorURL(opts.Mirror)
+		if err != nil {
+			return nil, err
+		}
+		u.mirrorURL = mirror
 	}
 
 	if u.username == "" || u.token == "" {
@@ -177,12 +730,74 @@ func NewUpdater(settingsPath, dataPath, modPath, factPath, username, token strin
 		if pathsMsg == "" {
 			pathsMsg = "no default config files found"
 		}
-		return nil, fmt.Errorf("username or token not found in cli args or parsed configs (%s)", pathsMsg)
+		err := fmt.Errorf("username or token not found in cli args or parsed configs (%s)", pathsMsg)
+		return nil, errors.Join(append([]error{err}, u.credentialErrs...)...)
+	}
+	u.debugf("Using username from %s", u.usernameSource)
+	u.debugf("Using token from %s", u.tokenSource)
+
+	preferred := ""
+	if opts.PreferVersion != "" {
+		v, err := majorMinor(opts.PreferVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferred factorio version: %w", err)
+		}
+		preferred = v
+	}
+
+	if opts.FactorioVersion != "" {
+		v, err := majorMinor(opts.FactorioVersion)
+		if err != nil {
+			return nil, err
+		}
+		u.factVersion = v
+	} else {
+		if err := u.determineVersion(); err != nil {
+			return nil, fmt.Errorf("determining factorio version: %w", err)
+		}
+		if support := classifyFactorioVersion(u.factVersion); support != versionSupported {
+			pterm.Warning.Printf("Detected Factorio %s looks %s; most mods will report no compatible release. Pass --factorio-version if detection picked the wrong binary.\n", u.factVersion, support)
+			u.WriteLog("WARNING: detected Factorio version %s is %s", u.factVersion, support)
+		}
+	}
+	if preferred != "" && preferred != u.factVersion {
+		u.infof("Resolving mods for Factorio %s (installed: %s)\n", preferred, u.factVersion)
+		u.WriteLog("Resolving mods for Factorio %s instead of the installed %s", preferred, u.factVersion)
+		u.factVersion = preferred
+	}
+
+	if opts.Init {
+		if err := u.scaffoldModDir(); err != nil {
+			return nil, fmt.Errorf("initializing mods directory: %w", err)
+		}
+	}
+	if err := ValidateModPath(u.modPath); err != nil {
+		return nil, err
+	}
+
+	if u.factPath != "" {
+		u.bundledMods = detectBundledMods(dataDirCandidates(u.factPath))
+	}
+	if u.bundledMods == nil {
+		u.debugf("No Factorio data directory found; using the default built-in mod list")
+	} else {
+		u.debugf("Built-in mods from data directory: %s", strings.Join(u.bundledMods, ", "))
+	}
+
+	cache, err := loadMetadataCache(u.metadataCachePath())
+	if err != nil {
+		u.WriteLog("WARNING: %v; starting with an empty cache", err)
 	}
+	u.metaCache = cache
 
-	if err := u.determineVersion(); err != nil {
-		return nil, fmt.Errorf("determining factorio version: %w", err)
+	hashes, err := loadHashCache(u.hashCachePath())
+	if err != nil {
+		u.WriteLog("WARNING: %v; starting with an empty cache", err)
 	}
+	u.hashCache = hashes
+
+	// Recovered downloads must be in place before installed mods are detected.
+	u.sweepStaleTemps(time.Now())
 
 	if err := u.parseModList(); err != nil {
 		return nil, fmt.Errorf("parsing mod list: %w", err)
@@ -194,13 +809,6 @@ func NewUpdater(settingsPath, dataPath, modPath, factPath, username, token strin
 // parseTokens resolves authentication credentials by checking server-settings.json
 // first, then falling back to player-data.json. CLI flags take priority over both.
 func (u *Updater) parseTokens() error {
-	type configData struct {
-		Username        string `json:"username,omitempty"`
-		Token           string `json:"token,omitempty"`
-		ServiceUsername string `json:"service-username,omitempty"`
-		ServiceToken    string `json:"service-token,omitempty"`
-	}
-
 	loadConfig := func(path string) (*configData, error) {
 		if path == "" {
 			return nil, nil
@@ -210,7 +818,7 @@ func (u *Updater) parseTokens() error {
 			return nil, fmt.Errorf("reading config %s: %w", path, err)
 		}
 		var c configData
-		if err := json.Unmarshal(data, &c); err != nil {
+		if err := unmarshalConfig(data, &c); err != nil {
 			return nil, fmt.Errorf("parsing config %s: %w", path, err)
 		}
 		return &c, nil
@@ -238,109 +846,329 @@ func (u *Updater) parseTokens() error {
 	settings, err := loadConfig(u.settingsPath)
 	if err != nil {
 		pterm.Warning.Printf("Failed to parse %s: %v\n", u.settingsPath, err)
+		u.credentialErrs = append(u.credentialErrs, err)
 	}
 	data, err := loadConfig(u.dataPath)
 	if err != nil {
 		pterm.Warning.Printf("Failed to parse %s: %v\n", u.dataPath, err)
+		u.credentialErrs = append(u.credentialErrs, err)
 	}
 
+	settingsUser, settingsToken := settings.credentials(u.settingsPath, false)
+	dataUser, dataToken := data.credentials(u.dataPath, true)
+
 	if u.username == "" {
-		if settings != nil && settings.Username != "" {
-			u.username = settings.Username
-		} else if data != nil && data.ServiceUsername != "" {
-			u.username = data.ServiceUsername
+		if settingsUser != "" {
+			u.username, u.usernameSource = settingsUser, u.settingsPath
+		} else if dataUser != "" {
+			u.username, u.usernameSource = dataUser, u.dataPath
 		}
 	}
 
 	if u.token == "" {
-		if settings != nil && settings.Token != "" {
-			u.token = settings.Token
-		} else if data != nil && data.ServiceToken != "" {
-			u.token = data.ServiceToken
+		if settingsToken != "" {
+			u.token, u.tokenSource = settingsToken, u.settingsPath
+		} else if dataToken != "" {
+			u.token, u.tokenSource = dataToken, u.dataPath
 		}
 	}
 
+	if u.username == "" && u.fallbackUsername != "" {
+		u.username, u.usernameSource = u.fallbackUsername, credentialSource(u.fallbackUsername, u.fallbackSource)
+	}
+	if u.token == "" && u.fallbackToken != "" {
+		u.token, u.tokenSource = u.fallbackToken, credentialSource(u.fallbackToken, u.fallbackSource)
+	}
 	return nil
 }
 
+// configData holds the credential keys of server-settings.json and
+// player-data.json.
+type configData struct {
+	Username        string `json:"username,omitempty"`
+	Token           string `json:"token,omitempty"`
+	ServiceUsername string `json:"service-username,omitempty"`
+	ServiceToken    string `json:"service-token,omitempty"`
+}
+
+// credentials returns the username and token of the config loaded from
+// path, which is player-data.json when playerData is set. When the file has
+// none under its own keys, the other file's keys are tried, with a warning.
+func (c *configData) credentials(path string, playerData bool) (username, token string) {
+	if c == nil {
+		return "", ""
+	}
+	ownUser, ownToken, otherUser, otherToken := c.Username, c.Token, c.ServiceUsername, c.ServiceToken
+	want, other := "server-settings.json", "player-data.json"
+	if playerData {
+		ownUser, ownToken, otherUser, otherToken = otherUser, otherToken, ownUser, ownToken
+		want, other = other, want
+	}
+	if ownUser != "" || ownToken != "" || (otherUser == "" && otherToken == "") {
+		return ownUser, ownToken
+	}
+	pterm.Warning.Printf("%s has the credential keys of %s, not %s; check that --server-settings and --player-data are not swapped\n", path, other, want)
+	return otherUser, otherToken
+}
+
+// credentialSource returns the description of where a credential passed in
+// Options came from, or "" when none was passed.
+func credentialSource(value, source string) string {
+	switch {
+	case value == "":
+		return ""
+	case source == "":
+		return "the updater options"
+	}
+	return source
+}
+
+// CredentialSources reports where the username and token in use were read
+// from: a description supplied with Options, or the path of
+// server-settings.json or player-data.json.
+// Why: When rotating tokens it matters which file the working one lives in.
+func (u *Updater) CredentialSources() (username, token string) {
+	return u.usernameSource, u.tokenSource
+}
+
+// versionProbeTimeout bounds how long determineVersion waits for the binary.
+// It is a variable so tests can shorten it.
+var versionProbeTimeout = 5 * time.Second
+
+// maxProbeOutput caps how much binary output a VersionProbeError carries.
+const maxProbeOutput = 2048
+
+// VersionProbeErrorKind categorizes why the Factorio version could not be
+// read from the binary.
+type VersionProbeErrorKind int
+
+const (
+	// ProbeNotRunnable means the binary could not be started at all, e.g. it
+	// does not exist or is not executable.
+	ProbeNotRunnable VersionProbeErrorKind = iota
+	// ProbeTimeout means the binary did not exit within versionProbeTimeout.
+	ProbeTimeout
+	// ProbeFailed means the binary ran but exited unsuccessfully.
+	ProbeFailed
+	// ProbeUnparseable means the binary ran but printed no version line.
+	ProbeUnparseable
+)
+
+// VersionProbeError reports a failed "factorio --version" run together with
+// whatever the binary printed before it failed.
+type VersionProbeError struct {
+	Path   string
+	Kind   VersionProbeErrorKind
+	Output string // combined stdout/stderr, trimmed to maxProbeOutput
+	Err    error
+}
+
+func (e *VersionProbeError) Error() string {
+	var msg string
+	switch e.Kind {
+	case ProbeNotRunnable:
+		msg = fmt.Sprintf("cannot run factorio binary %q: %v", e.Path, e.Err)
+	case ProbeTimeout:
+		msg = fmt.Sprintf("factorio binary %q did not report its version within %s", e.Path, versionProbeTimeout)
+	case ProbeFailed:
+		msg = fmt.Sprintf("factorio binary %q failed: %v", e.Path, e.Err)
+	default:
+		msg = fmt.Sprintf("could not parse version from factorio binary %q output", e.Path)
+	}
+	if e.Output == "" {
+		return msg + " (no output)"
+	}
+	return fmt.Sprintf("%s; output: %s", msg, e.Output)
+}
+
+func (e *VersionProbeError) Unwrap() error {
+	return e.Err
+}
+
 // determineVersion executes the Factorio binary with --version and parses
-// the major.minor version string from its output.
+// the major.minor version string from its output. Failures are returned as a
+// *VersionProbeError carrying any output captured so far.
 // Why: Context timeout prevents the application from hanging indefinitely if
 // the local factorio executable is artificially slow or blocking.
 func (u *Updater) determineVersion() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), versionProbeTimeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, u.factPath, "--version")
+	// A wrapper script's children can keep the output pipe open after the
+	// script is killed; stop waiting for them shortly after the timeout.
+	cmd.WaitDelay = time.Second
 	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("running factorio binary %q: %w", u.factPath, err)
+	probeErr := &VersionProbeError{Path: u.factPath, Output: trimProbeOutput(output), Err: err}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		probeErr.Kind = ProbeTimeout
+		probeErr.Err = ctx.Err()
+		return probeErr
+	case errors.As(err, &exitErr):
+		probeErr.Kind = ProbeFailed
+		return probeErr
+	case err != nil:
+		probeErr.Kind = ProbeNotRunnable
+		return probeErr
+	}
+
+	version, ok := parseFactorioVersion(string(output))
+	if !ok {
+		probeErr.Kind = ProbeUnparseable
+		return probeErr
+	}
+	u.factVersion = version
+	if strings.Contains(string(output), "headless") {
+		u.debugf("Factorio %s is the headless server build", version)
 	}
 
-	match := factVerRe.FindStringSubmatch(string(output))
-	if len(match) > 2 {
-		u.factVersion = fmt.Sprintf("%s.%s", match[1], match[2])
-	} else {
-		return fmt.Errorf("could not parse version from factorio binary output: %s", string(output))
+	return nil
+}
+
+// parseFactorioVersion extracts the major.minor version from the output of
+// "factorio --version". The "Version: x.y.z" line is preferred; builds that
+// log a "Factorio x.y.z (build ...)" banner instead are read from that.
+func parseFactorioVersion(output string) (string, bool) {
+	match := factVerRe.FindStringSubmatch(output)
+	if match == nil {
+		match = factBannerRe.FindStringSubmatch(output)
+	}
+	if match == nil {
+		return "", false
+	}
+	return match[1] + "." + match[2], true
+}
+
+// trimProbeOutput trims whitespace and keeps at most the last maxProbeOutput
+// bytes, where an error message is most likely to be.
+func trimProbeOutput(output []byte) string {
+	out := strings.TrimSpace(string(output))
+	if len(out) > maxProbeOutput {
+		out = "..." + out[len(out)-maxProbeOutput:]
 	}
+	return out
+}
 
-	return nil
+// versionSupport classifies a Factorio version against the range the mod
+// portal actually serves releases for.
+type versionSupport int
+
+const (
+	versionSupported versionSupport = iota
+	versionTooOld
+	versionTooNew
+	versionUnparseable
+)
+
+func (v versionSupport) String() string {
+	switch v {
+	case versionSupported:
+		return "supported"
+	case versionTooOld:
+		return "unusually old"
+	case versionTooNew:
+		return "unusually new"
+	default:
+		return "unparseable"
+	}
+}
+
+// Bounds of the Factorio versions considered plausible targets. 0.18 stays
+// in range because versionMatch maps it onto 1.x releases.
+const (
+	minSupportedFactorio = "0.18"
+	maxSupportedMajor    = 2
+)
+
+// classifyFactorioVersion reports whether v is a Factorio version modern mods
+// are likely to publish releases for.
+func classifyFactorioVersion(v string) versionSupport {
+	match := versionRe.FindStringSubmatch(v)
+	if match == nil {
+		return versionUnparseable
+	}
+	if compareVersions(v, minSupportedFactorio) < 0 {
+		return versionTooOld
+	}
+	if major, _ := strconv.Atoi(match[1]); major > maxSupportedMajor {
+		return versionTooNew
+	}
+	return versionSupported
 }
 
 // parseModList reads mod-list.json and scans the mods directory for installed
 // zip files, populating the Updater's mod tracking map.
 func (u *Updater) parseModList() error {
-	modListPath := filepath.Join(u.modPath, "mod-list.json")
-	data, err := os.ReadFile(modListPath)
+	data, err := os.ReadFile(u.ModListPath())
 	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("reading mod-list.json: %w", err)
 	}
 
-	type modEntry struct {
-		Name    string `json:"name"`
-		Enabled bool   `json:"enabled"`
-	}
-	var modList struct {
-		Mods []modEntry `json:"mods"`
-	}
-
 	if data != nil {
-		if err := json.Unmarshal(data, &modList); err != nil {
+		entries, err := u.decodeModList(data)
+		if err != nil {
 			return fmt.Errorf("parsing mod-list.json: %w", err)
 		}
 
-		for _, m := range modList.Mods {
-			if isBuiltInMod(m.Name) {
+		for _, m := range entries {
+			if u.isBuiltInMod(m.Name) {
+				u.debugf("Skipping built-in mod %s", m.Name)
 				continue
 			}
+			if _, seen := u.mods[m.Name]; !seen {
+				u.listOrder = append(u.listOrder, m.Name)
+			}
 			u.mods[m.Name] = &ModData{
-				Name:    m.Name,
-				Enabled: m.Enabled,
-				Title:   m.Name, // Default to name until metadata resolves it
+				Name:          m.Name,
+				Enabled:       m.Enabled,
+				Title:         m.Name, // Default to name until metadata resolves it
+				PinnedVersion: m.Version,
+				Source:        FromModList,
 			}
 		}
+		for _, name := range u.listOrder {
+			data := u.mods[name]
+			u.savedModList = append(u.savedModList, modListEntry{Name: name, Enabled: data.Enabled, Version: data.PinnedVersion})
+		}
 	}
 
-	// Detect currently installed mods from zip filenames
+	// Detect currently installed mods from zip filenames and unpacked mod
+	// directories
 	files, err := os.ReadDir(u.modPath)
 	if err == nil {
+		u.zipsMu.Lock()
+		u.zips = buildZipIndex(files)
+		u.zipsMu.Unlock()
 		for _, f := range files {
-			if !f.IsDir() {
-				match := modZipRe.FindStringSubmatch(f.Name())
-				if len(match) == 3 {
-					name := match[1]
-					version := match[2]
-					if m, ok := u.mods[name]; ok {
+			if u.isDirEntry(f) {
+				u.trackDirMod(f.Name())
+				continue
+			}
+			match := modZipRe.FindStringSubmatch(f.Name())
+			if len(match) == 3 {
+				name := match[1]
+				version := match[2]
+				if u.isBuiltInMod(name) {
+					u.debugf("Skipping built-in mod %s", name)
+					continue
+				}
+				if m, ok := u.mods[name]; ok {
+					// Several releases may sit side by side; track the newest.
+					// A directory install takes precedence over zips.
+					if m.InstallDir == "" && (!m.Installed || compareVersions(version, m.Version) > 0) {
 						m.Installed = true
 						m.Version = version
-					} else {
-						u.mods[name] = &ModData{
-							Name:      name,
-							Title:     name,
-							Enabled:   true,
-							Installed: true,
-							Version:   version,
-						}
+					}
+				} else {
+					u.mods[name] = &ModData{
+						Name:      name,
+						Title:     name,
+						Enabled:   true,
+						Installed: true,
+						Version:   version,
+						Source:    FromModList,
 					}
 				}
 			}
@@ -350,6 +1178,31 @@ func (u *Updater) parseModList() error {
 	return nil
 }
 
+// trackDirMod marks the mod unpacked in the mods directory entry dirName as
+// installed, taking its name and version from info.json.
+func (u *Updater) trackDirMod(dirName string) {
+	info, ok := readDirMod(filepath.Join(u.modPath, dirName))
+	if !ok {
+		u.debugf("Ignoring directory %s: no valid info.json", dirName)
+		return
+	}
+	if u.isBuiltInMod(info.Name) {
+		u.debugf("Skipping built-in mod %s", info.Name)
+		return
+	}
+	m, ok := u.mods[info.Name]
+	if !ok {
+		m = &ModData{Name: info.Name, Title: info.Name, Enabled: true, Source: FromModList}
+		u.mods[info.Name] = m
+	}
+	// The directory is what the developer works on, so it takes precedence
+	// over any zip of the same mod.
+	m.Installed = true
+	m.Version = info.Version
+	m.InstallDir = dirName
+	u.debugf("Found %s %s installed as directory %s", info.Name, info.Version, dirName)
+}
+
 // versionMatch determines if a mod release is compatible with the installed
 // Factorio version, handling the legacy 0.18 ↔ 1.x equivalence.
 func versionMatch(installed, mod string) bool {
@@ -365,17 +1218,88 @@ func versionMatch(installed, mod string) bool {
 	}
 
 	if len(modMatch) > 3 && modMatch[3] != "" && len(instMatch) > 3 {
-		return mod == installed
+		return compareVersions(modMatch[0], instMatch[0]) == 0
 	}
 
 	return modMatch[1] == instMatch[1] && modMatch[2] == instMatch[2]
 }
 
+// releaseCompatible reports whether rel may be selected for the target
+// Factorio version. Releases without a factorio_version are accepted, since
+// some old or meta mods omit it yet still load.
+func (u *Updater) releaseCompatible(rel *ModRelease) bool {
+	if u.ignoreVersionCheck || rel.InfoJSON.FactorioVersion == "" {
+		return true
+	}
+	return versionMatch(u.factVersion, rel.InfoJSON.FactorioVersion)
+}
+
+// selectRelease picks the release to track: the one matching pinned if set,
+// otherwise the newest compatible release, skipping pre-releases unless
+// allowPrerelease is set. A stable release wins a tie with a pre-release of
+// the same number. It returns nil when nothing qualifies.
+func (u *Updater) selectRelease(releases []ModRelease, pinned string) *ModRelease {
+	var latest *ModRelease
+	for i := range releases {
+		rel := &releases[i]
+		if pinned != "" {
+			if rel.Version == pinned {
+				latest = rel
+			}
+			continue
+		}
+		if !u.releaseCompatible(rel) || (isPrerelease(rel.Version) && !u.allowPrerelease) {
+			continue
+		}
+		// The portal lists releases oldest first, but do not rely on it.
+		if latest == nil {
+			latest = rel
+		} else if c := compareVersions(rel.Version, latest.Version); c > 0 || (c == 0 && isPrerelease(latest.Version)) {
+			latest = rel
+		}
+	}
+	return latest
+}
+
+// newestRelease returns the highest-versioned release whatever its Factorio
+// version, skipping pre-releases unless allowPrerelease is set.
+func (u *Updater) newestRelease(releases []ModRelease) *ModRelease {
+	var newest *ModRelease
+	for i := range releases {
+		rel := &releases[i]
+		if isPrerelease(rel.Version) && !u.allowPrerelease {
+			continue
+		}
+		if newest == nil || compareVersions(rel.Version, newest.Version) > 0 {
+			newest = rel
+		}
+	}
+	return newest
+}
+
+// isPrerelease reports whether version looks like a pre-release: a semver
+// suffix such as "1.2.0-beta.1", or a part that is not a plain number, such
+// as "1.2.0rc1". Factorio itself only accepts numeric versions, so these are
+// test builds that some authors publish to the portal.
+func isPrerelease(version string) bool {
+	if strings.Contains(version, "-") {
+		return true
+	}
+	for part := range strings.SplitSeq(version, ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // RetrieveModMetadata queries the Factorio Mod Portal API for a specific mod,
 // selecting the latest release compatible with the detected Factorio version.
+// Successful responses are cached and reused for a few minutes unless
+// refreshMetadata is set; in offline mode the cache always answers instead.
 // Why: Segregates the network IO required for metadata hydration, allowing the
 // graph resolver to iteratively fetch details precisely when new deps are discovered.
-func (u *Updater) RetrieveModMetadata(mod string) error {
+func (u *Updater) RetrieveModMetadata(ctx context.Context, mod string) error {
 	u.modsMu.RLock()
 	m := u.mods[mod]
 	u.modsMu.RUnlock()
@@ -383,161 +1307,498 @@ func (u *Updater) RetrieveModMetadata(mod string) error {
 	if m == nil {
 		return fmt.Errorf("mod %q not found in tracking map", mod)
 	}
+	if u.offline {
+		meta, ok := u.metaCache.get(mod)
+		if !ok {
+			return &MetadataError{Mod: mod, Kind: MetadataOffline, Err: fmt.Errorf("no cached metadata for mod %q: %w", mod, ErrOffline)}
+		}
+		u.debugf("Using cached metadata for %s", mod)
+		return u.applyMetadata(m, meta)
+	}
+	if !u.refreshMetadata {
+		if meta, ok := u.metaCache.fresh(mod, time.Now().UTC()); ok {
+			u.debugf("Using metadata for %s cached within the last %s", mod, metadataCacheMaxAge)
+			return u.applyMetadata(m, meta)
+		}
+	}
 	apiURL := fmt.Sprintf("%s/api/mods/%s/full", u.modServerURL, url.PathEscape(mod))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
 		return fmt.Errorf("creating request for mod %q: %w", mod, err)
 	}
+	// /full lists every release and compresses well. Asking explicitly
+	// disables the transport's transparent decoding, so decodedBody handles it.
+	req.Header.Set("Accept-Encoding", "gzip")
+	u.modifyRequest(req)
+	u.debugf("GET %s", apiURL)
 
 	resp, err := u.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("fetching metadata for mod %q: %w", mod, err)
+		return &MetadataError{Mod: mod, Kind: MetadataNetwork, Err: fmt.Errorf("fetching metadata for mod %q: %w", mod, err)}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("mod portal returned status %d for %q", resp.StatusCode, mod)
+		kind := MetadataBadStatus
+		if resp.StatusCode == http.StatusNotFound {
+			kind = MetadataNotFound
+			// A taken-down mod still on disk is not broken, only unmaintained.
+			if m.Installed {
+				kind = MetadataOrphaned
+			}
+		}
+		return &MetadataError{Mod: mod, Kind: kind, Err: fmt.Errorf("mod portal returned status %d for %q", resp.StatusCode, mod)}
 	}
 
-	// Limit response body size to prevent memory exhaustion
-	limitedReader := io.LimitReader(resp.Body, maxAPIResponseBytes)
+	body, err := decodedBody(resp)
+	if err != nil {
+		return fmt.Errorf("decoding metadata for mod %q: %w", mod, err)
+	}
+	// Limit the decompressed size to prevent memory exhaustion
+	limitedReader := limitedBody(body, maxAPIResponseBytes)
 
 	var meta ModPortalMetadata
 	if err := json.NewDecoder(limitedReader).Decode(&meta); err != nil {
 		return fmt.Errorf("decoding metadata for mod %q: %w", mod, err)
 	}
+	u.metaCache.put(mod, meta, time.Now().UTC())
+
+	return u.applyMetadata(m, meta)
+}
 
+// applyMetadata selects the release to track for m from a portal response:
+// the pinned version if set, otherwise the newest compatible release.
+func (u *Updater) applyMetadata(m *ModData, meta ModPortalMetadata) error {
+	mod := m.Name
 	m.Title = meta.Title
 	m.Deprecated = meta.Deprecated
 
-	var latest *ModRelease
-	for i := range meta.Releases {
-		rel := &meta.Releases[i]
-		if versionMatch(u.factVersion, rel.InfoJSON.FactorioVersion) {
-			latest = rel
-		}
-	}
+	latest := u.selectRelease(meta.Releases, m.PinnedVersion)
 	m.Latest = latest
+	m.Newest = u.newestRelease(meta.Releases)
+	if newer := m.NewerIncompatible(); newer != nil {
+		u.debugf("%s %s requires Factorio %s; keeping the compatible release", mod, newer.Version, newer.InfoJSON.FactorioVersion)
+	}
+
+	if m.PinnedVersion != "" && latest == nil {
+		return &MetadataError{Mod: mod, Kind: MetadataPinMissing, Err: fmt.Errorf("pinned version %s of mod %q not found on mod portal", m.PinnedVersion, mod)}
+	}
+	if latest != nil && latest.InfoJSON.FactorioVersion == "" {
+		u.WriteLog("WARNING: %s %s declares no factorio_version; assuming it is compatible", mod, latest.Version)
+	}
 
 	return nil
 }
 
+// ResolveProgress is a snapshot of metadata resolution passed to the
+// ResolveMetadata progress callback.
+type ResolveProgress struct {
+	// Resolved counts the mods whose metadata fetch finished, successfully or not.
+	Resolved int
+	// Total counts every mod tracked so far, including discovered dependencies.
+	Total int
+	// Discovered counts dependencies that were not tracked before resolving.
+	Discovered int
+}
+
 // ResolveMetadata constructs the dependency graph by fetching metadata for all
 // tracked mods and iteratively resolving transitive dependencies until the
-// graph stabilizes.
+// graph stabilizes. With noDeps only the tracked mods are fetched, in bulk
+// where possible; with maxDepth, dependencies further away are tracked but
+// not fetched. With strictDeps, unsatisfiable required dependencies are
+// returned as an *UnsatisfiedDependenciesError.
+// onProgress, if non-nil, is called after every fetch; calls are
+// serialized, and Resolved never decreases. Once ctx is done no further
+// fetches start, and the returned error wraps context.Cause(ctx).
 // Why: Pre-computes the entire deployment plan to guarantee zero missing
 // dependencies before executing any destructive filesystem modifications.
-func (u *Updater) ResolveMetadata() error {
+func (u *Updater) ResolveMetadata(ctx context.Context, onProgress func(ResolveProgress)) error {
 	var errs []error
+	var progress ResolveProgress
 
-	// mu protects the errs slice during concurrent metadata hydration requests,
-	// preventing data races.
+	// mu protects the errs slice and progress counters during concurrent
+	// metadata hydration requests, preventing data races.
 	var mu sync.Mutex
 
-	// eg bounds concurrent HTTP fetches. Waiting on this group explicitly blocks
-	// function exit until all Goroutines complete, actively preventing memory leaks.
-	eg := new(errgroup.Group)
-	eg.SetLimit(10)
+	// bulk holds short metadata prefetched for the tracked mods; it is
+	// written before any fetch starts and only read afterwards.
+	var bulk map[string]ModPortalMetadata
+
+	// requested holds every mod fetched so far, guarded by mu, so each is
+	// fetched at most once per call and a failed fetch is never retried.
+	requested := make(map[string]bool)
+
+	fetch := func(mod string) {
+		mu.Lock()
+		again := requested[mod]
+		requested[mod] = true
+		mu.Unlock()
+		if again {
+			return
+		}
 
-	// Collect and sort mod names to ensure deterministic network dispatch order
-	u.modsMu.RLock()
-	var modNames []string
-	for mod := range u.mods {
-		modNames = append(modNames, mod)
-	}
-	u.modsMu.RUnlock()
-	slices.Sort(modNames)
+		u.modsMu.RLock()
+		m := u.mods[mod]
+		u.modsMu.RUnlock()
+
+		if ctx.Err() != nil {
+			m.ResolveErr = context.Cause(ctx)
+			return
+		}
+		var err error
+		if meta, ok := bulk[mod]; ok {
+			// Not cached: offline runs may follow dependencies, which the
+			// short form lacks.
+			err = u.applyMetadata(m, meta)
+		} else {
+			err = u.RetrieveModMetadata(ctx, mod)
+		}
+		if err != nil && ctx.Err() != nil {
+			m.ResolveErr = context.Cause(ctx)
+			return // cut short by ctx, not a failure of this mod
+		}
+		var metaErr *MetadataError
+		if err != nil && !errors.As(err, &metaErr) {
+			err = &MetadataError{Mod: mod, Kind: MetadataOther, Err: err}
+		}
+		m.ResolveErr = err
+		if metaErr != nil && metaErr.Kind == MetadataOrphaned {
+			// Reported as a warning: the installed copy keeps working.
+			u.WriteLog("WARNING: %s is installed but was removed from the mod portal; keeping the local copy", mod)
+			err = nil
+		}
+
+		u.emit(Event{Type: EventModFetched, Mod: mod, Error: errorText(err)})
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		progress.Resolved++
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+
+	// eg bounds concurrent HTTP fetches. Waiting on this group explicitly blocks
+	// function exit until all Goroutines complete, actively preventing memory leaks.
+	eg := new(errgroup.Group)
+	eg.SetLimit(metadataWorkers)
+
+	// Collect and sort mod names to ensure deterministic network dispatch order
+	u.modsMu.RLock()
+	var modNames []string
+	for mod := range u.mods {
+		modNames = append(modNames, mod)
+	}
+	u.modsMu.RUnlock()
+	slices.Sort(modNames)
+	if err := u.checkModLimit(len(modNames)); err != nil {
+		return err
+	}
+	progress.Total = len(modNames)
+	u.emit(Event{Type: EventResolveStart, Total: int64(len(modNames))})
+
+	if names := u.bulkCandidates(modNames); len(names) > 0 {
+		var err error
+		if bulk, err = u.prefetchBulkMetadata(ctx, names); err != nil {
+			u.WriteLog("WARNING: %v; fetching mod metadata one by one", err)
+		}
+	}
 
 	// Fetch metadata for all initially tracked mods
 	for _, mod := range modNames {
 		eg.Go(func() error {
-			if err := u.RetrieveModMetadata(mod); err != nil {
-				mu.Lock()
-				errs = append(errs, err)
-				mu.Unlock()
-			}
+			fetch(mod)
 			return nil
 		})
 	}
 	_ = eg.Wait()
 
-	// Resolve missing transitive deps dynamically
-	for {
+	// Resolve missing transitive deps dynamically, unless the user manages
+	// dependencies themselves. Each round discovers the deps one hop further
+	// from the tracked mods.
+	var limitErr error
+	for depth := 1; !u.noDeps && ctx.Err() == nil; depth++ {
 		missingMods := make(map[string]bool)
 
 		u.modsMu.RLock()
+		mu.Lock()
 		for _, data := range u.mods {
 			if data.Latest == nil {
 				continue
 			}
 
-			for _, depStr := range data.Latest.InfoJSON.Dependencies {
-				depStr = strings.TrimSpace(depStr)
-				// Skip optional (?) and incompatible (!) dependencies
-				if strings.HasPrefix(depStr, "!") || strings.HasPrefix(depStr, "?") || strings.HasPrefix(depStr, "(?)") {
-					continue
-				}
-
-				match := depRe.FindStringSubmatch(depStr)
-				if len(match) > 1 {
-					depName := match[1]
-					if isBuiltInMod(depName) {
-						continue
-					}
-					if _, ok := u.mods[depName]; !ok {
-						missingMods[depName] = true
-					}
+			for _, dep := range u.requiredDependencies(data.Latest) {
+				if _, ok := u.mods[dep.name]; !ok && !requested[dep.name] {
+					missingMods[dep.name] = true
 				}
 			}
 		}
+		tracked := len(u.mods)
+		mu.Unlock()
 		u.modsMu.RUnlock()
 
 		if len(missingMods) == 0 {
 			break
 		}
+		if limitErr = u.checkModLimit(tracked + len(missingMods)); limitErr != nil {
+			break
+		}
 
+		beyondLimit := u.maxDepth > 0 && depth > u.maxDepth
 		var newModNames []string
 		u.modsMu.Lock()
 		for m := range missingMods {
-			newModNames = append(newModNames, m)
-			u.mods[m] = &ModData{
+			data := &ModData{
 				Name:    m,
 				Title:   m,
 				Enabled: true,
+				Source:  FromDependency,
+				Depth:   depth,
 			}
+			if beyondLimit {
+				data.ResolveErr = ErrDepthLimit
+			}
+			newModNames = append(newModNames, m)
+			u.mods[m] = data
 		}
 		u.modsMu.Unlock()
 		slices.Sort(newModNames)
 
+		if beyondLimit {
+			u.WriteLog("Not resolving %d dependencies beyond depth %d: %s",
+				len(newModNames), u.maxDepth, strings.Join(newModNames, ", "))
+			break
+		}
+
+		mu.Lock()
+		progress.Total += len(newModNames)
+		progress.Discovered += len(newModNames)
+		mu.Unlock()
+
 		// egDeps bounds concurrent missing dependency metadata fetches.
 		// Guaranteeing we await all Goroutines averts memory leaks on closure.
 		egDeps := new(errgroup.Group)
-		egDeps.SetLimit(10)
+		egDeps.SetLimit(metadataWorkers)
 
 		for _, m := range newModNames {
 			egDeps.Go(func() error {
-				if err := u.RetrieveModMetadata(m); err != nil {
-					mu.Lock()
-					errs = append(errs, err)
-					mu.Unlock()
-				}
+				fetch(m)
 				return nil
 			})
 		}
 		_ = egDeps.Wait()
 	}
 
+	if err := u.saveMetadataCache(); err != nil {
+		u.WriteLog("WARNING: %v", err)
+	}
+
+	if limitErr != nil {
+		return limitErr
+	}
+	if ctx.Err() != nil {
+		stopped := fmt.Errorf("metadata resolution stopped after %d of %d mods: %w", progress.Resolved, progress.Total, context.Cause(ctx))
+		if len(errs) > 0 {
+			return errors.Join(stopped, &ResolveError{Errs: errs})
+		}
+		return stopped
+	}
+
+	unsatisfied := u.unsatisfiedDependencies()
+	if u.strictDeps && len(unsatisfied) > 0 {
+		depErr := &UnsatisfiedDependenciesError{Errs: unsatisfied}
+		if len(errs) > 0 {
+			return errors.Join(depErr, &ResolveError{Errs: errs})
+		}
+		return depErr
+	}
+
 	if len(errs) > 0 {
-		return fmt.Errorf("encountered %d metadata errors: %w", len(errs), errors.Join(errs...))
+		return &ResolveError{Errs: errs}
 	}
 
 	return nil
 }
 
+// checkModLimit returns an error wrapping ErrTooManyMods if tracking n mods
+// would exceed maxMods.
+func (u *Updater) checkModLimit(n int) error {
+	if u.maxMods <= 0 || n <= u.maxMods {
+		return nil
+	}
+	return fmt.Errorf("%w: resolving would track %d mods, more than the limit of %d (raise --max-mods if the modpack really is this large)", ErrTooManyMods, n, u.maxMods)
+}
+
+// unsatisfiedDependencies returns every required dependency of an enabled,
+// resolved mod that the resolved set cannot satisfy, sorted by mod and
+// dependency name. Releases outside the declared version constraint are also
+// logged as warnings.
+func (u *Updater) unsatisfiedDependencies() []*DependencyError {
+	u.modsMu.RLock()
+	defer u.modsMu.RUnlock()
+
+	var out []*DependencyError
+	for _, data := range u.mods {
+		if !data.Enabled {
+			continue
+		}
+		for _, dep := range u.requiredDependencies(data.Latest) {
+			depErr := &DependencyError{Mod: data.Name, Dependency: dep.name, Constraint: strings.TrimSpace(dep.op + " " + dep.version)}
+			target, ok := u.mods[dep.name]
+			switch {
+			case !ok:
+				depErr.Reason = "not tracked and dependencies are not being resolved"
+			case target.Latest == nil && target.UnresolvedReason() == NoCompatibleRelease:
+				depErr.Reason = fmt.Sprintf("no compatible release for Factorio %s", u.factVersion)
+			case target.Latest == nil:
+				depErr.Reason = target.UnresolvedReason().String()
+			case !dep.satisfiedBy(target.Latest.Version):
+				depErr.Reason = fmt.Sprintf("the selected release is %s", target.Latest.Version)
+				u.WriteLog("WARNING: %v", depErr)
+			default:
+				continue
+			}
+			out = append(out, depErr)
+		}
+	}
+	slices.SortFunc(out, func(a, b *DependencyError) int {
+		return cmp.Or(cmp.Compare(a.Mod, b.Mod), cmp.Compare(a.Dependency, b.Dependency))
+	})
+	return out
+}
+
+// requiredDependencies extracts the mandatory, non-built-in dependencies
+// declared by a release, including "~" ones, together with their version
+// constraints. Optional and incompatible dependencies are skipped.
+func (u *Updater) requiredDependencies(rel *ModRelease) []dependency {
+	if rel == nil {
+		return nil
+	}
+
+	var deps []dependency
+	for _, depStr := range rel.InfoJSON.Dependencies {
+		dep, ok := parseDependency(depStr)
+		// Skip optional, hidden optional, and incompatible dependencies
+		if !ok || !dep.required() || u.isBuiltInMod(dep.name) {
+			continue
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// depKind classifies a dependency by its info.json prefix.
+type depKind int
+
+const (
+	depRequired       depKind = iota // no prefix
+	depIncompatible                  // "!"
+	depOptional                      // "?"
+	depHiddenOptional                // "(?)"
+	depNoLoadOrder                   // "~", required but does not affect load order
+)
+
+// dependency is a single parsed entry from a release's info.json dependency list.
+type dependency struct {
+	kind    depKind
+	name    string
+	op      string // comparison operator, empty when unconstrained
+	version string
+}
+
+// required reports whether the dependency must be installed for the
+// depending mod to load.
+func (d dependency) required() bool {
+	return d.kind == depRequired || d.kind == depNoLoadOrder
+}
+
+// satisfiedBy reports whether the given release version meets the
+// dependency's version constraint. Unconstrained dependencies accept any version.
+func (d dependency) satisfiedBy(version string) bool {
+	if d.op == "" {
+		return true
+	}
+
+	c := compareVersions(version, d.version)
+	switch d.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case "=":
+		return c == 0
+	case ">=":
+		return c >= 0
+	case ">":
+		return c > 0
+	default:
+		return false
+	}
+}
+
+// compareVersions compares two dotted numeric version strings segment by
+// segment, treating missing segments as zero. It returns -1, 0, or +1.
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var ai, bi int
+		if i < len(as) {
+			ai, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bi, _ = strconv.Atoi(bs[i])
+		}
+		if c := cmp.Compare(ai, bi); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// parseDependency splits a dependency string such as "? some mod >= 1.0.0"
+// into its prefix kind, mod name, and optional version constraint, following
+// Factorio's grammar: an optional "!", "?", "(?)", or "~" prefix, the name,
+// then an optional operator and version. Mod names may contain spaces, so
+// the name is matched lazily and trimmed.
+func parseDependency(depStr string) (dependency, bool) {
+	match := depRe.FindStringSubmatch(strings.TrimSpace(depStr))
+	if match == nil {
+		return dependency{}, false
+	}
+
+	name := strings.TrimSpace(match[depRe.SubexpIndex("name")])
+	if name == "" {
+		return dependency{}, false
+	}
+
+	kind := depRequired
+	switch match[depRe.SubexpIndex("prefix")] {
+	case "!":
+		kind = depIncompatible
+	case "?":
+		kind = depOptional
+	case "(?)":
+		kind = depHiddenOptional
+	case "~":
+		kind = depNoLoadOrder
+	}
+
+	return dependency{
+		kind:    kind,
+		name:    name,
+		op:      match[depRe.SubexpIndex("op")],
+		version: match[depRe.SubexpIndex("ver")],
+	}, true
+}
+
 // GetMods returns a sorted snapshot of all tracked mods, ordered alphabetically
 // by title for deterministic UI rendering.
 // Why: Ensures the CLI or structured output consumes a predictable sequence,
@@ -557,30 +1818,190 @@ func (u *Updater) GetMods() []*ModData {
 	return list
 }
 
-// saveModList writes the current mod tracking state back to mod-list.json,
-// creating a timestamped backup of the previous version first.
-func (u *Updater) saveModList() error {
-	type modEntry struct {
-		Name    string `json:"name"`
-		Enabled bool   `json:"enabled"`
+// UnresolvedMods returns the enabled mods left without a Latest release after
+// ResolveMetadata, sorted by name. Use ModData.UnresolvedReason to tell a
+// failed fetch from a mod with no compatible release.
+func (u *Updater) UnresolvedMods() []*ModData {
+	u.modsMu.RLock()
+	var list []*ModData
+	for _, m := range u.mods {
+		if m.Enabled && m.Latest == nil {
+			list = append(list, m)
+		}
 	}
-	type modOut struct {
-		Mods []modEntry `json:"mods"`
+	u.modsMu.RUnlock()
+
+	slices.SortFunc(list, func(a, b *ModData) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+// FactorioVersion returns the Factorio version releases are matched against.
+func (u *Updater) FactorioVersion() string {
+	return u.factVersion
+}
+
+// ModPath returns the mods directory this Updater manages.
+func (u *Updater) ModPath() string {
+	return u.modPath
+}
+
+// ModListPath returns the path of the mod-list.json the Updater reads and
+// writes.
+func (u *Updater) ModListPath() string {
+	if u.modListFile != "" {
+		return u.modListFile
 	}
+	return filepath.Join(u.modPath, "mod-list.json")
+}
 
+// AddMod begins tracking the named mod as enabled so the next ResolveMetadata
+// and UpdateMods pass installs it. Mods that are already tracked are left as-is.
+func (u *Updater) AddMod(name string) error {
+	if u.isBuiltInMod(name) {
+		return fmt.Errorf("mod %q is built in and cannot be installed from the portal", name)
+	}
+
+	u.modsMu.Lock()
+	defer u.modsMu.Unlock()
+	if _, ok := u.mods[name]; !ok {
+		u.mods[name] = &ModData{Name: name, Title: name, Enabled: true, Source: FromModList}
+	}
+	return nil
+}
+
+// PinMod fixes the named mod to an exact release version, or clears the pin
+// when version is empty. The pin takes effect on the next ResolveMetadata.
+func (u *Updater) PinMod(name, version string) error {
+	u.modsMu.Lock()
+	defer u.modsMu.Unlock()
+	m, ok := u.mods[name]
+	if !ok {
+		return fmt.Errorf("mod %q not found in tracking map", name)
+	}
+	m.PinnedVersion = version
+	return nil
+}
+
+// RemoveMod deletes every versioned zip of the named mod from the mods
+// directory and stops tracking it, so the next saveModList drops its entry.
+func (u *Updater) RemoveMod(name string) error {
+	if err := u.checkWritable("the files of " + name); err != nil {
+		return err
+	}
+	files, err := os.ReadDir(u.modPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading mod directory: %w", err)
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		match := modZipRe.FindStringSubmatch(f.Name())
+		if len(match) == 3 && match[1] == name {
+			if err := os.Remove(filepath.Join(u.modPath, f.Name())); err != nil {
+				return fmt.Errorf("removing %s: %w", f.Name(), err)
+			}
+			u.unindexZip(f.Name())
+			u.hashCache.drop(f.Name())
+			u.WriteLog("Removed mod file: %s", f.Name())
+		}
+	}
+
+	u.modsMu.Lock()
+	delete(u.mods, name)
+	u.modsMu.Unlock()
+	return nil
+}
+
+// SaveModList persists the tracked mod state to mod-list.json. UpdateMods does
+// this itself; call it directly after changing the mod set without updating.
+func (u *Updater) SaveModList() error {
+	return u.saveModList()
+}
+
+// RewriteModList parses mod-list.json in opts.ModPath and writes it back in
+// the canonical form saveModList produces, returning the number of mods
+// written. Only the path, built-in mod, fsync, and log level options are
+// used: no credentials, Factorio binary, or network access are needed.
+func RewriteModList(opts Options) (int, error) {
+	u := &Updater{
+		modPath:          opts.ModPath,
+		modListFile:      opts.ModListPath,
+		factPath:         opts.FactPath,
+		logLevel:         opts.LogLevel,
+		extraBuiltInMods: opts.BuiltInMods,
+		noFsync:          opts.NoFsync,
+		preserveOrder:    opts.PreserveOrder,
+		lenientModList:   opts.LenientModList,
+		readOnly:         opts.ReadOnly,
+		mods:             make(map[string]*ModData),
+	}
+	if opts.Init {
+		if err := u.scaffoldModDir(); err != nil {
+			return 0, fmt.Errorf("initializing mods directory: %w", err)
+		}
+	}
+	if err := ValidateModPath(u.modPath); err != nil {
+		return 0, err
+	}
+	if u.factPath != "" {
+		u.bundledMods = detectBundledMods(dataDirCandidates(u.factPath))
+	}
+
+	if err := u.parseModList(); err != nil {
+		return 0, fmt.Errorf("parsing mod list: %w", err)
+	}
+	if err := u.saveModList(); err != nil {
+		return 0, fmt.Errorf("saving mod-list: %w", err)
+	}
+	return len(u.mods), nil
+}
+
+// modListEntries returns the mod-list.json entries for the tracked mods, in
+// the order saveModList writes them.
+func (u *Updater) modListEntries() []modListEntry {
 	u.modsMu.RLock()
-	out := modOut{Mods: make([]modEntry, 0, len(u.mods))}
+	entries := make([]modListEntry, 0, len(u.mods))
 	for mod, data := range u.mods {
-		out.Mods = append(out.Mods, modEntry{Name: mod, Enabled: data.Enabled})
+		entries = append(entries, modListEntry{Name: mod, Enabled: data.Enabled, Version: data.PinnedVersion})
 	}
 	u.modsMu.RUnlock()
 
-	slices.SortFunc(out.Mods, func(a, b modEntry) int {
+	// With preserveOrder, entries read from mod-list.json keep their position
+	// and newly tracked mods follow them, sorted by name.
+	rank := u.listOrderRank()
+	slices.SortFunc(entries, func(a, b modListEntry) int {
+		ra, okA := rank[a.Name]
+		rb, okB := rank[b.Name]
+		switch {
+		case okA && okB:
+			return cmp.Compare(ra, rb)
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
 		return cmp.Compare(a.Name, b.Name)
 	})
+	return entries
+}
+
+// saveModList writes the current mod tracking state back to mod-list.json,
+// creating a timestamped backup of the previous version first.
+func (u *Updater) saveModList() error {
+	type modOut struct {
+		Mods []modListEntry `json:"mods"`
+	}
+	out := modOut{Mods: u.modListEntries()}
 
-	modListPath := filepath.Join(u.modPath, "mod-list.json")
-	backupPath := filepath.Join(u.modPath, fmt.Sprintf("mod-list.%s.json", time.Now().Format("2006-01-02_1504.05")))
+	modListPath := u.ModListPath()
+	if err := u.checkWritable(modListPath); err != nil {
+		return err
+	}
+	backupPath := filepath.Join(filepath.Dir(modListPath), fmt.Sprintf("mod-list.%s.json", time.Now().Format("2006-01-02_1504.05")))
 
 	if err := os.Rename(modListPath, backupPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		pterm.Warning.Printf("Failed to backup mod-list.json: %v\n", err)
@@ -591,33 +2012,110 @@ func (u *Updater) saveModList() error {
 		return fmt.Errorf("marshalling mod-list: %w", err)
 	}
 
-	tmpPath := modListPath + ".tmp"
-	if err := os.WriteFile(tmpPath, bytes, 0600); err != nil {
-		return fmt.Errorf("writing mod-list to temporary file: %w", err)
+	// Report what is about to change for auditability, before the write.
+	for _, line := range diffModLists(u.savedModList, out.Mods).Lines() {
+		u.WriteLog("%s", line)
+		u.infof("%s\n", line)
 	}
 
-	if err := os.Rename(tmpPath, modListPath); err != nil {
-		return fmt.Errorf("atomically renaming mod-list: %w", err)
+	if err := u.writeFileAtomic(modListPath, bytes, 0600); err != nil {
+		return fmt.Errorf("writing mod-list: %w", err)
 	}
+	u.savedModList = out.Mods
 
 	return nil
 }
 
+// listOrderRank maps each name read from mod-list.json to its position when
+// preserveOrder is set, and returns nil otherwise.
+func (u *Updater) listOrderRank() map[string]int {
+	if !u.preserveOrder {
+		return nil
+	}
+	rank := make(map[string]int, len(u.listOrder))
+	for i, name := range u.listOrder {
+		rank[name] = i
+	}
+	return rank
+}
+
+// ModListSaveError reports that mod-list.json could not be written after
+// UpdateMods finished downloading. The downloads themselves are unaffected
+// and still listed in the UpdateResult.
+type ModListSaveError struct {
+	Path string
+	Err  error
+}
+
+func (e *ModListSaveError) Error() string {
+	return fmt.Sprintf("saving mod-list: %v", e.Err)
+}
+
+func (e *ModListSaveError) Unwrap() error {
+	return e.Err
+}
+
+// UpdateResult summarizes the mods changed by a single UpdateMods run.
+// Why: Gives hooks and structured consumers a stable payload describing what
+// changed, rather than only a count.
+type UpdateResult struct {
+	// Updated lists every mod whose latest release was downloaded, sorted by name.
+	Updated []UpdatedMod `json:"updated"`
+	// Held lists the updates skipped for exceeding Options.AutoApprove,
+	// sorted by name.
+	Held []HeldMod `json:"held,omitempty"`
+}
+
+// UpdatedMod describes a single mod download performed by UpdateMods.
+type UpdatedMod struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	// FromVersion is the previously installed version, empty for new installs.
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version"`
+}
+
 // UpdateMods iterates over all tracked mods, pruning outdated releases and
 // downloading the latest compatible versions. Errors for individual mods are
 // accumulated and returned collectively rather than halting the entire process.
+// Once ctx is done no further downloads start; mod-list.json is still saved
+// for the downloads that completed. In offline mode it returns ErrOffline
+// without touching the mods directory.
 // Why: Adopts a fault-tolerant batch application model, maximizing the number of
 // successfully updated mods even during partial Mod Portal outages.
-func (u *Updater) UpdateMods() (int, error) {
+func (u *Updater) UpdateMods(ctx context.Context) (UpdateResult, error) {
 	var errs []error
-	var updatedCount atomic.Int32
+	result := UpdateResult{Updated: []UpdatedMod{}}
+	if u.offline {
+		return result, ErrOffline
+	}
+	if err := u.checkWritable(u.modPath); err != nil {
+		return result, err
+	}
 
 	// mu provides thread-safe appends to the errs slice across parallel downloads.
 	var mu sync.Mutex
 
+	// Hash every already-installed release up front so the download phase only
+	// ever spins up for mods that actually need fetching.
+	sortedMods := u.GetMods()
+	result.Held = u.heldUpdates(sortedMods)
+	pending := u.pendingDownloads(sortedMods)
+	if len(pending) == 0 {
+		return result, u.finishUpToDate(sortedMods)
+	}
+
+	// raw selects the plain heartbeat output over progress bars. A terminal
+	// the multiprinter cannot drive falls back to it too.
+	raw := pterm.RawOutput
 	var multi *pterm.MultiPrinter
-	if !pterm.RawOutput {
-		multi, _ = pterm.DefaultMultiPrinter.Start()
+	if !raw && u.logLevel != LogQuiet {
+		var err error
+		if multi, err = startMultiPrinter(); err != nil {
+			multi, raw = nil, true
+			u.WriteLog("WARNING: progress display unavailable, using plain output: %v", err)
+			u.debugf("Progress display unavailable, using plain output: %v", err)
+		}
 	}
 
 	// AMP Linux Keep-Alive Workaround
@@ -626,11 +2124,11 @@ func (u *Updater) UpdateMods() (int, error) {
 	// We inject a tiny heartbeat to ping the pipe and force a sync every 4 seconds.
 	// We use a context here to guarantee the goroutine is torn down when the function exits,
 	// averting memory leaks or hanging channels if the errgroup returns early.
-	ctx, cancel := context.WithCancel(context.Background())
+	hbCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	var heartbeatWg sync.WaitGroup
-	if pterm.RawOutput {
+	if raw {
 		heartbeatWg.Go(func() {
 			t := time.NewTicker(4 * time.Second)
 			defer t.Stop()
@@ -639,7 +2137,7 @@ func (u *Updater) UpdateMods() (int, error) {
 				case <-t.C:
 					pterm.Print(".")
 					os.Stdout.Sync() //nolint:errcheck // Best-effort heartbeat flush
-				case <-ctx.Done():
+				case <-hbCtx.Done():
 					pterm.Println() // Flush to a clean line when downloads finish
 					return
 				}
@@ -650,38 +2148,57 @@ func (u *Updater) UpdateMods() (int, error) {
 	// eg bounds concurrent mod port API downloads to 5 parallel Goroutines.
 	// We wait on the group at the end to ensure no runaway Goroutines or memory leaks.
 	eg := new(errgroup.Group)
-	eg.SetLimit(5) // Bound concurrent downloads to prevent Mod Portal rate-limiting
-	sortedMods := u.GetMods()
-	for _, data := range sortedMods {
+	eg.SetLimit(downloadWorkers) // Bound concurrent downloads to prevent Mod Portal rate-limiting
+	skipped := 0
+	// logs and failures are indexed like sortedMods so each download's log
+	// lines and error are reported in mod order, not completion order.
+	logs := make([]modLog, len(sortedMods))
+	failures := make([]error, len(sortedMods))
+	for i, data := range sortedMods {
+		if data.Latest == nil {
+			if data.UnresolvedReason() != Orphaned {
+				errs = append(errs, u.missingReleaseError(data))
+			}
+			continue
+		}
+		if !pending[data.Name] {
+			continue
+		}
+
 		eg.Go(func() error {
-			if data.Latest == nil {
+			if ctx.Err() != nil {
 				mu.Lock()
-				errs = append(errs, fmt.Errorf("metadata or release missing for mod %q on factorio version %q", data.Name, u.factVersion))
+				skipped++
 				mu.Unlock()
 				return nil
 			}
-
-			didUpdate, err := u.downloadLatest(data.Name, multi)
-
-			mu.Lock()
-			if err != nil {
-				errs = append(errs, fmt.Errorf("downloading %q: %w", data.Name, err))
-			}
-			mu.Unlock()
-
+			err := u.downloadLatest(ctx, data.Name, multi, &logs[i])
 			if err != nil {
+				failures[i] = fmt.Errorf("downloading %q: %w", data.Name, err)
 				return nil
 			}
 
-			if didUpdate {
-				updatedCount.Add(1)
+			updated := UpdatedMod{Name: data.Name, Title: data.Title, ToVersion: data.Latest.Version}
+			if data.Installed {
+				updated.FromVersion = data.Version
 			}
+			mu.Lock()
+			result.Updated = append(result.Updated, updated)
+			mu.Unlock()
 			return nil
 		})
 	}
 	_ = eg.Wait()
+	for _, err := range failures {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if skipped > 0 {
+		errs = append(errs, fmt.Errorf("stopped before downloading %d mod(s): %w", skipped, context.Cause(ctx)))
+	}
 
-	if pterm.RawOutput {
+	if raw {
 		cancel()           // Stop the heartbeat explicitly
 		heartbeatWg.Wait() // Block until the final newline drops to prevent racing the CLI output
 	}
@@ -690,163 +2207,566 @@ func (u *Updater) UpdateMods() (int, error) {
 		_, _ = multi.Stop()
 		fmt.Println() // Flush cursor downward to prevent print masking
 	}
+	for i := range logs {
+		logs[i].flush(u)
+	}
 
-	// Safely prune old mod releases sequentially after rendering stops
-	for _, data := range sortedMods {
-		if data.Latest == nil {
+	// Prune old mod releases once rendering stops, from a single listing of
+	// the mods directory taken after every download has finished.
+	errs = append(errs, u.pruneAll(sortedMods, result.Updated)...)
+	if err := u.saveHashCache(); err != nil {
+		u.WriteLog("WARNING: %v", err)
+	}
+
+	if err := u.saveModList(); err != nil {
+		errs = append(errs, &ModListSaveError{Path: u.ModListPath(), Err: err})
+	}
+
+	slices.SortFunc(result.Updated, func(a, b UpdatedMod) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return result, errors.Join(errs...)
+}
+
+// startMultiPrinter starts the live display holding the download progress
+// bars. It is a variable so tests can simulate a terminal it cannot drive.
+var startMultiPrinter = func() (*pterm.MultiPrinter, error) {
+	return pterm.DefaultMultiPrinter.Start()
+}
+
+// finishUpToDate completes an UpdateMods run with nothing to download,
+// rewriting mod-list.json only when its content would change.
+func (u *Updater) finishUpToDate(mods []*ModData) error {
+	var errs []error
+	for _, data := range mods {
+		if data.Latest == nil && data.UnresolvedReason() != Orphaned {
+			errs = append(errs, u.missingReleaseError(data))
+		}
+	}
+	errs = append(errs, u.pruneAll(mods, nil)...)
+	if err := u.saveHashCache(); err != nil {
+		u.WriteLog("WARNING: %v", err)
+	}
+
+	if !slices.Equal(u.modListEntries(), u.savedModList) {
+		if err := u.saveModList(); err != nil {
+			errs = append(errs, &ModListSaveError{Path: u.ModListPath(), Err: err})
+		}
+	}
+	if pterm.RawOutput {
+		pterm.Println() // End the "Updating mods..." line like the heartbeat does
+	}
+	return errors.Join(errs...)
+}
+
+// missingReleaseError reports a mod UpdateMods cannot act on because no
+// release was resolved for it. Orphaned mods are not reported: there is
+// nothing to update, and their installed copy is left alone.
+func (u *Updater) missingReleaseError(data *ModData) error {
+	return fmt.Errorf("metadata or release missing for mod %q on factorio version %q", data.Name, u.factVersion)
+}
+
+// pruneAll prunes the old releases of every mod concurrently, and removes
+// directory installs that a forced update replaced with a zip. Each mod only
+// touches its own files, so the mods are independent; the returned errors
+// follow the order of mods.
+func (u *Updater) pruneAll(mods []*ModData, updated []UpdatedMod) []error {
+	if u.noPrune {
+		return nil
+	}
+	modErrs := make([]error, len(mods))
+	eg := new(errgroup.Group)
+	eg.SetLimit(runtime.NumCPU())
+	for i, data := range mods {
+		if data.Latest == nil || (data.InstallDir != "" && !u.force) {
 			continue
 		}
-		if err := u.pruneOld(data.Name); err != nil {
-			errs = append(errs, fmt.Errorf("pruning old releases for %q: %w", data.Name, err))
+		eg.Go(func() error {
+			var errs []error
+			if err := u.pruneOld(data.Name); err != nil {
+				errs = append(errs, fmt.Errorf("pruning old releases for %q: %w", data.Name, err))
+			}
+			replaced := slices.ContainsFunc(updated, func(m UpdatedMod) bool { return m.Name == data.Name })
+			if data.InstallDir != "" && replaced {
+				if err := u.removeDirMod(data); err != nil {
+					errs = append(errs, fmt.Errorf("replacing directory mod %q: %w", data.Name, err))
+				}
+			}
+			modErrs[i] = errors.Join(errs...)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	var errs []error
+	for _, err := range modErrs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// zipFile is a versioned release zip found in the mods directory.
+type zipFile struct{ name, version string }
+
+// zipIndex maps the modKey of a mod name to its release zips on disk.
+type zipIndex map[string][]zipFile
+
+// readDir lists a directory; tests swap it to count directory scans.
+var readDir = os.ReadDir
+
+// buildZipIndex groups the release zips among entries by mod, ignoring
+// directories and files not named "<mod>_<version>.zip".
+func buildZipIndex(entries []os.DirEntry) zipIndex {
+	idx := make(zipIndex)
+	for _, f := range entries {
+		if !f.IsDir() {
+			idx.add(f.Name())
+		}
+	}
+	return idx
+}
+
+// add records the zip named fileName if it names a release.
+func (idx zipIndex) add(fileName string) {
+	match := modZipRe.FindStringSubmatch(fileName)
+	if len(match) != 3 {
+		return
+	}
+	key := modKey(match[1])
+	if !slices.ContainsFunc(idx[key], func(z zipFile) bool { return z.name == fileName }) {
+		idx[key] = append(idx[key], zipFile{name: fileName, version: match[2]})
+	}
+}
+
+// remove forgets the zip named fileName.
+func (idx zipIndex) remove(fileName string) {
+	match := modZipRe.FindStringSubmatch(fileName)
+	if len(match) != 3 {
+		return
+	}
+	key := modKey(match[1])
+	idx[key] = slices.DeleteFunc(idx[key], func(z zipFile) bool { return z.name == fileName })
+}
+
+// modZips returns the release zips of mod on disk from the index, scanning
+// the mods directory if parseModList has not built it.
+func (u *Updater) modZips(mod string) ([]zipFile, error) {
+	u.zipsMu.Lock()
+	defer u.zipsMu.Unlock()
+	if u.zips == nil {
+		files, err := readDir(u.modPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading mod directory: %w", err)
 		}
+		u.zips = buildZipIndex(files)
 	}
+	return slices.Clone(u.zips[modKey(mod)]), nil
+}
 
-	if err := u.saveModList(); err != nil {
-		errs = append(errs, fmt.Errorf("saving mod-list: %w", err))
+// indexZip and unindexZip keep the index in step with files the Updater
+// writes or deletes. Before the first scan there is nothing to update.
+func (u *Updater) indexZip(fileName string) {
+	u.zipsMu.Lock()
+	defer u.zipsMu.Unlock()
+	if u.zips != nil {
+		u.zips.add(fileName)
 	}
+}
 
-	return int(updatedCount.Load()), errors.Join(errs...)
+func (u *Updater) unindexZip(fileName string) {
+	u.zipsMu.Lock()
+	defer u.zipsMu.Unlock()
+	if u.zips != nil {
+		u.zips.remove(fileName)
+	}
 }
 
-// pruneOld removes all versioned zip files for the given mod that do not
-// match the latest release version, ONLY if the latest version exists on disk.
+// hasZip reports whether the index lists the release zip fileName. Names
+// that are not "<mod>_<version>.zip" are assumed present, leaving the
+// answer to the hash check.
+func (u *Updater) hasZip(fileName string) bool {
+	match := modZipRe.FindStringSubmatch(fileName)
+	if len(match) != 3 {
+		return true
+	}
+	zips, err := u.modZips(match[1])
+	return err != nil || slices.ContainsFunc(zips, func(z zipFile) bool { return modNamesEqual(z.name, fileName) })
+}
+
+// pruneOld removes the versioned zip files for the given mod beyond the
+// keepVersions newest, always counting the latest release's file as one of
+// them, ONLY if that file exists on disk.
 func (u *Updater) pruneOld(mod string) error {
+	u.modsMu.RLock()
 	data := u.mods[mod]
+	u.modsMu.RUnlock()
 	if data == nil || data.Latest == nil {
 		return nil
 	}
 	if data.Latest.FileName == "" {
 		return fmt.Errorf("latest release for %q has empty filename, skipping prune", mod)
 	}
-	latestVersion := data.Latest.Version
 
 	// Sanitize against directory traversal payloads
 	safeFileName := filepath.Base(filepath.Clean(data.Latest.FileName))
-	latestPath := filepath.Join(u.modPath, safeFileName)
 
-	if _, err := os.Stat(latestPath); errors.Is(err, fs.ErrNotExist) {
+	// The portal's file name need not follow the mod name's casing, so the
+	// latest release is looked up directly rather than in the index.
+	if _, err := os.Stat(filepath.Join(u.modPath, safeFileName)); errors.Is(err, fs.ErrNotExist) {
 		// Newest version wasn't downloaded or is missing. Abort pruning to remain safe.
+		u.debugf("Skipping prune of %s: %s is not on disk", mod, safeFileName)
 		return nil
 	}
 
-	files, err := os.ReadDir(u.modPath)
+	onDisk, err := u.modZips(mod)
 	if err != nil {
-		return fmt.Errorf("reading mod directory: %w", err)
+		return err
 	}
 
-	prefix := mod + "_"
+	var older []zipFile
+	for _, z := range onDisk {
+		// Keep exactly the file that was downloaded and validated. Rebuilding
+		// "<mod>_<version>.zip" could disagree with the portal's file_name in
+		// casing or version formatting and delete the fresh release.
+		if !modNamesEqual(z.name, safeFileName) {
+			older = append(older, z)
+		}
+	}
 
-	for _, f := range files {
-		if f.IsDir() {
+	// The latest release takes one of the keepVersions slots; the rest go to
+	// the newest of the other releases on disk.
+	slices.SortFunc(older, func(a, b zipFile) int {
+		return compareVersions(b.version, a.version)
+	})
+	retain := max(u.keepVersions, 1) - 1
+	for i, rel := range older {
+		if i < retain {
+			u.debugf("Keeping %s (--keep-versions %d)", rel.name, u.keepVersions)
 			continue
 		}
-		name := f.Name()
-		if !strings.HasPrefix(name, prefix) {
+		removePath := filepath.Join(u.modPath, rel.name)
+		if err := os.Remove(removePath); err != nil {
+			return fmt.Errorf("removing %s: %w", rel.name, err)
+		}
+		u.unindexZip(rel.name)
+		u.hashCache.drop(rel.name)
+		u.WriteLog("Removed old release: %s", rel.name)
+		if !pterm.RawOutput {
+			u.infof("Removed old release: %s\n", rel.name)
+		}
+	}
+
+	return nil
+}
+
+// needsDownload reports whether the latest release of the given mod must be
+// fetched: it is not installed, the installed version differs, or the file
+// on disk fails hash validation.
+func (u *Updater) needsDownload(data *ModData) bool {
+	if !data.Installed || data.Version != data.Latest.Version {
+		return true
+	}
+
+	// Sanitize against directory traversal payloads
+	safeFileName := filepath.Base(filepath.Clean(data.Latest.FileName))
+	if !u.hasZip(safeFileName) {
+		return true // nothing to hash
+	}
+	algo, expected := data.Latest.checksum()
+	return !u.validateInstalled(safeFileName, algo, expected)
+}
+
+// pendingDownloads runs needsDownload concurrently over the mods with a
+// resolved release and returns the set of names that must be fetched.
+func (u *Updater) pendingDownloads(mods []*ModData) map[string]bool {
+	pending := make(map[string]bool)
+	var mu sync.Mutex
+
+	// eg bounds concurrent file hashing to the number of available CPUs.
+	eg := new(errgroup.Group)
+	eg.SetLimit(runtime.NumCPU())
+	for _, data := range mods {
+		if data.Latest == nil {
 			continue
 		}
-		match := modZipRe.FindStringSubmatch(name)
-		if len(match) == 3 && match[1] == mod && match[2] != latestVersion {
-			removePath := filepath.Join(u.modPath, name)
-			if err := os.Remove(removePath); err != nil {
-				return fmt.Errorf("removing %s: %w", name, err)
-			}
-			u.WriteLog("Removed old release: %s", name)
-			if !pterm.RawOutput {
-				pterm.Info.Printf("Removed old release: %s\n", name)
+		if data.InstallDir != "" && !u.force {
+			u.debugf("Skipping %s: installed as directory %s; use --force to replace it", data.Name, data.InstallDir)
+			continue
+		}
+		if u.exceedsAutoApprove(data) {
+			continue
+		}
+		eg.Go(func() error {
+			if u.needsDownload(data) {
+				mu.Lock()
+				pending[data.Name] = true
+				mu.Unlock()
+			} else {
+				algo, _ := data.Latest.checksum()
+				u.debugf("Skipping %s: %s is installed and passes %s validation", data.Name, data.Version, algo)
 			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	return pending
+}
+
+// downloadURL builds the authenticated portal URL for a release, using
+// net/url for safe credential encoding, or the same path on the mirror,
+// which is sent no credentials.
+func (u *Updater) downloadURL(rel *ModRelease) (string, error) {
+	if u.mirrorURL != "" {
+		dlURL, err := url.Parse(u.mirrorURL + rel.DownloadURL)
+		if err != nil {
+			return "", err
 		}
+		return dlURL.String(), nil
+	}
+	dlURL, err := url.Parse(fmt.Sprintf("%s%s", u.modServerURL, rel.DownloadURL))
+	if err != nil {
+		return "", err
 	}
+	q := dlURL.Query()
+	q.Set("username", u.username)
+	q.Set("token", u.token)
+	dlURL.RawQuery = q.Encode()
+	return dlURL.String(), nil
+}
 
-	return nil
+// parseMirrorURL validates a --mirror base URL and returns it without a
+// trailing slash, ready to prefix a portal download path.
+func parseMirrorURL(raw string) (string, error) {
+	mirror, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("parsing mirror URL: %w", err)
+	}
+	if (mirror.Scheme != "http" && mirror.Scheme != "https") || mirror.Host == "" {
+		return "", fmt.Errorf("mirror URL %q must be an absolute http or https URL", raw)
+	}
+	return strings.TrimSuffix(mirror.String(), "/"), nil
+}
+
+// DownloadEstimate summarizes the transfer size of a pending update run.
+type DownloadEstimate struct {
+	// Mods is the number of mods that will be downloaded.
+	Mods int
+	// Bytes is the sum of every size the portal reported.
+	Bytes int64
+	// Unknown counts mods whose size could not be determined.
+	Unknown int
+}
+
+// PendingDownloads returns the mods UpdateMods would download, in GetMods
+// order, so callers can preview or confirm the changes beforehand.
+func (u *Updater) PendingDownloads() []*ModData {
+	mods := u.GetMods()
+	pending := u.pendingDownloads(mods)
+
+	var out []*ModData
+	for _, m := range mods {
+		if pending[m.Name] {
+			out = append(out, m)
+		}
+	}
+	return out
 }
 
-// downloadLatest checks whether the given mod needs a download (new install,
-// version mismatch, or hash mismatch) and fetches it from the Mod Portal.
-// It returns a boolean marking true if updated.
-func (u *Updater) downloadLatest(mod string, multi *pterm.MultiPrinter) (bool, error) {
+// EstimateDownloads issues a HEAD request for each of the given pending mods
+// to total their Content-Length. Failed or size-less responses are counted
+// as unknown rather than aborting.
+func (u *Updater) EstimateDownloads(ctx context.Context, pending []*ModData) DownloadEstimate {
+	var mu sync.Mutex
+	sizes := make([]int64, 0, len(pending))
+
+	// eg bounds concurrent HEAD requests like the download phase does.
+	eg := new(errgroup.Group)
+	eg.SetLimit(downloadWorkers)
+	for _, data := range pending {
+		eg.Go(func() error {
+			size := u.headContentLength(ctx, data.Latest)
+			mu.Lock()
+			sizes = append(sizes, size)
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	return sumDownloadSizes(sizes)
+}
+
+// headContentLength returns the Content-Length reported for a release
+// download, or -1 when it cannot be determined.
+func (u *Updater) headContentLength(ctx context.Context, rel *ModRelease) int64 {
+	if u.offline {
+		return -1
+	}
+	dlURL, err := u.downloadURL(rel)
+	if err != nil {
+		return -1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dlURL, nil)
+	if err != nil {
+		return -1
+	}
+	u.modifyRequest(req)
+	u.debugf("HEAD %s", redactURL(dlURL))
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return -1
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return -1
+	}
+	return resp.ContentLength
+}
+
+// sumDownloadSizes totals a set of per-mod sizes, where a negative size
+// marks a mod whose size is unknown.
+func sumDownloadSizes(sizes []int64) DownloadEstimate {
+	est := DownloadEstimate{Mods: len(sizes)}
+	for _, size := range sizes {
+		if size < 0 {
+			est.Unknown++
+			continue
+		}
+		est.Bytes += size
+	}
+	return est
+}
+
+// downloadLatest fetches the latest release of the given mod from the Mod
+// Portal. Callers decide beforehand whether a download is needed.
+func (u *Updater) downloadLatest(ctx context.Context, mod string, multi *pterm.MultiPrinter, log logger) error {
 	data := u.mods[mod]
 	latest := data.Latest
 
 	if latest.FileName == "" {
-		return false, fmt.Errorf("latest release for %q has empty filename", mod)
+		return fmt.Errorf("latest release for %q has empty filename", mod)
 	}
 
 	// Sanitize against directory traversal payloads
 	safeFileName := filepath.Base(filepath.Clean(latest.FileName))
 	targetPath := filepath.Join(u.modPath, safeFileName)
 
-	needsDownload := false
-	if !data.Installed || data.Version != latest.Version {
-		needsDownload = true
-	} else if !validateSHA1(latest.Sha1, targetPath) {
-		needsDownload = true
+	dlURL, err := u.downloadURL(latest)
+	if err != nil {
+		return fmt.Errorf("parsing download URL for %q: %w", mod, err)
 	}
 
-	if !needsDownload {
-		return false, nil
+	// Check the cache before starting a progress bar, which only a download
+	// would ever stop. Consumers of the events still see an install.
+	algo, expected := latest.checksum()
+	if u.installFromCache(targetPath, algo, expected, log) {
+		u.emit(Event{Type: EventDownloadStart, Mod: mod, Version: latest.Version})
+		var size int64
+		if info, err := os.Stat(targetPath); err == nil {
+			size = info.Size()
+		}
+		u.indexZip(safeFileName)
+		u.rememberHash(safeFileName, algo, expected)
+		u.emit(Event{Type: EventDownloadDone, Mod: mod, Version: latest.Version, Bytes: size})
+		log.WriteLog("Installed %s (%s) from the download cache", data.Title, latest.Version)
+		return nil
 	}
 
-	// Build download URL using net/url for safe credential encoding
-	dlURL, err := url.Parse(fmt.Sprintf("%s%s", u.modServerURL, latest.DownloadURL))
-	if err != nil {
-		return false, fmt.Errorf("parsing download URL for %q: %w", mod, err)
-	}
-	q := dlURL.Query()
-	q.Set("username", u.username)
-	q.Set("token", u.token)
-	dlURL.RawQuery = q.Encode()
-
-	var p *pterm.ProgressbarPrinter
+	counter := &writeCounter{Title: fmt.Sprintf("Downloading %s (%s)", data.Title, latest.Version)}
 	if !pterm.RawOutput && multi != nil {
 		pWriter := multi.NewWriter()
-		p, _ = pterm.DefaultProgressbar.WithTotal(100).WithWriter(pWriter).WithTitle(fmt.Sprintf("Downloading %s (%s)", data.Title, latest.Version)).Start()
+		counter.Progress, _ = pterm.DefaultProgressbar.WithTotal(100).WithWriter(pWriter).WithTitle(counter.Title).Start()
+	}
+	if u.onEvent != nil {
+		counter.OnWrite = func(written, total uint64) {
+			u.emit(Event{Type: EventDownloadProgress, Mod: mod, Version: latest.Version, Bytes: int64(written), Total: int64(total)})
+		}
 	}
 
-	if err := downloadFile(u.httpClient, targetPath, dlURL.String(), p, latest.Sha1); err != nil {
-		return false, err
+	log.debugf("GET %s", redactURL(dlURL))
+	u.emit(Event{Type: EventDownloadStart, Mod: mod, Version: latest.Version})
+	err = u.downloadFile(ctx, targetPath, dlURL, counter, algo, expected)
+	u.emit(Event{Type: EventDownloadDone, Mod: mod, Version: latest.Version, Bytes: int64(counter.Current), Error: errorText(err)})
+	if err != nil {
+		return err
 	}
+	u.indexZip(safeFileName)
+	u.rememberHash(safeFileName, algo, expected)
+	u.storeInCache(targetPath, expected, log)
 
-	u.WriteLog("Downloaded %s (%s)", data.Title, latest.Version)
-	return true, nil
+	log.WriteLog("Downloaded %s (%s)", data.Title, latest.Version)
+	return nil
 }
 
-// validateSHA1 computes the SHA-1 digest of the file at the given path and
-// compares it against the expected hex-encoded hash string.
-// #nosec G401 - SHA-1 is mandated by the Factorio Mod Portal API for download validation.
-func validateSHA1(expectedHash, targetPath string) bool {
-	f, err := os.Open(targetPath)
-	if err != nil {
-		return false
+// ErrReleaseNotFound reports that the mod portal no longer serves a release,
+// typically because its author deleted it after metadata was fetched.
+var ErrReleaseNotFound = errors.New("release no longer exists on the mod portal")
+
+// downloadStatusError categorizes an unsuccessful download response so the
+// CLI can tell an invalid token apart from a vanished release. It returns nil
+// for a usable response.
+func downloadStatusError(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("download returned status %d: %w", resp.StatusCode, ErrInvalidCredentials)
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("download returned status %d: %w", resp.StatusCode, ErrReleaseNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	case resp.Request != nil && strings.HasPrefix(resp.Request.URL.Path, "/login"):
+		// A rejected token is redirected to the portal's HTML login page.
+		return fmt.Errorf("download redirected to the login page: %w", ErrInvalidCredentials)
 	}
-	defer func() { _ = f.Close() }()
+	return nil
+}
 
-	h := sha1.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return false
-	}
+// DownloadTooLargeError reports a download exceeding the size ceiling.
+type DownloadTooLargeError struct {
+	Limit int64
+}
 
-	return hex.EncodeToString(h.Sum(nil)) == expectedHash
+func (e *DownloadTooLargeError) Error() string {
+	return fmt.Sprintf("download exceeds the maximum size of %d bytes", e.Limit)
 }
 
 // downloadFile fetches a file from dlURL, writes it to targetPath, tracks
-// progress via the optional ProgressbarPrinter, and validates the SHA-1 hash.
-func downloadFile(client *http.Client, targetPath string, dlURL string, p *pterm.ProgressbarPrinter, expectedHash string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+// progress through the optional counter, and validates the file against
+// the expected hex digest using hashAlgo.
+func (u *Updater) downloadFile(ctx context.Context, targetPath string, dlURL string, counter *writeCounter, hashAlgo HashAlgo, expected string) error {
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dlURL, nil)
 	if err != nil {
 		return fmt.Errorf("creating download request: %w", err)
 	}
+	u.modifyRequest(req)
 
-	resp, err := client.Do(req)
+	resp, err := u.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("executing download: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	if err := downloadStatusError(resp); err != nil {
+		return err
+	}
+
+	limit := u.maxDownloadBytes
+	if limit <= 0 {
+		limit = DefaultMaxDownloadBytes
+	}
+	if resp.ContentLength > limit {
+		return fmt.Errorf("download is %d bytes: %w", resp.ContentLength, &DownloadTooLargeError{Limit: limit})
 	}
 
 	tmpPath := targetPath + ".tmp"
@@ -856,12 +2776,19 @@ func downloadFile(client *http.Client, targetPath string, dlURL string, p *pterm
 	}
 	defer func() { _ = out.Close() }()
 
-	counter := &writeCounter{
-		Total:    uint64(max(resp.ContentLength, 0)),
-		Progress: p,
+	if counter == nil {
+		counter = &writeCounter{}
 	}
+	counter.Total = uint64(max(resp.ContentLength, 0))
+	p := counter.Progress
 
-	if _, err = io.Copy(out, io.TeeReader(resp.Body, counter)); err != nil {
+	// Read one byte past the limit so an oversized body is detected even
+	// when the server omits or understates Content-Length.
+	written, err := io.Copy(out, io.TeeReader(io.LimitReader(resp.Body, limit+1), counter))
+	if err == nil && written > limit {
+		err = &DownloadTooLargeError{Limit: limit}
+	}
+	if err != nil {
 		if p != nil {
 			_, _ = p.Stop()
 		}
@@ -873,34 +2800,128 @@ func downloadFile(client *http.Client, targetPath string, dlURL string, p *pterm
 		_, _ = p.Stop()
 	}
 
+	if err := u.syncFile(out); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("syncing %s: %w", tmpPath, err)
+	}
+
 	// Ensure file is flushed and closed before reading it for validation
 	if err := out.Close(); err != nil {
 		_ = os.Remove(tmpPath)
 		return fmt.Errorf("flushing to disk %s: %w", tmpPath, err)
 	}
 
-	// #nosec G401 - SHA-1 is mandated by the Factorio Mod Portal API.
-	if !validateSHA1(expectedHash, tmpPath) {
+	if !validateHash(hashAlgo, expected, tmpPath) {
 		// Clean up corrupted download
 		_ = os.Remove(tmpPath)
-		return fmt.Errorf("SHA-1 validation failed for %s", tmpPath)
+		return fmt.Errorf("%s validation failed for %s", hashAlgo, tmpPath)
 	}
 
 	if err := os.Rename(tmpPath, targetPath); err != nil {
 		return fmt.Errorf("atomically renaming download: %w", err)
 	}
 
+	if err := u.syncDir(filepath.Dir(targetPath)); err != nil {
+		return fmt.Errorf("syncing mods directory: %w", err)
+	}
+
 	return nil
 }
 
+// fsyncFile and fsyncDir perform the actual sync calls. They are variables so
+// tests can observe that the durable write path is taken.
+var (
+	fsyncFile = func(f *os.File) error { return f.Sync() }
+	fsyncDir  = func(dir string) error {
+		// Windows cannot fsync a directory handle, and NTFS journals the
+		// rename itself.
+		if runtime.GOOS == "windows" {
+			return nil
+		}
+		d, err := os.Open(dir)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = d.Close() }()
+		return d.Sync()
+	}
+)
+
+// syncFile flushes f to stable storage unless fsync is disabled.
+func (u *Updater) syncFile(f *os.File) error {
+	if u.noFsync {
+		return nil
+	}
+	return fsyncFile(f)
+}
+
+// syncDir flushes the directory entry table of dir, making a preceding
+// rename durable, unless fsync is disabled.
+func (u *Updater) syncDir(dir string) error {
+	if u.noFsync {
+		return nil
+	}
+	return fsyncDir(dir)
+}
+
+// writeFileAtomic writes data to path through a synced temporary file and a
+// rename, so a crash leaves either the old or the new content.
+func (u *Updater) writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := u.checkWritable(path); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+	if err := u.syncFile(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("syncing temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("closing temporary file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("atomically renaming: %w", err)
+	}
+	return u.syncDir(filepath.Dir(path))
+}
+
 // writeCounter wraps an io.Writer to track download progress and update
 // a pterm ProgressbarPrinter with the current completion percentage.
 type writeCounter struct {
 	Total    uint64
 	Current  uint64
 	Progress *pterm.ProgressbarPrinter
+	// Title is the progress bar title the transfer rate and ETA are
+	// appended to.
+	Title string
+	// OnWrite, if set, is called with the running byte count at most every
+	// progressEventBytes and once the full Total has arrived.
+	OnWrite  func(written, total uint64)
+	reported uint64
+	// now returns the current time; nil means time.Now.
+	now func() time.Time
+
+	start       time.Time // first write
+	sampleAt    time.Time // start of the current rate sample
+	sampleBytes uint64    // Current at sampleAt
+	rate        float64   // bytes per second over the last full sample
 }
 
+// rateSampleInterval is how long writeCounter measures before refreshing
+// the instantaneous rate and the progress bar title.
+const rateSampleInterval = 500 * time.Millisecond
+
 // Write implements io.Writer, accumulating byte counts and updating the
 // progress bar when both Total and Progress are non-zero/non-nil.
 func (wc *writeCounter) Write(p []byte) (int, error) {
@@ -910,16 +2931,159 @@ func (wc *writeCounter) Write(p []byte) (int, error) {
 		pct := min(int(float64(wc.Current)/float64(wc.Total)*100), 100)
 		wc.Progress.Add(pct - wc.Progress.Current)
 	}
+	if wc.sample() && wc.Progress != nil && wc.Title != "" {
+		wc.Progress.UpdateTitle(wc.Title + " " + wc.status())
+	}
+	if wc.OnWrite != nil && (wc.Current-wc.reported >= progressEventBytes || wc.Current == wc.Total) {
+		wc.reported = wc.Current
+		wc.OnWrite(wc.Current, wc.Total)
+	}
 	return n, nil
 }
 
-// isBuiltInMod determines if a given module name belongs to the official
-// Factorio core distribution, which should not be queried on the mod portal.
-func isBuiltInMod(name string) bool {
-	switch name {
-	case "base", "core", "space-age", "quality", "elevated-rails":
-		return true
-	default:
+// sample advances the rate measurement to the current time and reports
+// whether a new instantaneous rate was taken.
+func (wc *writeCounter) sample() bool {
+	now := time.Now()
+	if wc.now != nil {
+		now = wc.now()
+	}
+	if wc.start.IsZero() {
+		wc.start, wc.sampleAt, wc.sampleBytes = now, now, wc.Current
+		return false
+	}
+	elapsed := now.Sub(wc.sampleAt)
+	if elapsed < rateSampleInterval {
 		return false
 	}
+	wc.rate = float64(wc.Current-wc.sampleBytes) / elapsed.Seconds()
+	wc.sampleAt, wc.sampleBytes = now, wc.Current
+	return true
+}
+
+// averageRate returns the bytes per second received from the first write
+// up to the latest sample.
+func (wc *writeCounter) averageRate() float64 {
+	elapsed := wc.sampleAt.Sub(wc.start)
+	if wc.start.IsZero() || elapsed <= 0 {
+		return 0
+	}
+	return float64(wc.sampleBytes) / elapsed.Seconds()
+}
+
+// eta estimates the time left from the average rate. It reports false while
+// the total size or the rate is unknown.
+func (wc *writeCounter) eta() (time.Duration, bool) {
+	rate := wc.averageRate()
+	if wc.Total == 0 || rate <= 0 {
+		return 0, false
+	}
+	if wc.Current >= wc.Total {
+		return 0, true
+	}
+	return time.Duration(float64(wc.Total-wc.Current) / rate * float64(time.Second)), true
+}
+
+// status renders the instantaneous rate and, when the size is known, the
+// remaining time, e.g. "2.5 MiB/s, 12s left".
+func (wc *writeCounter) status() string {
+	text := FormatBytes(int64(wc.rate)) + "/s"
+	if eta, ok := wc.eta(); ok {
+		text += ", " + eta.Round(time.Second).String() + " left"
+	}
+	return text
+}
+
+// FormatBytes renders a byte count using binary units (KiB, MiB, GiB).
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// defaultBuiltInMods are the mods bundled with Factorio itself, used when the
+// installation's data directory cannot be inspected.
+var defaultBuiltInMods = []string{"base", "core", "space-age", "quality", "elevated-rails"}
+
+// isBuiltInMod determines if a given module name belongs to the official
+// Factorio core distribution, or was configured as built-in, and so should
+// not be queried on the mod portal.
+func (u *Updater) isBuiltInMod(name string) bool {
+	bundled := u.bundledMods
+	if bundled == nil {
+		bundled = defaultBuiltInMods
+	}
+	equal := func(other string) bool { return modNamesEqual(name, other) }
+	return slices.ContainsFunc(bundled, equal) || slices.ContainsFunc(u.extraBuiltInMods, equal)
+}
+
+// foldModNameCase is set on platforms whose default filesystems are case
+// insensitive, where Helmod_1.0.0.zip and helmod_1.0.0.zip are the same file.
+const foldModNameCase = runtime.GOOS == "windows"
+
+// modNamesEqual compares mod names the way the host filesystem does.
+func modNamesEqual(a, b string) bool {
+	return namesEqual(a, b, foldModNameCase)
+}
+
+// modKey returns the form of a mod name used to group its files, folding
+// case where modNamesEqual does.
+func modKey(name string) string {
+	if foldModNameCase {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// namesEqual compares two mod names, ignoring case when foldCase is set.
+func namesEqual(a, b string, foldCase bool) bool {
+	if foldCase {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// dataDirCandidates lists where the bundled data directory may sit relative
+// to the Factorio executable: <root>/bin/x64/factorio on Linux and Windows,
+// and Contents/MacOS/factorio inside the macOS app bundle.
+func dataDirCandidates(factPath string) []string {
+	binDir := filepath.Dir(filepath.Clean(factPath))
+	return []string{
+		filepath.Join(filepath.Dir(filepath.Dir(binDir)), "data"),
+		filepath.Join(filepath.Dir(binDir), "data"),
+	}
+}
+
+// detectBundledMods returns the sorted names of the mod folders containing an
+// info.json in the first data directory that holds the base mod, or nil if
+// none does.
+func detectBundledMods(dataDirs []string) []string {
+	for _, dir := range dataDirs {
+		if _, err := os.Stat(filepath.Join(dir, "base", "info.json")); err != nil {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		var names []string
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, e.Name(), "info.json")); err == nil {
+				names = append(names, e.Name())
+			}
+		}
+		slices.Sort(names)
+		return names
+	}
+	return nil
 }
@@ -1,16 +1,29 @@
 package factorio
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/pterm/pterm"
 )
 
 func TestVersionMatch(t *testing.T) {
@@ -56,6 +69,12 @@ func TestVersionMatch(t *testing.T) {
 			mod:       "0.18.33",
 			expected:  true,
 		},
+		{
+			name:      "zero patch equals major minor",
+			installed: "2.0",
+			mod:       "2.0.0",
+			expected:  true,
+		},
 		{
 			name:      "invalid mod format",
 			installed: "2.0",
@@ -93,20 +112,301 @@ func TestIsBuiltInMod(t *testing.T) {
 		{"elevated-rails is built-in", "elevated-rails", true},
 		{"random mod is not built-in", "helmod", false},
 		{"empty string is not built-in", "", false},
-		{"case sensitive check", "Base", false},
+		{"case follows the filesystem", "Base", runtime.GOOS == "windows"},
 		{"partial match is not built-in", "space", false},
 	}
 
+	// Defaults must hold whether or not extra built-ins are configured.
+	plain := &Updater{}
+	extended := &Updater{extraBuiltInMods: []string{"my-base-overhaul"}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isBuiltInMod(tt.modName)
-			if result != tt.expected {
-				t.Errorf("isBuiltInMod(%q) = %v; want %v", tt.modName, result, tt.expected)
+			for _, u := range []*Updater{plain, extended} {
+				if result := u.isBuiltInMod(tt.modName); result != tt.expected {
+					t.Errorf("isBuiltInMod(%q) with extras %v = %v; want %v", tt.modName, u.extraBuiltInMods, result, tt.expected)
+				}
+			}
+		})
+	}
+
+	t.Run("extra built-ins are merged with the defaults", func(t *testing.T) {
+		if !extended.isBuiltInMod("my-base-overhaul") {
+			t.Error("configured extra built-in should be recognized")
+		}
+		if plain.isBuiltInMod("my-base-overhaul") {
+			t.Error("extra built-in should not leak into an unconfigured updater")
+		}
+	})
+}
+
+func TestParseDependency(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    dependency
+		wantErr bool
+	}{
+		{"plain name", "boblibrary", dependency{name: "boblibrary"}, false},
+		{"hyphenated name", "mod-with-hyphens", dependency{name: "mod-with-hyphens"}, false},
+		{"underscored name with constraint", "Name_With_Underscores = 2.0.1", dependency{name: "Name_With_Underscores", op: "=", version: "2.0.1"}, false},
+		{"name with spaces", "some mod name", dependency{name: "some mod name"}, false},
+		{"name with spaces and constraint", "some mod name >= 1.0.0", dependency{name: "some mod name", op: ">=", version: "1.0.0"}, false},
+		{"optional name with spaces", "? some mod name >= 1.0.0", dependency{kind: depOptional, name: "some mod name", op: ">=", version: "1.0.0"}, false},
+		{"optional without separating space", "?some mod name >= 1.0.0", dependency{kind: depOptional, name: "some mod name", op: ">=", version: "1.0.0"}, false},
+		{"trailing spaces", "boblibrary   ", dependency{name: "boblibrary"}, false},
+		{"trailing space before operator", "boblibrary  >= 1.0.0", dependency{name: "boblibrary", op: ">=", version: "1.0.0"}, false},
+		{"leading and trailing spaces", "  boblibrary  ", dependency{name: "boblibrary"}, false},
+		{"tab separators", "\tboblibrary\t>=\t1.0.0\t", dependency{name: "boblibrary", op: ">=", version: "1.0.0"}, false},
+		{"hyphenated name with constraint", "mod-b < 2.0.0", dependency{name: "mod-b", op: "<", version: "2.0.0"}, false},
+		{"two segment version", "base >= 2.0", dependency{name: "base", op: ">=", version: "2.0"}, false},
+		{"invalid characters", "bad$name", dependency{}, true},
+		{"empty string", "   ", dependency{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDependency(tt.input)
+			if ok == tt.wantErr {
+				t.Fatalf("parseDependency(%q) ok = %v; want %v", tt.input, ok, !tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDependency(%q) = %+v; want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependencyPrefixes(t *testing.T) {
+	tests := []struct {
+		input    string
+		kind     depKind
+		required bool
+	}{
+		{"helmod", depRequired, true},
+		{"helmod >= 1.0.0", depRequired, true},
+		{"! helmod", depIncompatible, false},
+		{"!helmod", depIncompatible, false},
+		{"? helmod", depOptional, false},
+		{"?helmod >= 1.0.0", depOptional, false},
+		{"(?) helmod", depHiddenOptional, false},
+		{"(?)helmod", depHiddenOptional, false},
+		{"~ helmod", depNoLoadOrder, true},
+		{"~helmod", depNoLoadOrder, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			dep, ok := parseDependency(tt.input)
+			if !ok {
+				t.Fatalf("parseDependency(%q) failed to parse", tt.input)
+			}
+			if dep.name != "helmod" {
+				t.Errorf("name = %q; want helmod", dep.name)
+			}
+			if dep.kind != tt.kind {
+				t.Errorf("kind = %v; want %v", dep.kind, tt.kind)
+			}
+			if dep.required() != tt.required {
+				t.Errorf("required() = %v; want %v", dep.required(), tt.required)
+			}
+		})
+	}
+
+	t.Run("only required dependencies are resolved", func(t *testing.T) {
+		rel := &ModRelease{}
+		rel.InfoJSON.Dependencies = []string{
+			"base >= 2.0.0", "hard", "! incompatible", "? optional", "(?) hidden", "~ no-load-order",
+		}
+		want := []dependency{{kind: depRequired, name: "hard"}, {kind: depNoLoadOrder, name: "no-load-order"}}
+		if got := (&Updater{}).requiredDependencies(rel); !slices.Equal(got, want) {
+			t.Errorf("requiredDependencies() = %+v; want %+v", got, want)
+		}
+	})
+}
+
+func TestNoLoadOrderDependencies(t *testing.T) {
+	t.Run("constraint is retained", func(t *testing.T) {
+		dep, ok := parseDependency("~foo >= 1.0.0")
+		if !ok {
+			t.Fatal("parseDependency failed to parse ~foo >= 1.0.0")
+		}
+		want := dependency{kind: depNoLoadOrder, name: "foo", op: ">=", version: "1.0.0"}
+		if dep != want {
+			t.Errorf("parseDependency() = %+v; want %+v", dep, want)
+		}
+		if !dep.satisfiedBy("1.2.0") {
+			t.Error("1.2.0 should satisfy >= 1.0.0")
+		}
+		if dep.satisfiedBy("0.9.0") {
+			t.Error("0.9.0 should not satisfy >= 1.0.0")
+		}
+	})
+
+	t.Run("resolved as required", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rel := ModRelease{Version: "1.0.0", FileName: "x_1.0.0.zip"}
+			rel.InfoJSON.FactorioVersion = "2.0"
+			switch r.URL.Path {
+			case "/api/mods/root/full":
+				rel.InfoJSON.Dependencies = []string{"~foo", "~bar >= 1.0.0", "~ baz > 9.0.0"}
+			case "/api/mods/baz/full":
+				rel.Version = "2.0.0"
+			}
+			_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: r.URL.Path, Releases: []ModRelease{rel}})
+		})
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		u := &Updater{
+			modServerURL: server.URL,
+			factVersion:  "2.0",
+			httpClient:   server.Client(),
+			mods: map[string]*ModData{
+				"root": {Name: "root", Title: "root", Enabled: true},
+			},
+		}
+
+		if err := u.ResolveMetadata(context.Background(), nil); err != nil {
+			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+		}
+		for _, name := range []string{"foo", "bar", "baz"} {
+			if m, ok := u.mods[name]; !ok || m.Latest == nil {
+				t.Errorf("expected ~ dependency %q to be resolved", name)
+			}
+		}
+
+		// baz 2.0.0 violates "> 9.0.0" and should be logged as a warning
+		if !strings.Contains(u.logBuf.String(), "root requires baz > 9.0.0") {
+			t.Errorf("expected constraint warning in log, got:\n%s", u.logBuf.String())
+		}
+	})
+}
+
+func TestRetrieveModMetadataGzip(t *testing.T) {
+	rel := ModRelease{Version: "2.2.12", FileName: "helmod_2.2.12.zip"}
+	rel.InfoJSON.FactorioVersion = "2.0"
+	payload, _ := json.Marshal(ModPortalMetadata{Title: "Helmod", Releases: []ModRelease{rel}})
+
+	for _, gzipped := range []bool{true, false} {
+		t.Run(fmt.Sprintf("gzip=%v", gzipped), func(t *testing.T) {
+			var gotEncoding string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotEncoding = r.Header.Get("Accept-Encoding")
+				if !gzipped {
+					_, _ = w.Write(payload)
+					return
+				}
+				w.Header().Set("Content-Encoding", "gzip")
+				zw := gzip.NewWriter(w)
+				_, _ = zw.Write(payload)
+				_ = zw.Close()
+			}))
+			defer server.Close()
+
+			u := &Updater{modServerURL: server.URL, factVersion: "2.0", httpClient: server.Client(),
+				mods: map[string]*ModData{"helmod": {Name: "helmod"}}}
+			if err := u.RetrieveModMetadata(context.Background(), "helmod"); err != nil {
+				t.Fatalf("RetrieveModMetadata() returned unexpected error: %v", err)
+			}
+			if gotEncoding != "gzip" {
+				t.Errorf("Accept-Encoding = %q; want gzip", gotEncoding)
+			}
+			if m := u.mods["helmod"]; m.Title != "Helmod" || m.Latest == nil || m.Latest.Version != "2.2.12" {
+				t.Errorf("helmod = title %q, latest %+v; want the decoded metadata", m.Title, m.Latest)
+			}
+		})
+	}
+}
+
+func TestRetrieveModMetadataGzipBomb(t *testing.T) {
+	// A title of maxAPIResponseBytes spaces compresses to a few kilobytes.
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write([]byte(`{"title": "`))
+	_, _ = zw.Write(bytes.Repeat([]byte(" "), maxAPIResponseBytes))
+	_, _ = zw.Write([]byte(`", "releases": []}`))
+	_ = zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	u := &Updater{modServerURL: server.URL, factVersion: "2.0", httpClient: server.Client(),
+		mods: map[string]*ModData{"helmod": {Name: "helmod"}}}
+	err := u.RetrieveModMetadata(context.Background(), "helmod")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("RetrieveModMetadata() error = %v; want ErrResponseTooLarge for a %d-byte gzip body", err, compressed.Len())
+	}
+}
+
+func TestModReleaseReleasedAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  time.Time
+	}{
+		{"portal format", `, "released_at": "2024-10-21T12:06:56.661000Z"`, time.Date(2024, 10, 21, 12, 6, 56, 661000000, time.UTC)},
+		{"offset converted to UTC", `, "released_at": "2024-10-21T14:06:56+02:00"`, time.Date(2024, 10, 21, 12, 6, 56, 0, time.UTC)},
+		{"no zone taken as UTC", `, "released_at": "2024-10-21T12:06:56.661"`, time.Date(2024, 10, 21, 12, 6, 56, 661000000, time.UTC)},
+		{"missing", ``, time.Time{}},
+		{"null", `, "released_at": null`, time.Time{}},
+		{"empty", `, "released_at": ""`, time.Time{}},
+		{"unparseable", `, "released_at": "yesterday"`, time.Time{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := `{"version": "2.2.12", "file_name": "helmod_2.2.12.zip", "info_json": {"factorio_version": "2.0"}` + tc.field + `}`
+			var rel ModRelease
+			if err := json.Unmarshal([]byte(payload), &rel); err != nil {
+				t.Fatalf("Unmarshal(%s) returned unexpected error: %v", payload, err)
+			}
+			if !rel.ReleasedAt.Equal(tc.want) || rel.ReleasedAt.Location() != time.UTC {
+				t.Errorf("ReleasedAt = %v; want %v in UTC", rel.ReleasedAt, tc.want)
+			}
+			if rel.Version != "2.2.12" || rel.FileName != "helmod_2.2.12.zip" || rel.InfoJSON.FactorioVersion != "2.0" {
+				t.Errorf("release = %+v; want the other fields decoded too", rel)
+			}
+
+			// The metadata cache stores releases re-encoded; the date must survive.
+			data, _ := json.Marshal(rel)
+			var back ModRelease
+			if err := json.Unmarshal(data, &back); err != nil || !back.ReleasedAt.Equal(tc.want) {
+				t.Errorf("round trip of %s = %v, %v; want %v", data, back.ReleasedAt, err, tc.want)
+			}
+			if tc.want.IsZero() && strings.Contains(string(data), "released_at") {
+				t.Errorf("Marshal() = %s; want a zero ReleasedAt omitted", data)
 			}
 		})
 	}
 }
 
+func TestRetrieveModMetadataEscapesName(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: "Some Mod Name"})
+	}))
+	defer server.Close()
+
+	u := &Updater{
+		modServerURL: server.URL,
+		factVersion:  "2.0",
+		httpClient:   server.Client(),
+		mods: map[string]*ModData{
+			"some mod name": {Name: "some mod name"},
+		},
+	}
+
+	if err := u.RetrieveModMetadata(context.Background(), "some mod name"); err != nil {
+		t.Fatalf("RetrieveModMetadata() returned unexpected error: %v", err)
+	}
+	if want := "/api/mods/some%20mod%20name/full"; gotPath != want {
+		t.Errorf("request path = %q; want %q", gotPath, want)
+	}
+}
+
 func TestParseModList(t *testing.T) {
 	// Create a temp directory to simulate a mods folder
 	tmpDir := t.TempDir()
@@ -178,6 +478,13 @@ func TestParseModList(t *testing.T) {
 	if u.mods["not-installed-mod"].Installed {
 		t.Error("not-installed-mod should not be marked as installed")
 	}
+
+	// Everything found in mod-list.json or on disk is a direct user choice
+	for name, m := range u.mods {
+		if m.Source != FromModList {
+			t.Errorf("%s Source = %v; want %v", name, m.Source, FromModList)
+		}
+	}
 }
 
 func TestParseTokens(t *testing.T) {
@@ -208,6 +515,24 @@ func TestParseTokens(t *testing.T) {
 		}
 	})
 
+	t.Run("fallback credentials fill only what the files lack", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(tmpDir, "server-settings.json"), []byte(`{"username": "server_user"}`), 0644)
+
+		u := &Updater{
+			settingsPath:     filepath.Join(tmpDir, "server-settings.json"),
+			modPath:          filepath.Join(tmpDir, "mods"),
+			fallbackUsername: "file_user",
+			fallbackToken:    "file_token",
+		}
+		if err := u.parseTokens(); err != nil {
+			t.Fatalf("parseTokens() returned unexpected error: %v", err)
+		}
+		if u.username != "server_user" || u.token != "file_token" {
+			t.Errorf("credentials = %q, %q; want server_user and the fallback token", u.username, u.token)
+		}
+	})
+
 	t.Run("falls back to player-data when server-settings missing", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -308,6 +633,69 @@ func TestParseTokens(t *testing.T) {
 		}
 	})
 
+	t.Run("server-settings with a UTF-8 BOM is read", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		serverSettings := "\xEF\xBB\xBF" + `{"username": "bom_user", "token": "bom_token"}`
+		_ = os.WriteFile(filepath.Join(tmpDir, "server-settings.json"), []byte(serverSettings), 0644)
+
+		u := &Updater{
+			settingsPath: filepath.Join(tmpDir, "server-settings.json"),
+			modPath:      filepath.Join(tmpDir, "mods"),
+		}
+
+		if err := u.parseTokens(); err != nil {
+			t.Fatalf("parseTokens() returned unexpected error: %v", err)
+		}
+		if u.username != "bom_user" || u.token != "bom_token" {
+			t.Errorf("credentials = %q, %q; want bom_user, bom_token", u.username, u.token)
+		}
+		if len(u.credentialErrs) != 0 {
+			t.Errorf("credentialErrs = %v; want none", u.credentialErrs)
+		}
+	})
+
+	t.Run("player-data passed as server-settings still resolves", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		playerData := `{"service-username": "player_user", "service-token": "player_token"}`
+		_ = os.WriteFile(filepath.Join(tmpDir, "swapped.json"), []byte(playerData), 0644)
+
+		u := &Updater{
+			settingsPath: filepath.Join(tmpDir, "swapped.json"),
+			modPath:      filepath.Join(tmpDir, "mods"),
+		}
+
+		if err := u.parseTokens(); err != nil {
+			t.Fatalf("parseTokens() returned unexpected error: %v", err)
+		}
+		if u.username != "player_user" || u.token != "player_token" {
+			t.Errorf("credentials = %q, %q; want player_user, player_token", u.username, u.token)
+		}
+		if u.tokenSource != filepath.Join(tmpDir, "swapped.json") {
+			t.Errorf("tokenSource = %q; want the settings path", u.tokenSource)
+		}
+	})
+
+	t.Run("server-settings passed as player-data still resolves", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		serverSettings := `{"username": "server_user", "token": "server_token"}`
+		_ = os.WriteFile(filepath.Join(tmpDir, "swapped.json"), []byte(serverSettings), 0644)
+
+		u := &Updater{
+			dataPath: filepath.Join(tmpDir, "swapped.json"),
+			modPath:  filepath.Join(tmpDir, "mods"),
+		}
+
+		if err := u.parseTokens(); err != nil {
+			t.Fatalf("parseTokens() returned unexpected error: %v", err)
+		}
+		if u.username != "server_user" || u.token != "server_token" {
+			t.Errorf("credentials = %q, %q; want server_user, server_token", u.username, u.token)
+		}
+	})
+
 	t.Run("both configs malformed returns no error but leaves credentials empty", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -334,6 +722,127 @@ func TestParseTokens(t *testing.T) {
 	})
 }
 
+func TestParseTokensRecordsSources(t *testing.T) {
+	tests := []struct {
+		name                      string
+		settings, playerData      string
+		username, usernameSource  string
+		fallbackUser, fallbackTok string
+		wantUserFrom, wantTokFrom string // "settings", "data", or a literal source
+	}{
+		{
+			name:         "server-settings supplies both",
+			settings:     `{"username": "server_user", "token": "server_token"}`,
+			playerData:   `{"service-username": "player_user", "service-token": "player_token"}`,
+			wantUserFrom: "settings", wantTokFrom: "settings",
+		},
+		{
+			name:         "player-data supplies both",
+			playerData:   `{"service-username": "player_user", "service-token": "player_token"}`,
+			wantUserFrom: "data", wantTokFrom: "data",
+		},
+		{
+			name:         "each credential from the first file that has it",
+			settings:     `{"username": "server_user"}`,
+			playerData:   `{"service-username": "player_user", "service-token": "player_token"}`,
+			wantUserFrom: "settings", wantTokFrom: "data",
+		},
+		{
+			name:       "option credential keeps its source",
+			playerData: `{"service-username": "player_user", "service-token": "player_token"}`,
+			username:   "cli_user", usernameSource: "the --username flag",
+			wantUserFrom: "the --username flag", wantTokFrom: "data",
+		},
+		{
+			name:         "game files beat the fallback",
+			settings:     `{"username": "server_user", "token": "server_token"}`,
+			playerData:   `{}`,
+			fallbackUser: "file_user", fallbackTok: "file_token",
+			wantUserFrom: "settings", wantTokFrom: "settings",
+		},
+		{
+			name:         "fallback fills what the game files lack",
+			settings:     `{"username": "server_user"}`,
+			playerData:   `{}`,
+			fallbackUser: "file_user", fallbackTok: "file_token",
+			wantUserFrom: "settings", wantTokFrom: "/home/u/config.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			settingsPath := filepath.Join(tmpDir, "server-settings.json")
+			dataPath := filepath.Join(tmpDir, "player-data.json")
+			if tt.settings != "" {
+				_ = os.WriteFile(settingsPath, []byte(tt.settings), 0644)
+			}
+			_ = os.WriteFile(dataPath, []byte(tt.playerData), 0644)
+			resolve := func(from string) string {
+				switch from {
+				case "settings":
+					return settingsPath
+				case "data":
+					return dataPath
+				}
+				return from
+			}
+
+			u := &Updater{modPath: filepath.Join(tmpDir, "mods"), username: tt.username,
+				usernameSource:   credentialSource(tt.username, tt.usernameSource),
+				fallbackUsername: tt.fallbackUser, fallbackToken: tt.fallbackTok, fallbackSource: "/home/u/config.json"}
+			if err := u.parseTokens(); err != nil {
+				t.Fatalf("parseTokens() returned unexpected error: %v", err)
+			}
+			gotUser, gotTok := u.CredentialSources()
+			if want := resolve(tt.wantUserFrom); gotUser != want {
+				t.Errorf("username source = %q; want %q", gotUser, want)
+			}
+			if want := resolve(tt.wantTokFrom); gotTok != want {
+				t.Errorf("token source = %q; want %q", gotTok, want)
+			}
+		})
+	}
+}
+
+func TestConfigDataCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		c          *configData
+		playerData bool
+		wantUser   string
+		wantToken  string
+	}{
+		{"nil config", nil, false, "", ""},
+		{"settings keys in settings", &configData{Username: "u", Token: "t"}, false, "u", "t"},
+		{"player keys in player-data", &configData{ServiceUsername: "u", ServiceToken: "t"}, true, "u", "t"},
+		{"player keys in settings", &configData{ServiceUsername: "u", ServiceToken: "t"}, false, "u", "t"},
+		{"settings keys in player-data", &configData{Username: "u", Token: "t"}, true, "u", "t"},
+		{"own keys win over the other file's", &configData{Username: "own", ServiceUsername: "other", ServiceToken: "t"}, false, "own", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, token := tt.c.credentials("config.json", tt.playerData)
+			if user != tt.wantUser || token != tt.wantToken {
+				t.Errorf("credentials() = %q, %q; want %q, %q", user, token, tt.wantUser, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestCredentialSource(t *testing.T) {
+	if got := credentialSource("", "the --token flag"); got != "" {
+		t.Errorf("credentialSource(empty) = %q; want empty", got)
+	}
+	if got := credentialSource("tok", ""); got != "the updater options" {
+		t.Errorf("credentialSource(no source) = %q; want the updater options", got)
+	}
+	if got := credentialSource("tok", "$FACTORIO_UPDATER_TOKEN"); got != "$FACTORIO_UPDATER_TOKEN" {
+		t.Errorf("credentialSource() = %q; want the given source", got)
+	}
+}
+
 func TestSaveModList(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -407,34 +916,41 @@ func TestSaveModList(t *testing.T) {
 	}
 }
 
-func TestValidateHash(t *testing.T) {
-	tmpDir := t.TempDir()
-	testFile := filepath.Join(tmpDir, "test.zip")
-	content := []byte("hello world")
-	_ = os.WriteFile(testFile, content, 0644)
-
-	// Compute expected SHA-1
-	h := sha1.New()
-	h.Write(content)
-	expectedHash := hex.EncodeToString(h.Sum(nil))
+func TestModListPathOutsideModDir(t *testing.T) {
+	modDir := t.TempDir()
+	configDir := t.TempDir()
+	listPath := filepath.Join(configDir, "mod-list.json")
+	_ = os.WriteFile(listPath, []byte(`{"mods": [{"name": "helmod", "enabled": true}, {"name": "flib", "enabled": false}]}`), 0644)
+	_ = os.WriteFile(filepath.Join(modDir, "helmod_2.2.12.zip"), []byte("zip"), 0644)
 
-	t.Run("correct hash returns true", func(t *testing.T) {
-		if !validateSHA1(expectedHash, testFile) {
-			t.Errorf("validateSHA1(%q) should return true for matching content", expectedHash)
-		}
-	})
+	u := &Updater{modPath: modDir, modListFile: listPath, mods: make(map[string]*ModData), noFsync: true}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	if m := u.mods["helmod"]; m == nil || !m.Enabled || !m.Installed || m.Version != "2.2.12" {
+		t.Fatalf("helmod = %+v; want enabled from the separate list and installed from the mods directory", m)
+	}
+	if m := u.mods["flib"]; m == nil || m.Enabled {
+		t.Fatalf("flib = %+v; want tracked as disabled", m)
+	}
 
-	t.Run("incorrect hash returns false", func(t *testing.T) {
-		if validateSHA1("deadbeef1234567890abcdef1234567890abcdef", testFile) {
-			t.Error("validateSHA1 should return false for non-matching hash")
-		}
-	})
+	u.mods["flib"].Enabled = true
+	if err := u.saveModList(); err != nil {
+		t.Fatalf("saveModList() returned unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(listPath)
+	if err != nil || !strings.Contains(string(data), `"name": "flib",
+      "enabled": true`) {
+		t.Errorf("saved %s = %s, %v; want flib enabled", listPath, data, err)
+	}
 
-	t.Run("missing file returns false", func(t *testing.T) {
-		if validateSHA1(expectedHash, filepath.Join(tmpDir, "nonexistent.zip")) {
-			t.Error("validateSHA1 should return false for missing file")
-		}
-	})
+	if _, err := os.Stat(filepath.Join(modDir, "mod-list.json")); !os.IsNotExist(err) {
+		t.Errorf("mod-list.json was written to the mods directory (err %v)", err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(configDir, "mod-list.*.json"))
+	if len(backups) != 1 {
+		t.Errorf("backups next to the list = %v; want one", backups)
+	}
 }
 
 func TestGetMods(t *testing.T) {
@@ -514,19 +1030,104 @@ func TestWriteCounter(t *testing.T) {
 	})
 }
 
-func TestPruneOld(t *testing.T) {
-	t.Run("removes old versions and keeps latest", func(t *testing.T) {
-		tmpDir := t.TempDir()
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
 
-		// Create multiple versioned zips for "helmod"
-		_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.1.0.zip"), []byte("old1"), 0644)
-		_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.1.5.zip"), []byte("old2"), 0644)
-		_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.2.12.zip"), []byte("latest"), 0644)
+	for _, tt := range tests {
+		if got := FormatBytes(tt.in); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
 
-		// Create an unrelated mod zip (should not be touched)
-		_ = os.WriteFile(filepath.Join(tmpDir, "jetpack_0.4.15.zip"), []byte("other"), 0644)
+func TestWriteCounterRateAndETA(t *testing.T) {
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name       string
+		total      uint64
+		writes     []uint64        // bytes per write
+		offsets    []time.Duration // time of each write after the first
+		wantRate   float64
+		wantETA    time.Duration
+		wantETAOK  bool
+		wantStatus string
+	}{
+		{
+			name:    "steady download",
+			total:   10 << 20,
+			writes:  []uint64{1 << 20, 1 << 20, 1 << 20},
+			offsets: []time.Duration{0, time.Second, 2 * time.Second},
+			// 3 MiB over 2s on average, 1 MiB in the last second.
+			wantRate: 1 << 20, wantETA: 4*time.Second + 666666666, wantETAOK: true,
+			wantStatus: "1.0 MiB/s, 5s left",
+		},
+		{
+			name:     "unknown size shows only the rate",
+			writes:   []uint64{512 << 10, 512 << 10},
+			offsets:  []time.Duration{0, time.Second},
+			wantRate: 512 << 10, wantStatus: "512.0 KiB/s",
+		},
+		{
+			name:       "writes inside one sample keep the previous rate",
+			total:      4 << 10,
+			writes:     []uint64{1 << 10, 1 << 10},
+			offsets:    []time.Duration{0, 100 * time.Millisecond},
+			wantStatus: "0 B/s",
+		},
+		{
+			name:     "complete download has nothing left",
+			total:    2 << 10,
+			writes:   []uint64{1 << 10, 1 << 10},
+			offsets:  []time.Duration{0, time.Second},
+			wantRate: 1 << 10, wantETAOK: true, wantStatus: "1.0 KiB/s, 0s left",
+		},
+	}
 
-		// Create a non-zip file (should not be touched)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var now time.Time
+			wc := &writeCounter{Total: tt.total, now: func() time.Time { return now }}
+			for i, n := range tt.writes {
+				now = base.Add(tt.offsets[i])
+				_, _ = wc.Write(make([]byte, n))
+			}
+			if wc.rate != tt.wantRate {
+				t.Errorf("rate = %v; want %v", wc.rate, tt.wantRate)
+			}
+			eta, ok := wc.eta()
+			if eta != tt.wantETA || ok != tt.wantETAOK {
+				t.Errorf("eta() = %v, %v; want %v, %v", eta, ok, tt.wantETA, tt.wantETAOK)
+			}
+			if got := wc.status(); got != tt.wantStatus {
+				t.Errorf("status() = %q; want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPruneOld(t *testing.T) {
+	t.Run("removes old versions and keeps latest", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		// Create multiple versioned zips for "helmod"
+		_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.1.0.zip"), []byte("old1"), 0644)
+		_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.1.5.zip"), []byte("old2"), 0644)
+		_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.2.12.zip"), []byte("latest"), 0644)
+
+		// Create an unrelated mod zip (should not be touched)
+		_ = os.WriteFile(filepath.Join(tmpDir, "jetpack_0.4.15.zip"), []byte("other"), 0644)
+
+		// Create a non-zip file (should not be touched)
 		_ = os.WriteFile(filepath.Join(tmpDir, "README.txt"), []byte("readme"), 0644)
 
 		u := &Updater{
@@ -619,7 +1220,7 @@ func TestDownloadFile(t *testing.T) {
 		tmpDir := t.TempDir()
 		target := filepath.Join(tmpDir, "test_mod_1.0.0.zip")
 
-		err := downloadFile(server.Client(), target, server.URL, nil, correctHash)
+		err := (&Updater{httpClient: server.Client()}).downloadFile(context.Background(), target, server.URL, nil, HashSHA1, correctHash)
 		if err != nil {
 			t.Fatalf("downloadFile() returned unexpected error: %v", err)
 		}
@@ -644,7 +1245,7 @@ func TestDownloadFile(t *testing.T) {
 		tmpDir := t.TempDir()
 		target := filepath.Join(tmpDir, "bad_hash_1.0.0.zip")
 
-		err := downloadFile(server.Client(), target, server.URL, nil, "0000000000000000000000000000000000000000")
+		err := (&Updater{httpClient: server.Client()}).downloadFile(context.Background(), target, server.URL, nil, HashSHA1, "0000000000000000000000000000000000000000")
 		if err == nil {
 			t.Fatal("downloadFile() should return error on hash mismatch")
 		}
@@ -667,7 +1268,7 @@ func TestDownloadFile(t *testing.T) {
 		tmpDir := t.TempDir()
 		target := filepath.Join(tmpDir, "partial_1.0.0.zip")
 
-		err := downloadFile(server.Client(), target, server.URL, nil, correctHash)
+		err := (&Updater{httpClient: server.Client()}).downloadFile(context.Background(), target, server.URL, nil, HashSHA1, correctHash)
 		if err == nil {
 			t.Fatal("downloadFile() should return error on truncated download")
 		}
@@ -679,233 +1280,2333 @@ func TestDownloadFile(t *testing.T) {
 	})
 }
 
-// --- Unit test for ResolveMetadata using httptest ---
+func TestPendingDownloads(t *testing.T) {
+	tmpDir := t.TempDir()
 
-func TestResolveMetadata(t *testing.T) {
-	t.Run("resolves transitive dependencies", func(t *testing.T) {
-		// Mock API: "mod-a" depends on "mod-b", "mod-b" has no further deps.
-		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			switch r.URL.Path {
-			case "/api/mods/mod-a/full":
-				resp := ModPortalMetadata{
-					Title: "Mod A",
-					Releases: []ModRelease{
-						{
-							Version:     "1.0.0",
-							DownloadURL: "/download/mod-a",
-							FileName:    "mod-a_1.0.0.zip",
-							Sha1:        "aaa",
-							InfoJSON: struct {
-								FactorioVersion string   `json:"factorio_version"`
-								Dependencies    []string `json:"dependencies"`
-							}{
-								FactorioVersion: "2.0",
-								Dependencies:    []string{"mod-b >= 1.0.0"},
-							},
-						},
-					},
-				}
-				_ = json.NewEncoder(w).Encode(resp)
-			case "/api/mods/mod-b/full":
-				resp := ModPortalMetadata{
-					Title: "Mod B",
-					Releases: []ModRelease{
-						{
-							Version:     "1.2.0",
-							DownloadURL: "/download/mod-b",
-							FileName:    "mod-b_1.2.0.zip",
-							Sha1:        "bbb",
-							InfoJSON: struct {
-								FactorioVersion string   `json:"factorio_version"`
-								Dependencies    []string `json:"dependencies"`
-							}{
-								FactorioVersion: "2.0",
-								Dependencies:    []string{},
-							},
-						},
-					},
-				}
-				_ = json.NewEncoder(w).Encode(resp)
-			default:
-				http.NotFound(w, r)
-			}
-		})
+	sum := func(b []byte) string {
+		h := sha1.New()
+		h.Write(b)
+		return hex.EncodeToString(h.Sum(nil))
+	}
 
-		server := httptest.NewServer(handler)
-		defer server.Close()
+	_ = os.WriteFile(filepath.Join(tmpDir, "current_1.0.0.zip"), []byte("current"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "corrupt_1.0.0.zip"), []byte("corrupt"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "outdated_1.0.0.zip"), []byte("outdated"), 0644)
 
-		u := &Updater{
-			modServerURL: server.URL,
-			factVersion:  "2.0",
-			mods: map[string]*ModData{
-				"mod-a": {Name: "mod-a", Title: "mod-a", Enabled: true},
-			},
-			httpClient: server.Client(),
-		}
+	u := &Updater{
+		modPath: tmpDir,
+		mods: map[string]*ModData{
+			"current": {Name: "current", Installed: true, Version: "1.0.0",
+				Latest: &ModRelease{Version: "1.0.0", FileName: "current_1.0.0.zip", Sha1: sum([]byte("current"))}},
+			"corrupt": {Name: "corrupt", Installed: true, Version: "1.0.0",
+				Latest: &ModRelease{Version: "1.0.0", FileName: "corrupt_1.0.0.zip", Sha1: sum([]byte("expected"))}},
+			"outdated": {Name: "outdated", Installed: true, Version: "1.0.0",
+				Latest: &ModRelease{Version: "1.1.0", FileName: "outdated_1.1.0.zip"}},
+			"missing": {Name: "missing",
+				Latest: &ModRelease{Version: "1.0.0", FileName: "missing_1.0.0.zip"}},
+			"unresolved": {Name: "unresolved", Installed: true, Version: "1.0.0"},
+		},
+	}
 
-		err := u.ResolveMetadata()
-		if err != nil {
-			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
-		}
+	pending := u.pendingDownloads(u.GetMods())
 
-		// mod-a should have its title resolved
-		if u.mods["mod-a"].Title != "Mod A" {
-			t.Errorf("mod-a title = %q; want %q", u.mods["mod-a"].Title, "Mod A")
+	want := map[string]bool{"corrupt": true, "outdated": true, "missing": true}
+	if len(pending) != len(want) {
+		t.Fatalf("pendingDownloads() = %v; want %v", pending, want)
+	}
+	for name := range want {
+		if !pending[name] {
+			t.Errorf("expected %q to need a download", name)
 		}
+	}
+}
 
-		// mod-b should have been discovered as a transitive dependency
-		modB, ok := u.mods["mod-b"]
-		if !ok {
-			t.Fatal("expected transitive dependency 'mod-b' to be discovered")
-		}
-		if modB.Title != "Mod B" {
-			t.Errorf("mod-b title = %q; want %q", modB.Title, "Mod B")
-		}
-		if modB.Latest == nil {
-			t.Fatal("mod-b should have a Latest release")
+func BenchmarkPendingDownloads(b *testing.B) {
+	tmpDir := b.TempDir()
+	content := make([]byte, 1<<20) // 1 MiB per fake mod zip
+
+	h := sha1.New()
+	h.Write(content)
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	u := &Updater{modPath: tmpDir, mods: make(map[string]*ModData)}
+	for i := range 50 {
+		name := fmt.Sprintf("mod-%02d", i)
+		fileName := name + "_1.0.0.zip"
+		_ = os.WriteFile(filepath.Join(tmpDir, fileName), content, 0644)
+		u.mods[name] = &ModData{
+			Name:      name,
+			Installed: true,
+			Version:   "1.0.0",
+			Latest:    &ModRelease{Version: "1.0.0", FileName: fileName, Sha1: hash},
 		}
-		if modB.Latest.Version != "1.2.0" {
-			t.Errorf("mod-b latest version = %q; want %q", modB.Latest.Version, "1.2.0")
+	}
+	mods := u.GetMods()
+
+	for b.Loop() {
+		if pending := u.pendingDownloads(mods); len(pending) != 0 {
+			b.Fatalf("expected no pending downloads, got %d", len(pending))
 		}
-	})
+	}
+}
 
-	t.Run("handles circular dependencies without infinite loop", func(t *testing.T) {
-		// "circle-a" depends on "circle-b", "circle-b" depends on "circle-a"
-		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var resp ModPortalMetadata
-			switch r.URL.Path {
-			case "/api/mods/circle-a/full":
-				resp = ModPortalMetadata{
-					Title: "Circle A",
-					Releases: []ModRelease{
-						{
-							Version:  "1.0.0",
-							FileName: "circle-a_1.0.0.zip",
-							InfoJSON: struct {
-								FactorioVersion string   `json:"factorio_version"`
-								Dependencies    []string `json:"dependencies"`
-							}{
-								FactorioVersion: "2.0",
-								Dependencies:    []string{"circle-b"},
-							},
-						},
-					},
-				}
-			case "/api/mods/circle-b/full":
-				resp = ModPortalMetadata{
-					Title: "Circle B",
-					Releases: []ModRelease{
-						{
-							Version:  "1.0.0",
-							FileName: "circle-b_1.0.0.zip",
-							InfoJSON: struct {
-								FactorioVersion string   `json:"factorio_version"`
-								Dependencies    []string `json:"dependencies"`
-							}{
-								FactorioVersion: "2.0",
-								Dependencies:    []string{"circle-a"},
-							},
-						},
-					},
-				}
-			default:
-				http.NotFound(w, r)
+func TestSumDownloadSizes(t *testing.T) {
+	tests := []struct {
+		name  string
+		sizes []int64
+		want  DownloadEstimate
+	}{
+		{"no downloads", nil, DownloadEstimate{}},
+		{"known sizes", []int64{1024, 2048, 0}, DownloadEstimate{Mods: 3, Bytes: 3072}},
+		{"unknown sizes are counted separately", []int64{1024, -1, 512, -1}, DownloadEstimate{Mods: 4, Bytes: 1536, Unknown: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sumDownloadSizes(tt.sizes); got != tt.want {
+				t.Errorf("sumDownloadSizes(%v) = %+v; want %+v", tt.sizes, got, tt.want)
 			}
-			_ = json.NewEncoder(w).Encode(resp)
 		})
+	}
+}
 
-		server := httptest.NewServer(handler)
-		defer server.Close()
-
-		u := &Updater{
-			modServerURL: server.URL,
-			factVersion:  "2.0",
-			mods: map[string]*ModData{
-				"circle-a": {Name: "circle-a", Title: "circle-a", Enabled: true},
-			},
-			httpClient: server.Client(),
+func TestEstimateDownloads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("estimate issued %s request; want HEAD", r.Method)
 		}
-
-		// Should complete without hanging
-		err := u.ResolveMetadata()
-		if err != nil {
-			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+		switch r.URL.Path {
+		case "/download/big":
+			w.Header().Set("Content-Length", "4096")
+		case "/download/small":
+			w.Header().Set("Content-Length", "100")
+		default:
+			http.NotFound(w, r)
 		}
+	}))
+	defer server.Close()
 
-		// Both should be resolved
-		if _, ok := u.mods["circle-a"]; !ok {
-			t.Error("circle-a should be in mods map")
-		}
-		if _, ok := u.mods["circle-b"]; !ok {
-			t.Error("circle-b should have been discovered")
+	u := &Updater{
+		modServerURL: server.URL,
+		modPath:      t.TempDir(),
+		httpClient:   server.Client(),
+		mods: map[string]*ModData{
+			"big":     {Name: "big", Latest: &ModRelease{Version: "1.0.0", FileName: "big_1.0.0.zip", DownloadURL: "/download/big"}},
+			"small":   {Name: "small", Latest: &ModRelease{Version: "1.0.0", FileName: "small_1.0.0.zip", DownloadURL: "/download/small"}},
+			"vanish":  {Name: "vanish", Latest: &ModRelease{Version: "1.0.0", FileName: "vanish_1.0.0.zip", DownloadURL: "/download/vanish"}},
+			"nothing": {Name: "nothing"},
+		},
+	}
+
+	pending := u.PendingDownloads()
+	if len(pending) != 3 {
+		t.Fatalf("PendingDownloads() returned %d mods; want 3", len(pending))
+	}
+
+	want := DownloadEstimate{Mods: 3, Bytes: 4196, Unknown: 1}
+	if got := u.EstimateDownloads(context.Background(), pending); got != want {
+		t.Errorf("EstimateDownloads() = %+v; want %+v", got, want)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://mods.factorio.com/download/foo?token=secret&username=bob", "https://mods.factorio.com/download/foo?token=REDACTED&username=bob"},
+		{"https://mods.factorio.com/api/mods/foo/full", "https://mods.factorio.com/api/mods/foo/full"},
+	}
+
+	for _, tt := range tests {
+		if got := redactURL(tt.in); got != tt.want {
+			t.Errorf("redactURL(%q) = %q; want %q", tt.in, got, tt.want)
 		}
-	})
+	}
+}
 
-	t.Run("trims whitespace in dependency strings", func(t *testing.T) {
-		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			switch r.URL.Path {
-			case "/api/mods/trimmod/full":
-				resp := ModPortalMetadata{
-					Title: "Trim Mod",
-					Releases: []ModRelease{
-						{
-							Version:  "1.0.0",
-							FileName: "trimmod_1.0.0.zip",
-							InfoJSON: struct {
-								FactorioVersion string   `json:"factorio_version"`
-								Dependencies    []string `json:"dependencies"`
-							}{
-								FactorioVersion: "2.0",
-								Dependencies:    []string{"  spacey-dep >= 1.0.0  "},
-							},
-						},
-					},
-				}
-				_ = json.NewEncoder(w).Encode(resp)
-			case "/api/mods/spacey-dep/full":
-				resp := ModPortalMetadata{
-					Title: "Spacey Dep",
-					Releases: []ModRelease{
-						{
-							Version:  "1.0.0",
-							FileName: "spacey-dep_1.0.0.zip",
-							InfoJSON: struct {
-								FactorioVersion string   `json:"factorio_version"`
-								Dependencies    []string `json:"dependencies"`
-							}{
-								FactorioVersion: "2.0",
-								Dependencies:    []string{},
-							},
-						},
-					},
-				}
-				_ = json.NewEncoder(w).Encode(resp)
-			default:
-				http.NotFound(w, r)
+func TestClassifyFactorioVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want versionSupport
+	}{
+		{"2.0", versionSupported},
+		{"1.1", versionSupported},
+		{"1.0", versionSupported},
+		{"0.18", versionSupported},
+		{"0.17", versionTooOld},
+		{"0.16", versionTooOld},
+		{"3.0", versionTooNew},
+		{"", versionUnparseable},
+		{"garbage", versionUnparseable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := classifyFactorioVersion(tt.in); got != tt.want {
+				t.Errorf("classifyFactorioVersion(%q) = %v; want %v", tt.in, got, tt.want)
 			}
 		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.0.10", "2.0.9", 1},
+		{"2.0.9", "2.0.10", -1},
+		{"1.0", "1.0.0", 0},
+		{"1.0.0", "1.0", 0},
+		{"1.0.1", "1.0", 1},
+		{"1.01.0", "1.1.0", 0},
+		{"0.18.0", "1.0.0", -1},
+		{"10.0.0", "9.99.99", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			if got := compareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("compareVersions(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestReleaseSelectionIsNumeric(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		releases := make([]ModRelease, 0, 3)
+		for _, v := range []string{"2.0.9", "2.0.10", "2.0.2"} {
+			rel := ModRelease{Version: v, FileName: "numeric_" + v + ".zip"}
+			rel.InfoJSON.FactorioVersion = "2.0"
+			releases = append(releases, rel)
+		}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: "Numeric", Releases: releases})
+	}))
+	defer server.Close()
+
+	u := &Updater{
+		modServerURL: server.URL,
+		factVersion:  "2.0",
+		httpClient:   server.Client(),
+		mods:         map[string]*ModData{"numeric": {Name: "numeric"}},
+	}
+	if err := u.RetrieveModMetadata(context.Background(), "numeric"); err != nil {
+		t.Fatalf("RetrieveModMetadata() returned unexpected error: %v", err)
+	}
+	if got := u.mods["numeric"].Latest.Version; got != "2.0.10" {
+		t.Errorf("Latest.Version = %q; want 2.0.10", got)
+	}
+}
+
+func TestParseModListTracksNewestInstalledZip(t *testing.T) {
+	tmpDir := t.TempDir()
+	// ReadDir returns these in lexical order, where 2.0.10 sorts before 2.0.9.
+	for _, v := range []string{"2.0.10", "2.0.9"} {
+		_ = os.WriteFile(filepath.Join(tmpDir, "sidebyside_"+v+".zip"), []byte("zip"), 0o644)
+	}
+
+	u := &Updater{modPath: tmpDir, mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	if got := u.mods["sidebyside"].Version; got != "2.0.10" {
+		t.Errorf("Version = %q; want 2.0.10", got)
+	}
+}
+
+func TestReleaseSelectionFactorioVersionChecks(t *testing.T) {
+	release := func(version, factorioVersion string) ModRelease {
+		rel := ModRelease{Version: version, FileName: "loose_" + version + ".zip"}
+		rel.InfoJSON.FactorioVersion = factorioVersion
+		return rel
+	}
+
+	tests := []struct {
+		name     string
+		releases []ModRelease
+		ignore   bool
+		want     string // empty means no release is selected
+	}{
+		{"empty factorio_version is compatible", []ModRelease{release("1.0.0", "")}, false, "1.0.0"},
+		{"newest wins over empty factorio_version", []ModRelease{release("1.0.0", ""), release("1.1.0", "2.0")}, false, "1.1.0"},
+		{"mismatched version is rejected", []ModRelease{release("1.0.0", "1.1")}, false, ""},
+		{"ignore version check takes newest", []ModRelease{release("1.0.0", "2.0"), release("3.0.0", "1.1")}, true, "3.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: "Loose", Releases: tt.releases})
+			}))
+			defer server.Close()
+
+			u := &Updater{
+				modServerURL:       server.URL,
+				factVersion:        "2.0",
+				httpClient:         server.Client(),
+				ignoreVersionCheck: tt.ignore,
+				mods:               map[string]*ModData{"loose": {Name: "loose"}},
+			}
+			if err := u.RetrieveModMetadata(context.Background(), "loose"); err != nil {
+				t.Fatalf("RetrieveModMetadata() returned unexpected error: %v", err)
+			}
+
+			latest := u.mods["loose"].Latest
+			switch {
+			case tt.want == "" && latest != nil:
+				t.Errorf("Latest = %q; want no compatible release", latest.Version)
+			case tt.want != "" && (latest == nil || latest.Version != tt.want):
+				t.Errorf("Latest = %v; want %q", latest, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadFileErrorCategories(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error // nil means uncategorized but still failing
+	}{
+		{"403 is invalid credentials", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}, ErrInvalidCredentials},
+		{"401 is invalid credentials", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}, ErrInvalidCredentials},
+		{"login redirect is invalid credentials", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				_, _ = w.Write([]byte("<html>login</html>"))
+				return
+			}
+			http.Redirect(w, r, "/login", http.StatusFound)
+		}, ErrInvalidCredentials},
+		{"404 is release not found", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}, ErrReleaseNotFound},
+		{"500 is uncategorized", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			target := filepath.Join(t.TempDir(), "cat_1.0.0.zip")
+			err := (&Updater{httpClient: server.Client()}).downloadFile(context.Background(), target, server.URL+"/download/cat", nil, HashSHA1, "")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, category := range []error{ErrInvalidCredentials, ErrReleaseNotFound} {
+				if got, want := errors.Is(err, category), category == tt.want; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v; want %v", err, category, got, want)
+				}
+			}
+			if _, statErr := os.Stat(target + ".tmp"); !os.IsNotExist(statErr) {
+				t.Error("no temp file should be left behind")
+			}
+		})
+	}
+}
+
+func TestParseModListSkipsExtraBuiltIns(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "mod-list.json"), []byte(`{"mods": [
+		{"name": "base", "enabled": true},
+		{"name": "house-base", "enabled": true},
+		{"name": "helmod", "enabled": true}
+	]}`), 0o644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "house-base_1.0.0.zip"), []byte("zip"), 0o644)
+
+	u := &Updater{modPath: tmpDir, mods: make(map[string]*ModData), extraBuiltInMods: []string{"house-base"}}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	if _, ok := u.mods["house-base"]; ok {
+		t.Error("configured built-in mod should not be tracked")
+	}
+	if _, ok := u.mods["helmod"]; !ok {
+		t.Error("regular mod should still be tracked")
+	}
+}
+
+func TestDetectBundledMods(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	for _, name := range []string{"base", "core", "space-age", "new-expansion"} {
+		_ = os.MkdirAll(filepath.Join(dataDir, name), 0o755)
+		_ = os.WriteFile(filepath.Join(dataDir, name, "info.json"), []byte(`{}`), 0o644)
+	}
+	// Neither a folder without info.json nor a plain file is a mod.
+	_ = os.MkdirAll(filepath.Join(dataDir, "changelog-assets"), 0o755)
+	_ = os.WriteFile(filepath.Join(dataDir, "server-settings.json"), []byte(`{}`), 0o644)
+
+	factPath := filepath.Join(root, "bin", "x64", "factorio")
+
+	t.Run("data directory contents become the bundled set", func(t *testing.T) {
+		got := detectBundledMods(dataDirCandidates(factPath))
+		want := []string{"base", "core", "new-expansion", "space-age"}
+		if !slices.Equal(got, want) {
+			t.Errorf("detectBundledMods() = %v; want %v", got, want)
+		}
+
+		u := &Updater{bundledMods: got}
+		if !u.isBuiltInMod("new-expansion") {
+			t.Error("detected expansion should be built-in")
+		}
+		if u.isBuiltInMod("quality") {
+			t.Error("detected set should override the hardcoded defaults")
+		}
+	})
+
+	t.Run("missing data directory falls back to defaults", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "bin", "x64", "factorio")
+		if got := detectBundledMods(dataDirCandidates(missing)); got != nil {
+			t.Errorf("detectBundledMods() = %v; want nil", got)
+		}
+		if !(&Updater{}).isBuiltInMod("quality") {
+			t.Error("defaults should apply when nothing was detected")
+		}
+	})
+}
+
+func TestDurableWritesSync(t *testing.T) {
+	content := []byte("durable")
+	h := sha1.New()
+	h.Write(content)
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	var fileSyncs, dirSyncs int
+	origFile, origDir := fsyncFile, fsyncDir
+	fsyncFile = func(f *os.File) error { fileSyncs++; return origFile(f) }
+	fsyncDir = func(dir string) error { dirSyncs++; return origDir(dir) }
+	t.Cleanup(func() { fsyncFile, fsyncDir = origFile, origDir })
+
+	for _, noFsync := range []bool{false, true} {
+		fileSyncs, dirSyncs = 0, 0
+		tmpDir := t.TempDir()
+		u := &Updater{
+			httpClient: server.Client(),
+			modPath:    tmpDir,
+			noFsync:    noFsync,
+			mods:       map[string]*ModData{"durable": {Name: "durable", Enabled: true}},
+		}
+
+		if err := u.downloadFile(context.Background(), filepath.Join(tmpDir, "durable_1.0.0.zip"), server.URL, nil, HashSHA1, hash); err != nil {
+			t.Fatalf("downloadFile() returned unexpected error: %v", err)
+		}
+		if err := u.saveModList(); err != nil {
+			t.Fatalf("saveModList() returned unexpected error: %v", err)
+		}
+
+		want := 2 // one download and one mod-list write
+		if noFsync {
+			want = 0
+		}
+		if fileSyncs != want || dirSyncs != want {
+			t.Errorf("noFsync=%v: file syncs = %d, dir syncs = %d; want %d each", noFsync, fileSyncs, dirSyncs, want)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "mod-list.json")); err != nil {
+			t.Errorf("noFsync=%v: mod-list.json missing after save: %v", noFsync, err)
+		}
+	}
+}
+
+func TestDownloadFileRejectsOversizedBody(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 2048)
+
+	tests := []struct {
+		name          string
+		contentLength bool
+	}{
+		{"declared Content-Length over limit", true},
+		{"chunked body over limit", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentLength {
+					w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+				} else {
+					w.(http.Flusher).Flush() // force chunked encoding without a length
+				}
+				_, _ = w.Write(body)
+			}))
+			defer server.Close()
+
+			target := filepath.Join(t.TempDir(), "huge_1.0.0.zip")
+			u := &Updater{httpClient: server.Client(), maxDownloadBytes: 1024}
+			err := u.downloadFile(context.Background(), target, server.URL, nil, HashSHA1, "")
+
+			var tooLarge *DownloadTooLargeError
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("downloadFile() error = %v; want a *DownloadTooLargeError", err)
+			}
+			if tooLarge.Limit != 1024 {
+				t.Errorf("Limit = %d; want 1024", tooLarge.Limit)
+			}
+			for _, path := range []string{target, target + ".tmp"} {
+				if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+					t.Errorf("%s should not exist after a rejected download", path)
+				}
+			}
+		})
+	}
+}
+
+func TestNamesEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		foldCase bool
+		want     bool
+	}{
+		{"helmod", "helmod", false, true},
+		{"Helmod", "helmod", false, false},
+		{"Helmod", "helmod", true, true},
+		{"helmod", "helmod2", true, false},
+	}
+
+	for _, tt := range tests {
+		if got := namesEqual(tt.a, tt.b, tt.foldCase); got != tt.want {
+			t.Errorf("namesEqual(%q, %q, %v) = %v; want %v", tt.a, tt.b, tt.foldCase, got, tt.want)
+		}
+	}
+}
+
+func TestPruneOldCaseHandling(t *testing.T) {
+	setup := func(t *testing.T) (*Updater, string) {
+		tmpDir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.2.0.zip"), []byte("new"), 0644)
+		_ = os.WriteFile(filepath.Join(tmpDir, "Helmod_2.1.0.zip"), []byte("old"), 0644)
+		u := &Updater{
+			modPath: tmpDir,
+			mods: map[string]*ModData{
+				"helmod": {Name: "helmod", Latest: &ModRelease{Version: "2.2.0", FileName: "helmod_2.2.0.zip"}},
+			},
+		}
+		return u, tmpDir
+	}
+
+	t.Run("windows folds case", func(t *testing.T) {
+		if runtime.GOOS != "windows" {
+			t.Skip("case-insensitive matching only applies on Windows")
+		}
+		u, tmpDir := setup(t)
+		if err := u.pruneOld("helmod"); err != nil {
+			t.Fatalf("pruneOld() returned unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "Helmod_2.1.0.zip")); !os.IsNotExist(err) {
+			t.Error("differently cased old release should be pruned on Windows")
+		}
+	})
+
+	t.Run("other platforms keep case", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("case-sensitive matching does not apply on Windows")
+		}
+		u, tmpDir := setup(t)
+		if err := u.pruneOld("helmod"); err != nil {
+			t.Fatalf("pruneOld() returned unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "Helmod_2.1.0.zip")); err != nil {
+			t.Error("Helmod is a different mod on case-sensitive filesystems and must be kept")
+		}
+	})
+}
+
+func TestPruneOldKeepsExactFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		version  string
+	}{
+		{"file name casing differs from mod name", "Helmod_2.2.0.zip", "2.2.0"},
+		{"file name version differs from release version", "helmod_2.2.0.zip", "2.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			_ = os.WriteFile(filepath.Join(tmpDir, tt.fileName), []byte("fresh"), 0644)
+			_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.1.0.zip"), []byte("old"), 0644)
+
+			u := &Updater{
+				modPath: tmpDir,
+				mods: map[string]*ModData{
+					"helmod": {Name: "helmod", Latest: &ModRelease{Version: tt.version, FileName: tt.fileName}},
+				},
+			}
+			if err := u.pruneOld("helmod"); err != nil {
+				t.Fatalf("pruneOld() returned unexpected error: %v", err)
+			}
+
+			if _, err := os.Stat(filepath.Join(tmpDir, tt.fileName)); err != nil {
+				t.Errorf("freshly downloaded %s was deleted", tt.fileName)
+			}
+			if _, err := os.Stat(filepath.Join(tmpDir, "helmod_2.1.0.zip")); !os.IsNotExist(err) {
+				t.Error("old release should have been pruned")
+			}
+		})
+	}
+}
+
+func TestPruneOldKeepVersions(t *testing.T) {
+	versions := []string{"2.0.9", "2.0.10", "2.0.2", "1.9.0", "2.1.0"}
+
+	tests := []struct {
+		keep int
+		want []string // remaining files, sorted
+	}{
+		{0, []string{"keeper_2.1.0.zip"}},
+		{1, []string{"keeper_2.1.0.zip"}},
+		// 2.0.10 outranks 2.0.9 numerically even though it sorts first as a string.
+		{2, []string{"keeper_2.0.10.zip", "keeper_2.1.0.zip"}},
+		{3, []string{"keeper_2.0.10.zip", "keeper_2.0.9.zip", "keeper_2.1.0.zip"}},
+		{10, []string{"keeper_1.9.0.zip", "keeper_2.0.10.zip", "keeper_2.0.2.zip", "keeper_2.0.9.zip", "keeper_2.1.0.zip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("keep %d", tt.keep), func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, v := range versions {
+				_ = os.WriteFile(filepath.Join(tmpDir, "keeper_"+v+".zip"), []byte(v), 0644)
+			}
+			u := &Updater{
+				modPath:      tmpDir,
+				keepVersions: tt.keep,
+				mods: map[string]*ModData{
+					"keeper": {Name: "keeper", Latest: &ModRelease{Version: "2.1.0", FileName: "keeper_2.1.0.zip"}},
+				},
+			}
+			if err := u.pruneOld("keeper"); err != nil {
+				t.Fatalf("pruneOld() returned unexpected error: %v", err)
+			}
+
+			entries, _ := os.ReadDir(tmpDir)
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("remaining files = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildZipIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{
+		"helmod_2.1.0.zip", "helmod_2.2.12.zip", "flib_0.12.0.zip",
+		"Krastorio2_1.3.24.zip", "my_mod_name_0.1.0.zip",
+		"notes.txt", "helmod.zip", "helmod_latest.zip",
+	} {
+		_ = os.WriteFile(filepath.Join(tmpDir, name), nil, 0644)
+	}
+	// A directory with a release-like name is not a zip.
+	_ = os.Mkdir(filepath.Join(tmpDir, "dir_1.0.0.zip"), 0o755)
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := buildZipIndex(entries)
+
+	want := zipIndex{
+		"helmod":             {{"helmod_2.1.0.zip", "2.1.0"}, {"helmod_2.2.12.zip", "2.2.12"}},
+		"flib":               {{"flib_0.12.0.zip", "0.12.0"}},
+		modKey("Krastorio2"): {{"Krastorio2_1.3.24.zip", "1.3.24"}},
+		"my_mod_name":        {{"my_mod_name_0.1.0.zip", "0.1.0"}},
+	}
+	if !reflect.DeepEqual(idx, want) {
+		t.Errorf("buildZipIndex() = %v; want %v", idx, want)
+	}
+
+	idx.add("flib_0.13.0.zip")
+	idx.add("flib_0.13.0.zip")
+	idx.remove("helmod_2.1.0.zip")
+	idx.add("readme.md")
+	if got := idx["flib"]; len(got) != 2 || got[1].version != "0.13.0" {
+		t.Errorf("flib after add = %v; want the new release recorded once", got)
+	}
+	if got := idx["helmod"]; len(got) != 1 || got[0].name != "helmod_2.2.12.zip" {
+		t.Errorf("helmod after remove = %v; want only helmod_2.2.12.zip", got)
+	}
+}
+
+func TestPruneAllReadsDirectoryOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{
+		"helmod_2.1.0.zip", "helmod_2.2.12.zip",
+		"jetpack_0.4.0.zip", "jetpack_0.4.15.zip", "jetpack_0.3.9.zip",
+		"flib_0.12.0.zip", "unrelated.txt",
+	} {
+		_ = os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644)
+	}
+
+	var reads atomic.Int32
+	orig := readDir
+	readDir = func(name string) ([]os.DirEntry, error) {
+		reads.Add(1)
+		return orig(name)
+	}
+	defer func() { readDir = orig }()
+
+	release := func(file string) *ModRelease { return &ModRelease{FileName: file} }
+	mods := []*ModData{
+		{Name: "flib", Latest: release("flib_0.12.0.zip")},
+		{Name: "helmod", Latest: release("helmod_2.2.12.zip")},
+		{Name: "jetpack", Latest: release("jetpack_0.4.15.zip")},
+		{Name: "missing", Latest: release("missing_1.0.0.zip")},
+	}
+	u := &Updater{modPath: tmpDir, keepVersions: 1, mods: make(map[string]*ModData)}
+	for _, m := range mods {
+		u.mods[m.Name] = m
+	}
+
+	if errs := u.pruneAll(mods, nil); len(errs) != 0 {
+		t.Fatalf("pruneAll() returned unexpected errors: %v", errs)
+	}
+	if n := reads.Load(); n != 1 {
+		t.Errorf("mods directory was read %d times; want once for all mods", n)
+	}
+
+	entries, _ := os.ReadDir(tmpDir)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := []string{"flib_0.12.0.zip", "helmod_2.2.12.zip", "jetpack_0.4.15.zip", "unrelated.txt"}
+	if !slices.Equal(got, want) {
+		t.Errorf("remaining files = %v; want %v", got, want)
+	}
+}
+
+func TestRewriteModListOffline(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "mod-list.json"), []byte(`{"mods":[
+		{"name":"zebra-mod","enabled":true},
+		{"name":"base","enabled":true},
+		{"name":"alpha-mod","enabled":false,"version":"1.2.3"},
+		{"name":"middle-mod","enabled":true}
+	]}`), 0644)
+
+	// RewriteModList builds its Updater without an http.Client, so any
+	// network use would panic instead of passing silently.
+	count, err := RewriteModList(Options{ModPath: tmpDir, NoFsync: true})
+	if err != nil {
+		t.Fatalf("RewriteModList() returned unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("RewriteModList() count = %d; want 3 (built-in base skipped)", count)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, "mod-list.json"))
+	var result struct {
+		Mods []struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+			Version string `json:"version"`
+		} `json:"mods"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("failed to parse written mod-list.json: %v", err)
+	}
+	var names []string
+	for _, m := range result.Mods {
+		names = append(names, m.Name)
+	}
+	if want := []string{"alpha-mod", "middle-mod", "zebra-mod"}; !slices.Equal(names, want) {
+		t.Errorf("mod order = %v; want %v", names, want)
+	}
+	if result.Mods[0].Enabled || result.Mods[0].Version != "1.2.3" {
+		t.Errorf("alpha-mod = %+v; want disabled and pinned to 1.2.3", result.Mods[0])
+	}
+}
+
+func TestSaveModListPreserveOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		preserveOrder bool
+		want          []string
+	}{
+		{"sorted by default", false, []string{"alpha-mod", "beta-new", "middle-mod", "zebra-mod", "zz-new"}},
+		{"original order kept, new mods appended", true, []string{"zebra-mod", "alpha-mod", "middle-mod", "beta-new", "zz-new"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			_ = os.WriteFile(filepath.Join(tmpDir, "mod-list.json"), []byte(`{"mods":[
+				{"name":"zebra-mod","enabled":true},
+				{"name":"alpha-mod","enabled":true},
+				{"name":"middle-mod","enabled":false}
+			]}`), 0644)
+
+			u := &Updater{modPath: tmpDir, preserveOrder: tt.preserveOrder, noFsync: true, mods: make(map[string]*ModData)}
+			if err := u.parseModList(); err != nil {
+				t.Fatalf("parseModList() returned unexpected error: %v", err)
+			}
+			_ = u.AddMod("zz-new")
+			_ = u.AddMod("beta-new")
+			if err := u.saveModList(); err != nil {
+				t.Fatalf("saveModList() returned unexpected error: %v", err)
+			}
+
+			data, _ := os.ReadFile(filepath.Join(tmpDir, "mod-list.json"))
+			var result struct {
+				Mods []struct {
+					Name string `json:"name"`
+				} `json:"mods"`
+			}
+			if err := json.Unmarshal(data, &result); err != nil {
+				t.Fatalf("failed to parse written mod-list.json: %v", err)
+			}
+			var names []string
+			for _, m := range result.Mods {
+				names = append(names, m.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("mod order = %v; want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"1.2.0", false},
+		{"0.18.35", false},
+		{"1.2.0-beta", true},
+		{"2.0.0-rc.1", true},
+		{"1.2.0rc1", true},
+		{"1.2.b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := isPrerelease(tt.version); got != tt.want {
+				t.Errorf("isPrerelease(%q) = %v; want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectReleasePrereleaseFilter(t *testing.T) {
+	releases := func(versions ...string) []ModRelease {
+		var out []ModRelease
+		for _, v := range versions {
+			out = append(out, ModRelease{Version: v})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name            string
+		releases        []ModRelease
+		pinned          string
+		allowPrerelease bool
+		want            string
+	}{
+		{"prerelease newest is skipped", releases("1.0.0", "1.1.0", "1.2.0-beta"), "", false, "1.1.0"},
+		{"prerelease allowed", releases("1.0.0", "1.1.0", "1.2.0-beta"), "", true, "1.2.0-beta"},
+		{"stable wins a tie", releases("1.2.0-rc1", "1.2.0"), "", true, "1.2.0"},
+		{"stable wins a tie listed first", releases("1.2.0", "1.2.0-rc1"), "", true, "1.2.0"},
+		{"only prereleases", releases("0.1.0-alpha", "0.2.0-alpha"), "", false, ""},
+		{"pin overrides the filter", releases("1.1.0", "1.2.0-beta"), "1.2.0-beta", false, "1.2.0-beta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Updater{ignoreVersionCheck: true, allowPrerelease: tt.allowPrerelease}
+			got := ""
+			if rel := u.selectRelease(tt.releases, tt.pinned); rel != nil {
+				got = rel.Version
+			}
+			if got != tt.want {
+				t.Errorf("selectRelease() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMetadataTracksNewestRelease(t *testing.T) {
+	release := func(version, factorio string) ModRelease {
+		rel := ModRelease{Version: version}
+		rel.InfoJSON.FactorioVersion = factorio
+		return rel
+	}
+	meta := ModPortalMetadata{Title: "Helmod", Releases: []ModRelease{
+		release("1.4.0", "1.1"),
+		release("1.5.0", "2.0"),
+		release("3.0.0", "2.1"),
+		release("3.1.0-beta", "2.1"),
+	}}
+
+	tests := []struct {
+		name       string
+		pinned     string
+		wantLatest string
+		wantNewest string
+		wantNewer  string
+	}{
+		{"newer release needs another factorio", "", "1.5.0", "3.0.0", "3.0.0"},
+		{"pinned mods report no newer release", "1.4.0", "1.4.0", "3.0.0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Updater{factVersion: "2.0"}
+			m := &ModData{Name: "helmod", PinnedVersion: tt.pinned}
+			if err := u.applyMetadata(m, meta); err != nil {
+				t.Fatalf("applyMetadata() returned unexpected error: %v", err)
+			}
+			if m.Latest == nil || m.Latest.Version != tt.wantLatest {
+				t.Errorf("Latest = %+v; want %s", m.Latest, tt.wantLatest)
+			}
+			if m.Newest == nil || m.Newest.Version != tt.wantNewest {
+				t.Errorf("Newest = %+v; want %s", m.Newest, tt.wantNewest)
+			}
+			got := ""
+			if newer := m.NewerIncompatible(); newer != nil {
+				got = newer.Version
+			}
+			if got != tt.wantNewer {
+				t.Errorf("NewerIncompatible() = %q; want %q", got, tt.wantNewer)
+			}
+		})
+	}
+}
+
+// --- Unit test for ResolveMetadata using httptest ---
+
+func TestResolveMetadata(t *testing.T) {
+	t.Run("resolves transitive dependencies", func(t *testing.T) {
+		// Mock API: "mod-a" depends on "mod-b", "mod-b" has no further deps.
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/mods/mod-a/full":
+				resp := ModPortalMetadata{
+					Title: "Mod A",
+					Releases: []ModRelease{
+						{
+							Version:     "1.0.0",
+							DownloadURL: "/download/mod-a",
+							FileName:    "mod-a_1.0.0.zip",
+							Sha1:        "aaa",
+							InfoJSON: struct {
+								FactorioVersion string   `json:"factorio_version"`
+								Dependencies    []string `json:"dependencies"`
+							}{
+								FactorioVersion: "2.0",
+								Dependencies:    []string{"mod-b >= 1.0.0"},
+							},
+						},
+					},
+				}
+				_ = json.NewEncoder(w).Encode(resp)
+			case "/api/mods/mod-b/full":
+				resp := ModPortalMetadata{
+					Title: "Mod B",
+					Releases: []ModRelease{
+						{
+							Version:     "1.2.0",
+							DownloadURL: "/download/mod-b",
+							FileName:    "mod-b_1.2.0.zip",
+							Sha1:        "bbb",
+							InfoJSON: struct {
+								FactorioVersion string   `json:"factorio_version"`
+								Dependencies    []string `json:"dependencies"`
+							}{
+								FactorioVersion: "2.0",
+								Dependencies:    []string{},
+							},
+						},
+					},
+				}
+				_ = json.NewEncoder(w).Encode(resp)
+			default:
+				http.NotFound(w, r)
+			}
+		})
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		u := &Updater{
+			modServerURL: server.URL,
+			factVersion:  "2.0",
+			mods: map[string]*ModData{
+				"mod-a": {Name: "mod-a", Title: "mod-a", Enabled: true},
+			},
+			httpClient: server.Client(),
+		}
+
+		err := u.ResolveMetadata(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+		}
+
+		// mod-a should have its title resolved
+		if u.mods["mod-a"].Title != "Mod A" {
+			t.Errorf("mod-a title = %q; want %q", u.mods["mod-a"].Title, "Mod A")
+		}
+
+		// mod-b should have been discovered as a transitive dependency
+		modB, ok := u.mods["mod-b"]
+		if !ok {
+			t.Fatal("expected transitive dependency 'mod-b' to be discovered")
+		}
+		if modB.Title != "Mod B" {
+			t.Errorf("mod-b title = %q; want %q", modB.Title, "Mod B")
+		}
+		if modB.Source != FromDependency {
+			t.Errorf("mod-b Source = %v; want %v", modB.Source, FromDependency)
+		}
+		if modB.Latest == nil {
+			t.Fatal("mod-b should have a Latest release")
+		}
+		if modB.Latest.Version != "1.2.0" {
+			t.Errorf("mod-b latest version = %q; want %q", modB.Latest.Version, "1.2.0")
+		}
+	})
+
+	t.Run("handles circular dependencies without infinite loop", func(t *testing.T) {
+		// "circle-a" depends on "circle-b", "circle-b" depends on "circle-a"
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var resp ModPortalMetadata
+			switch r.URL.Path {
+			case "/api/mods/circle-a/full":
+				resp = ModPortalMetadata{
+					Title: "Circle A",
+					Releases: []ModRelease{
+						{
+							Version:  "1.0.0",
+							FileName: "circle-a_1.0.0.zip",
+							InfoJSON: struct {
+								FactorioVersion string   `json:"factorio_version"`
+								Dependencies    []string `json:"dependencies"`
+							}{
+								FactorioVersion: "2.0",
+								Dependencies:    []string{"circle-b"},
+							},
+						},
+					},
+				}
+			case "/api/mods/circle-b/full":
+				resp = ModPortalMetadata{
+					Title: "Circle B",
+					Releases: []ModRelease{
+						{
+							Version:  "1.0.0",
+							FileName: "circle-b_1.0.0.zip",
+							InfoJSON: struct {
+								FactorioVersion string   `json:"factorio_version"`
+								Dependencies    []string `json:"dependencies"`
+							}{
+								FactorioVersion: "2.0",
+								Dependencies:    []string{"circle-a"},
+							},
+						},
+					},
+				}
+			default:
+				http.NotFound(w, r)
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		})
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		u := &Updater{
+			modServerURL: server.URL,
+			factVersion:  "2.0",
+			mods: map[string]*ModData{
+				"circle-a": {Name: "circle-a", Title: "circle-a", Enabled: true},
+			},
+			httpClient: server.Client(),
+		}
+
+		// Should complete without hanging
+		err := u.ResolveMetadata(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+		}
+
+		// Both should be resolved
+		if _, ok := u.mods["circle-a"]; !ok {
+			t.Error("circle-a should be in mods map")
+		}
+		if _, ok := u.mods["circle-b"]; !ok {
+			t.Error("circle-b should have been discovered")
+		}
+	})
+
+	t.Run("trims whitespace in dependency strings", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/mods/trimmod/full":
+				resp := ModPortalMetadata{
+					Title: "Trim Mod",
+					Releases: []ModRelease{
+						{
+							Version:  "1.0.0",
+							FileName: "trimmod_1.0.0.zip",
+							InfoJSON: struct {
+								FactorioVersion string   `json:"factorio_version"`
+								Dependencies    []string `json:"dependencies"`
+							}{
+								FactorioVersion: "2.0",
+								Dependencies:    []string{"  spacey-dep >= 1.0.0  "},
+							},
+						},
+					},
+				}
+				_ = json.NewEncoder(w).Encode(resp)
+			case "/api/mods/spacey-dep/full":
+				resp := ModPortalMetadata{
+					Title: "Spacey Dep",
+					Releases: []ModRelease{
+						{
+							Version:  "1.0.0",
+							FileName: "spacey-dep_1.0.0.zip",
+							InfoJSON: struct {
+								FactorioVersion string   `json:"factorio_version"`
+								Dependencies    []string `json:"dependencies"`
+							}{
+								FactorioVersion: "2.0",
+								Dependencies:    []string{},
+							},
+						},
+					},
+				}
+				_ = json.NewEncoder(w).Encode(resp)
+			default:
+				http.NotFound(w, r)
+			}
+		})
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		u := &Updater{
+			modServerURL: server.URL,
+			factVersion:  "2.0",
+			mods: map[string]*ModData{
+				"trimmod": {Name: "trimmod", Title: "trimmod", Enabled: true},
+			},
+			httpClient: server.Client(),
+		}
+
+		err := u.ResolveMetadata(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+		}
+
+		if _, ok := u.mods["spacey-dep"]; !ok {
+			t.Error("expected whitespace-padded dependency 'spacey-dep' to be discovered after TrimSpace")
+		}
+	})
+
+	t.Run("normalizes padded dependency names into map keys", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resp := ModPortalMetadata{Title: r.URL.Path}
+			rel := ModRelease{Version: "1.0.0", FileName: "x_1.0.0.zip"}
+			rel.InfoJSON.FactorioVersion = "2.0"
+			if r.URL.Path == "/api/mods/bobplates/full" {
+				rel.InfoJSON.Dependencies = []string{"boblibrary ", "  bobores  >= 1.0.0", "\tbobwarfare\t"}
+			}
+			resp.Releases = []ModRelease{rel}
+			_ = json.NewEncoder(w).Encode(resp)
+		})
 
 		server := httptest.NewServer(handler)
 		defer server.Close()
 
-		u := &Updater{
-			modServerURL: server.URL,
-			factVersion:  "2.0",
-			mods: map[string]*ModData{
-				"trimmod": {Name: "trimmod", Title: "trimmod", Enabled: true},
-			},
-			httpClient: server.Client(),
+		u := &Updater{
+			modServerURL: server.URL,
+			factVersion:  "2.0",
+			mods: map[string]*ModData{
+				"bobplates": {Name: "bobplates", Title: "bobplates", Enabled: true},
+			},
+			httpClient: server.Client(),
+		}
+
+		if err := u.ResolveMetadata(context.Background(), nil); err != nil {
+			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+		}
+
+		want := []string{"boblibrary", "bobores", "bobplates", "bobwarfare"}
+		got := make([]string, 0, len(u.mods))
+		for name := range u.mods {
+			got = append(got, name)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("mods map keys = %q; want %q", got, want)
+		}
+	})
+}
+
+func TestPinnedVersionSelection(t *testing.T) {
+	release := func(version, factorioVersion string) ModRelease {
+		rel := ModRelease{Version: version, FileName: "pinme_" + version + ".zip"}
+		rel.InfoJSON.FactorioVersion = factorioVersion
+		return rel
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{
+			Title: "Pin Me",
+			Releases: []ModRelease{
+				release("1.0.0", "1.1"),
+				release("2.0.0", "2.0"),
+				release("2.1.0", "2.0"),
+			},
+		})
+	}))
+	defer server.Close()
+
+	newUpdater := func(pin string) *Updater {
+		return &Updater{
+			modServerURL: server.URL,
+			factVersion:  "2.0",
+			httpClient:   server.Client(),
+			mods: map[string]*ModData{
+				"pinme": {Name: "pinme", PinnedVersion: pin},
+			},
+		}
+	}
+
+	t.Run("pin selects exact release", func(t *testing.T) {
+		u := newUpdater("2.0.0")
+		if err := u.RetrieveModMetadata(context.Background(), "pinme"); err != nil {
+			t.Fatalf("RetrieveModMetadata() returned unexpected error: %v", err)
+		}
+		if got := u.mods["pinme"].Latest.Version; got != "2.0.0" {
+			t.Errorf("Latest.Version = %q; want pinned 2.0.0", got)
+		}
+	})
+
+	t.Run("unknown pin is an error", func(t *testing.T) {
+		u := newUpdater("9.9.9")
+		if err := u.RetrieveModMetadata(context.Background(), "pinme"); err == nil {
+			t.Fatal("expected an error for a pinned version missing from the portal")
+		}
+		if u.mods["pinme"].Latest != nil {
+			t.Error("Latest should be nil when the pinned version is missing")
+		}
+	})
+
+	t.Run("pin round-trips through mod-list.json", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		u := &Updater{
+			modPath: tmpDir,
+			mods:    map[string]*ModData{"pinme": {Name: "pinme", Enabled: true, PinnedVersion: "2.0.0"}},
+		}
+		if err := u.saveModList(); err != nil {
+			t.Fatalf("saveModList() returned unexpected error: %v", err)
+		}
+
+		reloaded := &Updater{modPath: tmpDir, mods: make(map[string]*ModData)}
+		if err := reloaded.parseModList(); err != nil {
+			t.Fatalf("parseModList() returned unexpected error: %v", err)
+		}
+		if got := reloaded.mods["pinme"].PinnedVersion; got != "2.0.0" {
+			t.Errorf("PinnedVersion = %q after reload; want 2.0.0", got)
+		}
+	})
+}
+
+func TestRemoveMod(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.2.11.zip"), []byte("old"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "helmod_2.2.12.zip"), []byte("new"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "helmod-extras_1.0.0.zip"), []byte("other"), 0644)
+
+	u := &Updater{
+		modPath: tmpDir,
+		mods: map[string]*ModData{
+			"helmod":        {Name: "helmod"},
+			"helmod-extras": {Name: "helmod-extras"},
+		},
+	}
+
+	if err := u.RemoveMod("helmod"); err != nil {
+		t.Fatalf("RemoveMod() returned unexpected error: %v", err)
+	}
+
+	if _, ok := u.mods["helmod"]; ok {
+		t.Error("helmod should no longer be tracked")
+	}
+	for _, name := range []string{"helmod_2.2.11.zip", "helmod_2.2.12.zip"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "helmod-extras_1.0.0.zip")); err != nil {
+		t.Error("helmod-extras_1.0.0.zip should NOT have been touched")
+	}
+}
+
+func TestResolveMetadataProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		if name == "root" {
+			rel.InfoJSON.Dependencies = []string{"dep-a", "dep-b"}
+		}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	u := &Updater{
+		modServerURL: server.URL,
+		factVersion:  "2.0",
+		httpClient:   server.Client(),
+		mods: map[string]*ModData{
+			"root":  {Name: "root"},
+			"other": {Name: "other"},
+		},
+	}
+
+	var calls []ResolveProgress
+	if err := u.ResolveMetadata(context.Background(), func(p ResolveProgress) { calls = append(calls, p) }); err != nil {
+		t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+	}
+
+	if len(calls) != 4 {
+		t.Fatalf("progress callback called %d times; want 4 (one per mod)", len(calls))
+	}
+	for i, p := range calls {
+		if p.Resolved != i+1 {
+			t.Errorf("call %d: Resolved = %d; want %d", i, p.Resolved, i+1)
+		}
+		if p.Resolved > p.Total {
+			t.Errorf("call %d: Resolved %d exceeds Total %d", i, p.Resolved, p.Total)
+		}
+	}
+	if last := calls[len(calls)-1]; last.Total != 4 || last.Discovered != 2 {
+		t.Errorf("final progress = %+v; want Total 4, Discovered 2", last)
+	}
+}
+
+func TestResolveMetadataLabelsSource(t *testing.T) {
+	deps := map[string][]string{
+		"root":   {"listed", "dep-a"},
+		"dep-a":  {"dep-b"},
+		"listed": nil,
+		"dep-b":  nil,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		rel.InfoJSON.Dependencies = deps[name]
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"root","enabled":true},{"name":"listed","enabled":true}]}`), 0644)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	if err := u.ResolveMetadata(context.Background(), nil); err != nil {
+		t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+	}
+
+	// "listed" is also a dependency of root, but the user's choice wins.
+	want := map[string]ModSource{"root": FromModList, "listed": FromModList, "dep-a": FromDependency, "dep-b": FromDependency}
+	for name, source := range want {
+		m, ok := u.mods[name]
+		if !ok {
+			t.Errorf("%s is not tracked", name)
+			continue
+		}
+		if m.Source != source {
+			t.Errorf("%s Source = %v; want %v", name, m.Source, source)
+		}
+	}
+}
+
+func TestResolveMetadataStopsAtOverallTimeout(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		rel := ModRelease{Version: "1.0.0", FileName: "root_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		rel.InfoJSON.Dependencies = []string{"dep-a"}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: "root", Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"root","enabled":true}]}`), 0644)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+
+	// The budget runs out as soon as the first fetch completes.
+	cause := errors.New("overall timeout")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	err := u.ResolveMetadata(ctx, func(ResolveProgress) { cancel(cause) })
+
+	if !errors.Is(err, cause) {
+		t.Fatalf("ResolveMetadata() error = %v; want it to wrap the cancellation cause", err)
+	}
+	if !strings.Contains(err.Error(), "after 1 of 1 mods") {
+		t.Errorf("error %q should report what completed", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("portal was queried %d times; want 1", n)
+	}
+	if _, ok := u.mods["dep-a"]; ok {
+		t.Error("dependencies should not be resolved after the deadline")
+	}
+}
+
+func TestUpdateModsSkipsDownloadsAfterOverallTimeout(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	u := &Updater{
+		modServerURL: server.URL,
+		modPath:      t.TempDir(),
+		httpClient:   server.Client(),
+		noFsync:      true,
+		logLevel:     LogQuiet,
+		mods: map[string]*ModData{
+			"helmod": {Name: "helmod", Enabled: true, Latest: &ModRelease{Version: "2.0.0", FileName: "helmod_2.0.0.zip", DownloadURL: "/download/helmod"}},
+		},
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cause := errors.New("overall timeout")
+	cancel(cause)
+
+	result, err := u.UpdateMods(ctx)
+	if !errors.Is(err, cause) {
+		t.Fatalf("UpdateMods() error = %v; want it to wrap the cancellation cause", err)
+	}
+	if hits.Load() != 0 || len(result.Updated) != 0 {
+		t.Errorf("got %d requests and %d updates; want no downloads once the deadline passed", hits.Load(), len(result.Updated))
+	}
+}
+
+func TestUnresolvedModsClassification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		switch name {
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case "gone":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "legacy":
+			rel.InfoJSON.FactorioVersion = "1.1"
+		}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[
+		{"name":"fine","enabled":true},
+		{"name":"legacy","enabled":true},
+		{"name":"pinned","enabled":true,"version":"0.5.0"},
+		{"name":"broken","enabled":true},
+		{"name":"gone","enabled":true},
+		{"name":"off","enabled":false}
+	]}`), 0644)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	u.mods["off"].PinnedVersion = "9.9.9" // unresolved, but disabled mods are not reported
+	_ = u.ResolveMetadata(context.Background(), nil)
+
+	want := map[string]UnresolvedReason{
+		"broken": PortalError,
+		"gone":   PortalError,
+		"legacy": NoCompatibleRelease,
+		"pinned": NoCompatibleRelease,
+	}
+	var names []string
+	for _, m := range u.UnresolvedMods() {
+		names = append(names, m.Name)
+		if got := m.UnresolvedReason(); got != want[m.Name] {
+			t.Errorf("%s UnresolvedReason() = %v; want %v", m.Name, got, want[m.Name])
+		}
+	}
+	if wantNames := []string{"broken", "gone", "legacy", "pinned"}; !slices.Equal(names, wantNames) {
+		t.Errorf("UnresolvedMods() = %v; want %v", names, wantNames)
+	}
+}
+
+func TestResolveMetadataNoDeps(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/mods" {
+			http.Error(w, "bulk endpoint unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		hits.Add(1)
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		rel.InfoJSON.Dependencies = []string{"base >= 2.0", "flib >= 0.12", "? optional-lib"}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"helmod","enabled":true},{"name":"jetpack","enabled":true}]}`), 0644)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), noDeps: true, mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+	if err := u.ResolveMetadata(context.Background(), nil); err != nil {
+		t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+	}
+
+	if len(u.mods) != 2 {
+		t.Errorf("tracked %d mods; want only the 2 from mod-list.json", len(u.mods))
+	}
+	if _, ok := u.mods["flib"]; ok {
+		t.Error("flib should not be added with noDeps")
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("/full was queried %d times; want 2 after the bulk request failed", n)
+	}
+}
+
+func TestResolveMetadataMaxDepth(t *testing.T) {
+	// a -> b -> c -> d: with a limit of 2, b and c resolve and d is left over.
+	chain := map[string]string{"a": "b", "b": "c", "c": "d"}
+	var fetched sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		fetched.Store(name, true)
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		if dep, ok := chain[name]; ok {
+			rel.InfoJSON.Dependencies = []string{dep}
+		}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	u := &Updater{modServerURL: server.URL, modPath: t.TempDir(), factVersion: "2.0", httpClient: server.Client(), maxDepth: 2, mods: map[string]*ModData{
+		"a": {Name: "a", Enabled: true},
+	}}
+	if err := u.ResolveMetadata(context.Background(), nil); err != nil {
+		t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+	}
+
+	for name, depth := range map[string]int{"a": 0, "b": 1, "c": 2, "d": 3} {
+		m, ok := u.mods[name]
+		if !ok {
+			t.Fatalf("%s is not tracked", name)
+		}
+		if m.Depth != depth {
+			t.Errorf("%s Depth = %d; want %d", name, m.Depth, depth)
+		}
+	}
+	if _, ok := fetched.Load("d"); ok {
+		t.Error("d is beyond the depth limit and should not be fetched")
+	}
+	unresolved := u.UnresolvedMods()
+	if len(unresolved) != 1 || unresolved[0].Name != "d" || unresolved[0].UnresolvedReason() != BeyondMaxDepth {
+		t.Errorf("UnresolvedMods() = %+v; want only d, beyond the depth limit", unresolved)
+	}
+}
+
+func TestResolveMetadataMaxMods(t *testing.T) {
+	// Every mod depends on two fresh ones, doubling each round.
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		rel.InfoJSON.Dependencies = []string{name + "-x", name + "-y"}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	u := &Updater{modServerURL: server.URL, modPath: t.TempDir(), factVersion: "2.0", httpClient: server.Client(), maxMods: 10, mods: map[string]*ModData{
+		"root": {Name: "root", Enabled: true},
+	}}
+	err := u.ResolveMetadata(context.Background(), nil)
+	if !errors.Is(err, ErrTooManyMods) {
+		t.Fatalf("ResolveMetadata() error = %v; want ErrTooManyMods", err)
+	}
+	if !strings.Contains(err.Error(), "15 mods, more than the limit of 10") {
+		t.Errorf("error = %q; want the would-be count and the limit", err)
+	}
+	// 1 + 2 + 4 mods fit; the round that would add 8 more is not fetched.
+	if len(u.mods) != 7 || hits.Load() != 7 {
+		t.Errorf("tracked %d mods after %d fetches; want 7 of each", len(u.mods), hits.Load())
+	}
+}
+
+func TestResolveMetadataFetchesEachModOnce(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		mu.Lock()
+		hits[name]++
+		mu.Unlock()
+		if name == "broken-lib" {
+			http.Error(w, "unavailable", http.StatusBadGateway)
+			return
+		}
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		if name != "shared" {
+			rel.InfoJSON.Dependencies = []string{"shared", "broken-lib"}
+		} else {
+			rel.InfoJSON.Dependencies = []string{"broken-lib", "a"}
+		}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	// a, b, and c all need shared and broken-lib; shared needs broken-lib
+	// and points back at a.
+	u := &Updater{modServerURL: server.URL, modPath: t.TempDir(), factVersion: "2.0", httpClient: server.Client(), mods: map[string]*ModData{
+		"a": {Name: "a", Enabled: true},
+		"b": {Name: "b", Enabled: true},
+		"c": {Name: "c", Enabled: true},
+	}}
+	if err := u.ResolveMetadata(context.Background(), nil); err == nil {
+		t.Fatal("ResolveMetadata() returned nil error; want broken-lib reported")
+	}
+
+	want := map[string]int{"a": 1, "b": 1, "c": 1, "shared": 1, "broken-lib": 1}
+	if !reflect.DeepEqual(hits, want) {
+		t.Errorf("fetches per mod = %v; want %v", hits, want)
+	}
+}
+
+func TestResolveMetadataStrictDependencies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/mods/"), "/full")
+		rel := ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip"}
+		rel.InfoJSON.FactorioVersion = "2.0"
+		switch name {
+		case "app":
+			rel.InfoJSON.Dependencies = []string{"base >= 2.0", "old-lib >= 1.0.0", "flib", "? optional-lib"}
+		case "old-lib":
+			rel.InfoJSON.FactorioVersion = "1.1"
+		}
+		_ = json.NewEncoder(w).Encode(ModPortalMetadata{Title: name, Releases: []ModRelease{rel}})
+	}))
+	defer server.Close()
+
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			u := &Updater{modServerURL: server.URL, modPath: t.TempDir(), factVersion: "2.0", httpClient: server.Client(), strictDeps: strict, mods: map[string]*ModData{
+				"app": {Name: "app", Enabled: true},
+			}}
+			err := u.ResolveMetadata(context.Background(), nil)
+			if !strict {
+				if err != nil {
+					t.Errorf("ResolveMetadata() returned unexpected error: %v", err)
+				}
+				return
+			}
+
+			var depErr *UnsatisfiedDependenciesError
+			if !errors.As(err, &depErr) {
+				t.Fatalf("ResolveMetadata() error = %v; want an *UnsatisfiedDependenciesError", err)
+			}
+			want := []*DependencyError{{Mod: "app", Dependency: "old-lib", Constraint: ">= 1.0.0", Reason: "no compatible release for Factorio 2.0"}}
+			if !reflect.DeepEqual(depErr.Errs, want) {
+				t.Errorf("unsatisfied = %+v; want %+v", depErr.Errs, want)
+			}
+			if msg := err.Error(); !strings.Contains(msg, "app requires old-lib >= 1.0.0: no compatible release for Factorio 2.0") {
+				t.Errorf("error = %q; want it to name the dependency and failed constraint", msg)
+			}
+		})
+	}
+}
+
+func TestResolveMetadataOrphanedMods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), []byte(`{"mods":[{"name":"taken-down","enabled":true},{"name":"typo","enabled":true}]}`), 0644)
+	_ = os.WriteFile(filepath.Join(modDir, "taken-down_1.0.0.zip"), []byte("zip"), 0644)
+	u := &Updater{modServerURL: server.URL, modPath: modDir, factVersion: "2.0", httpClient: server.Client(), logLevel: LogQuiet, noFsync: true, keepVersions: 1, mods: make(map[string]*ModData)}
+	if err := u.parseModList(); err != nil {
+		t.Fatalf("parseModList() returned unexpected error: %v", err)
+	}
+
+	// Only the mod that was never installed counts as a failure.
+	err := u.ResolveMetadata(context.Background(), nil)
+	var resolveErr *ResolveError
+	if !errors.As(err, &resolveErr) || len(resolveErr.Errs) != 1 {
+		t.Fatalf("ResolveMetadata() error = %v; want a ResolveError for typo only", err)
+	}
+	if groups := resolveErr.Groups(); len(groups) != 1 || groups[0].Kind != MetadataNotFound || groups[0].Mods[0] != "typo" {
+		t.Errorf("Groups() = %+v; want typo not found", groups)
+	}
+	if got := u.mods["taken-down"].UnresolvedReason(); got != Orphaned {
+		t.Errorf("taken-down UnresolvedReason() = %v; want %v", got, Orphaned)
+	}
+	if got := u.mods["typo"].UnresolvedReason(); got != PortalError {
+		t.Errorf("typo UnresolvedReason() = %v; want %v", got, PortalError)
+	}
+
+	delete(u.mods, "typo")
+	if _, err := u.UpdateMods(context.Background()); err != nil {
+		t.Errorf("UpdateMods() returned unexpected error for an orphaned mod: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(modDir, "taken-down_1.0.0.zip")); err != nil {
+		t.Errorf("orphaned mod's zip was removed: %v", err)
+	}
+}
+
+func TestUpdateModsReportsModListSaveFailureSeparately(t *testing.T) {
+	content := []byte("fresh release")
+	h := sha1.New()
+	h.Write(content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	// A directory squatting on the temp path makes the mod-list write fail
+	// while leaving the mods directory itself writable for the download.
+	_ = os.Mkdir(filepath.Join(modDir, "mod-list.json.tmp"), 0o755)
+
+	u := &Updater{
+		modServerURL: server.URL,
+		modPath:      modDir,
+		httpClient:   server.Client(),
+		logLevel:     LogQuiet,
+		mods: map[string]*ModData{
+			"fresh": {Name: "fresh", Title: "Fresh", Enabled: true, Latest: &ModRelease{
+				Version:     "1.0.0",
+				FileName:    "fresh_1.0.0.zip",
+				DownloadURL: "/download/fresh",
+				Sha1:        hex.EncodeToString(h.Sum(nil)),
+			}},
+		},
+	}
+
+	result, err := u.UpdateMods(context.Background())
+	var saveErr *ModListSaveError
+	if !errors.As(err, &saveErr) {
+		t.Fatalf("UpdateMods() error = %v; want a *ModListSaveError", err)
+	}
+	if saveErr.Path != filepath.Join(modDir, "mod-list.json") {
+		t.Errorf("Path = %q; want the mod-list.json path", saveErr.Path)
+	}
+	if len(result.Updated) != 1 || result.Updated[0].Name != "fresh" {
+		t.Errorf("Updated = %+v; want the successful download to be reported", result.Updated)
+	}
+	if _, statErr := os.Stat(filepath.Join(modDir, "fresh_1.0.0.zip")); statErr != nil {
+		t.Errorf("downloaded release missing: %v", statErr)
+	}
+}
+
+func TestUpdateModsNoPruneKeepsOldReleases(t *testing.T) {
+	content := []byte("new release")
+	h := sha1.New()
+	h.Write(content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	modDir := t.TempDir()
+	oldZips := []string{"backup_1.0.0.zip", "backup_1.1.0.zip"}
+	for _, name := range oldZips {
+		_ = os.WriteFile(filepath.Join(modDir, name), []byte("old"), 0644)
+	}
+
+	u := &Updater{
+		modServerURL: server.URL,
+		modPath:      modDir,
+		httpClient:   server.Client(),
+		noPrune:      true,
+		logLevel:     LogQuiet,
+		mods: map[string]*ModData{
+			"backup": {Name: "backup", Title: "Backup", Enabled: true, Installed: true, Version: "1.1.0", Latest: &ModRelease{
+				Version:     "1.2.0",
+				FileName:    "backup_1.2.0.zip",
+				DownloadURL: "/download/backup",
+				Sha1:        hex.EncodeToString(h.Sum(nil)),
+			}},
+		},
+	}
+
+	result, err := u.UpdateMods(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateMods() returned unexpected error: %v", err)
+	}
+	if len(result.Updated) != 1 {
+		t.Errorf("Updated = %+v; want the new release to be downloaded", result.Updated)
+	}
+	for _, name := range append(oldZips, "backup_1.2.0.zip") {
+		if _, statErr := os.Stat(filepath.Join(modDir, name)); statErr != nil {
+			t.Errorf("%s should remain on disk with noPrune: %v", name, statErr)
 		}
+	}
+}
+
+func TestDetermineVersionProbeErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+
+	orig := versionProbeTimeout
+	versionProbeTimeout = 300 * time.Millisecond
+	defer func() { versionProbeTimeout = orig }()
+
+	tests := []struct {
+		name       string
+		script     string // empty means the binary does not exist
+		wantKind   VersionProbeErrorKind
+		wantOutput string
+	}{
+		{"missing binary", "", ProbeNotRunnable, ""},
+		{"hangs after partial output", "echo 'Version: 2.0'\nsleep 5\n", ProbeTimeout, "Version: 2.0"},
+		{"prints garbage", "echo 'Segmentation fault in the mod loader'\n", ProbeUnparseable, "Segmentation fault in the mod loader"},
+		{"exits non-zero", "echo 'cannot open display' >&2\nexit 3\n", ProbeFailed, "cannot open display"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin := filepath.Join(t.TempDir(), "factorio")
+			if tt.script != "" {
+				_ = os.WriteFile(bin, []byte("#!/bin/sh\n"+tt.script), 0755)
+			}
+
+			err := (&Updater{factPath: bin}).determineVersion()
+			var probeErr *VersionProbeError
+			if !errors.As(err, &probeErr) {
+				t.Fatalf("determineVersion() error = %v; want a *VersionProbeError", err)
+			}
+			if probeErr.Kind != tt.wantKind {
+				t.Errorf("Kind = %d; want %d (error: %v)", probeErr.Kind, tt.wantKind, err)
+			}
+			if probeErr.Output != tt.wantOutput {
+				t.Errorf("Output = %q; want %q", probeErr.Output, tt.wantOutput)
+			}
+			if tt.wantOutput != "" && !strings.Contains(err.Error(), tt.wantOutput) {
+				t.Errorf("error %q should include the captured output", err)
+			}
+		})
+	}
+}
+
+func TestDetermineVersionFakeBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+	bin := filepath.Join(t.TempDir(), "factorio")
+	_ = os.WriteFile(bin, []byte("#!/bin/sh\necho 'Version: 2.0.28 (build 80000, linux64, headless)'\n"), 0755)
+
+	u := &Updater{factPath: bin}
+	if err := u.determineVersion(); err != nil {
+		t.Fatalf("determineVersion() returned unexpected error: %v", err)
+	}
+	if u.factVersion != "2.0" {
+		t.Errorf("factVersion = %q; want %q", u.factVersion, "2.0")
+	}
+}
+
+func TestParseFactorioVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+		wantOK bool
+	}{
+		{"desktop build", "Version: 2.0.28 (build 80000, linux64, full, space-age)\nBinary version: 64\n", "2.0", true},
+		{
+			name:   "headless build",
+			output: "Version: 1.1.110 (build 60000, linux64, headless)\nBinary version: 64\nMap input version: 1.0.0-0\nMap output version: 1.1.110-0\n",
+			want:   "1.1", wantOK: true,
+		},
+		{"headless with CRLF line endings", "Version: 2.0.28 (build 80000, linux64, headless)\r\nBinary version: 64\r\n", "2.0", true},
+		{"log banner only", "   0.000 2026-01-02 03:04:05; Factorio 2.0.28 (build 80000, linux64, headless)\n", "2.0", true},
+		{"no version", "Segmentation fault\n", "", false},
+	}
 
-		err := u.ResolveMetadata()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseFactorioVersion(tt.output)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseFactorioVersion() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewUpdaterSharedHTTPClient(t *testing.T) {
+	shared := &http.Client{}
+	newUpdater := func(client *http.Client) *Updater {
+		t.Helper()
+		u, err := NewUpdater(Options{
+			ModPath:         t.TempDir(),
+			Username:        "user",
+			Token:           "token",
+			FactorioVersion: "2.0",
+			HTTPClient:      client,
+		})
 		if err != nil {
-			t.Fatalf("ResolveMetadata() returned unexpected error: %v", err)
+			t.Fatalf("NewUpdater() returned unexpected error: %v", err)
 		}
+		return u
+	}
 
-		if _, ok := u.mods["spacey-dep"]; !ok {
-			t.Error("expected whitespace-padded dependency 'spacey-dep' to be discovered after TrimSpace")
+	first, second := newUpdater(shared), newUpdater(shared)
+	if first.httpClient != shared || second.httpClient != shared {
+		t.Error("both updaters should use the injected client")
+	}
+
+	own1, own2 := newUpdater(nil), newUpdater(nil)
+	if own1.httpClient == nil || own1.httpClient == own2.httpClient {
+		t.Error("without an injected client each updater should get its own")
+	}
+}
+
+func TestNewUpdaterRejectsBadModPath(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "mod-list.json")
+	_ = os.WriteFile(file, []byte(`{"mods":[]}`), 0644)
+
+	tests := []struct {
+		name    string
+		modPath string
+		wantErr string
+	}{
+		{"file as mod path", file, "is a file, not a directory"},
+		{"missing mod path", filepath.Join(dir, "mods"), "does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewUpdater(Options{ModPath: tt.modPath, Username: "user", Token: "token", FactorioVersion: "2.0"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewUpdater() error = %v; want one containing %q", err, tt.wantErr)
+			}
+			if _, err := RewriteModList(Options{ModPath: tt.modPath}); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RewriteModList() error = %v; want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewUpdaterInitScaffoldsModDir(t *testing.T) {
+	modDir := filepath.Join(t.TempDir(), "factorio", "mods")
+	opts := Options{ModPath: modDir, Username: "user", Token: "token", FactorioVersion: "2.0", NoFsync: true, Init: true}
+
+	u, err := NewUpdater(opts)
+	if err != nil {
+		t.Fatalf("NewUpdater() with Init returned unexpected error: %v", err)
+	}
+	if info, err := os.Stat(modDir); err != nil || !info.IsDir() {
+		t.Fatalf("mods directory should be created: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(modDir, "mod-list.json"))
+	if err != nil {
+		t.Fatalf("mod-list.json should be created: %v", err)
+	}
+	var modList struct {
+		Mods []modListEntry `json:"mods"`
+	}
+	if err := json.Unmarshal(data, &modList); err != nil {
+		t.Fatalf("initial mod-list.json is not valid JSON: %v", err)
+	}
+	if want := []modListEntry{{Name: "base", Enabled: true}}; !slices.Equal(modList.Mods, want) {
+		t.Errorf("initial mods = %+v; want %+v", modList.Mods, want)
+	}
+	if len(u.mods) != 0 {
+		t.Errorf("a fresh install should track no mods, got %d", len(u.mods))
+	}
+
+	// An existing mod-list.json is left alone.
+	existing := []byte(`{"mods":[{"name":"base","enabled":true},{"name":"helmod","enabled":true}]}`)
+	_ = os.WriteFile(filepath.Join(modDir, "mod-list.json"), existing, 0644)
+	u, err = NewUpdater(opts)
+	if err != nil {
+		t.Fatalf("second NewUpdater() returned unexpected error: %v", err)
+	}
+	if _, ok := u.mods["helmod"]; !ok {
+		t.Error("Init should not overwrite an existing mod-list.json")
+	}
+}
+
+func TestNewUpdaterPreferVersion(t *testing.T) {
+	release := func(version, factorio string) ModRelease {
+		rel := ModRelease{Version: version}
+		rel.InfoJSON.FactorioVersion = factorio
+		return rel
+	}
+	releases := []ModRelease{release("1.5.0", "2.0"), release("2.0.0", "2.1"), release("3.0.0", "2.2")}
+
+	tests := []struct {
+		name       string
+		prefer     string
+		wantMatch  string
+		wantLatest string
+	}{
+		{"installed version", "", "2.0", "1.5.0"},
+		{"preferred version", "2.1", "2.1", "2.0.0"},
+		{"patch part is dropped", "2.1.7", "2.1", "2.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := NewUpdater(Options{ModPath: t.TempDir(), Username: "user", Token: "token", FactorioVersion: "2.0", PreferVersion: tt.prefer})
+			if err != nil {
+				t.Fatalf("NewUpdater() returned unexpected error: %v", err)
+			}
+			if u.FactorioVersion() != tt.wantMatch {
+				t.Errorf("FactorioVersion() = %q; want %q", u.FactorioVersion(), tt.wantMatch)
+			}
+			if rel := u.selectRelease(releases, ""); rel == nil || rel.Version != tt.wantLatest {
+				t.Errorf("selectRelease() = %+v; want %s", rel, tt.wantLatest)
+			}
+		})
+	}
+
+	if _, err := NewUpdater(Options{ModPath: t.TempDir(), Username: "user", Token: "token", FactorioVersion: "2.0", PreferVersion: "latest"}); err == nil {
+		t.Error("NewUpdater() should reject a malformed preferred version")
+	}
+}
+
+func TestDownloadLatestFromMirror(t *testing.T) {
+	content := []byte("mirrored release")
+	h := sha1.New()
+	h.Write(content)
+	officialHash := hex.EncodeToString(h.Sum(nil))
+
+	portal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("portal received %s; downloads should go to the mirror", r.URL)
+	}))
+	defer portal.Close()
+
+	var gotURL *url.URL
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL
+		_, _ = w.Write(content)
+	}))
+	defer mirror.Close()
+
+	tests := []struct {
+		name    string
+		sha1    string
+		wantErr bool
+	}{
+		{"official hash matches", officialHash, false},
+		{"tampered mirror copy", strings.Repeat("0", 40), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := parseMirrorURL(mirror.URL + "/")
+			if err != nil {
+				t.Fatalf("parseMirrorURL() returned unexpected error: %v", err)
+			}
+			modDir := t.TempDir()
+			u := &Updater{modServerURL: portal.URL, mirrorURL: base, modPath: modDir, username: "user", token: "secret",
+				httpClient: mirror.Client(), noFsync: true, mods: map[string]*ModData{
+					"helmod": {Name: "helmod", Title: "Helmod", Latest: &ModRelease{
+						Version: "2.2.12", FileName: "helmod_2.2.12.zip", DownloadURL: "/download/helmod/5f0c", Sha1: tt.sha1,
+					}},
+				}}
+
+			err = u.downloadLatest(context.Background(), "helmod", nil, u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadLatest() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if gotURL == nil || gotURL.Path != "/download/helmod/5f0c" {
+				t.Errorf("mirror request = %v; want the portal download path", gotURL)
+			}
+			if gotURL != nil && gotURL.Query().Has("token") {
+				t.Error("credentials must not be sent to the mirror")
+			}
+			_, statErr := os.Stat(filepath.Join(modDir, "helmod_2.2.12.zip"))
+			if !tt.wantErr && statErr != nil {
+				t.Errorf("release missing after download: %v", statErr)
+			}
+			if tt.wantErr && statErr == nil {
+				t.Error("a release failing hash validation should not be kept")
+			}
+		})
+	}
+}
+
+func TestParseMirrorURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"https://my-mirror/", "https://my-mirror", false},
+		{"http://10.0.0.5:8080/factorio/", "http://10.0.0.5:8080/factorio", false},
+		{"my-mirror", "", true},
+		{"ftp://my-mirror/", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseMirrorURL(tt.raw)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseMirrorURL(%q) = %q, %v; want %q, wantErr %v", tt.raw, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// upToDateUpdater returns an Updater tracking n installed mods whose zips
+// already match their latest release, so UpdateMods has nothing to fetch.
+func upToDateUpdater(tb testing.TB, n int) *Updater {
+	tb.Helper()
+	modDir := tb.TempDir()
+	u := &Updater{modPath: modDir, logLevel: LogQuiet, keepVersions: 1, mods: make(map[string]*ModData),
+		hashCache: &hashCache{entries: make(map[string]cachedHash)}}
+	for i := range n {
+		name := fmt.Sprintf("mod-%03d", i)
+		content := []byte(strings.Repeat(name, 16))
+		h := sha1.New()
+		h.Write(content)
+		fileName := name + "_1.0.0.zip"
+		_ = os.WriteFile(filepath.Join(modDir, fileName), content, 0644)
+		u.mods[name] = &ModData{Name: name, Title: name, Enabled: true, Installed: true, Version: "1.0.0",
+			Latest: &ModRelease{Version: "1.0.0", FileName: fileName, Sha1: hex.EncodeToString(h.Sum(nil))}}
+	}
+	if err := u.saveModList(); err != nil {
+		tb.Fatalf("saveModList() returned unexpected error: %v", err)
+	}
+	return u
+}
+
+func TestUpdateModsUpToDateLeavesModListAlone(t *testing.T) {
+	u := upToDateUpdater(t, 3)
+	u.mods["gone"] = &ModData{Name: "gone", Title: "gone", Enabled: true}
+	if err := u.saveModList(); err != nil {
+		t.Fatalf("saveModList() returned unexpected error: %v", err)
+	}
+	listPath := filepath.Join(u.modPath, "mod-list.json")
+	before, _ := os.ReadFile(listPath)
+	backups, _ := filepath.Glob(filepath.Join(u.modPath, "mod-list.*.json"))
+	for _, b := range backups {
+		_ = os.Remove(b)
+	}
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unexpected request", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	u.modServerURL, u.httpClient = server.URL, server.Client()
+	oldStart := startMultiPrinter
+	startMultiPrinter = func() (*pterm.MultiPrinter, error) {
+		t.Error("UpdateMods() started the download progress display, want the up-to-date fast path")
+		return oldStart()
+	}
+	t.Cleanup(func() { startMultiPrinter = oldStart })
+
+	result, err := u.UpdateMods(context.Background())
+	if n := requests.Load(); n != 0 {
+		t.Errorf("UpdateMods() sent %d requests, want no downloads", n)
+	}
+	for name, data := range u.mods {
+		if data.Latest == nil {
+			continue
 		}
-	})
+		if _, err := os.Stat(filepath.Join(u.modPath, data.Latest.FileName)); err != nil {
+			t.Errorf("current zip of %s is gone: %v", name, err)
+		}
+	}
+	if err == nil || !strings.Contains(err.Error(), `release missing for mod "gone"`) {
+		t.Errorf("UpdateMods() error = %v, want the missing release of gone reported", err)
+	}
+	if len(result.Updated) != 0 {
+		t.Errorf("UpdateMods() updated %v, want nothing", result.Updated)
+	}
+	after, _ := os.ReadFile(listPath)
+	if !bytes.Equal(before, after) {
+		t.Errorf("mod-list.json changed:\n%s\nwant:\n%s", after, before)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(u.modPath, "mod-list.*.json")); len(backups) != 0 {
+		t.Errorf("UpdateMods() left backups %v, want mod-list.json untouched", backups)
+	}
+
+	// A change to the tracked state is still written out.
+	u.mods["mod-000"].Enabled = false
+	if _, err := u.UpdateMods(context.Background()); err == nil {
+		t.Fatal("UpdateMods() returned nil error, want the missing release reported")
+	}
+	if after, _ := os.ReadFile(listPath); bytes.Equal(before, after) {
+		t.Error("mod-list.json was not rewritten after a mod was disabled")
+	}
+}
+
+func BenchmarkUpdateModsUpToDate(b *testing.B) {
+	u := upToDateUpdater(b, 150)
+	for b.Loop() {
+		if _, err := u.UpdateMods(context.Background()); err != nil {
+			b.Fatalf("UpdateMods() returned unexpected error: %v", err)
+		}
+	}
+}
+
+func TestUpdateModsFallsBackWhenProgressDisplayFails(t *testing.T) {
+	content := []byte("helmod release")
+	sum := sha1.Sum(content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	origStart, origRaw := startMultiPrinter, pterm.RawOutput
+	defer func() { startMultiPrinter, pterm.RawOutput = origStart, origRaw }()
+	pterm.RawOutput = false
+	var starts int
+	startMultiPrinter = func() (*pterm.MultiPrinter, error) {
+		starts++
+		return nil, errors.New("terminal does not support cursor movement")
+	}
+
+	modDir := t.TempDir()
+	u := &Updater{modServerURL: server.URL, modPath: modDir, httpClient: server.Client(), noFsync: true, logLevel: LogNormal,
+		mods: map[string]*ModData{"helmod": {Name: "helmod", Title: "Helmod", Enabled: true,
+			Latest: &ModRelease{Version: "1.1.0", FileName: "helmod_1.1.0.zip", DownloadURL: "/download/helmod", Sha1: hex.EncodeToString(sum[:])}}}}
+
+	result, err := u.UpdateMods(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateMods() returned unexpected error: %v", err)
+	}
+	if starts != 1 {
+		t.Errorf("startMultiPrinter called %d times; want 1", starts)
+	}
+	if len(result.Updated) != 1 {
+		t.Errorf("UpdateMods() updated %v; want helmod downloaded without the progress display", result.Updated)
+	}
+	if !strings.Contains(u.logBuf.String(), "progress display unavailable") {
+		t.Errorf("log = %q; want the fallback recorded", u.logBuf.String())
+	}
+}
+
+func TestWriteLogPrefixesRunID(t *testing.T) {
+	u := &Updater{}
+	u.WriteLog("Resolving mods")
+	u.factVersion = "2.0"
+	u.WriteLog("Downloaded %s (%s)", "helmod", "2.2.12")
+	u.WriteLog("mod-list.json changes: 1 added\n  + flib\n")
+	other := &Updater{}
+	other.WriteLog("unrelated")
+
+	id := u.RunID()
+	if len(id) != 8 || id == other.RunID() {
+		t.Fatalf("RunID() = %q (other run %q); want 8 hex digits unique to the run", id, other.RunID())
+	}
+	want := []string{
+		"[run " + id + "] Resolving mods",
+		"[run " + id + " factorio 2.0] Downloaded helmod (2.2.12)",
+		"[run " + id + " factorio 2.0] mod-list.json changes: 1 added",
+		"[run " + id + " factorio 2.0]   + flib",
+	}
+	if got := strings.Split(strings.TrimSuffix(u.logBuf.String(), "\n"), "\n"); !slices.Equal(got, want) {
+		t.Errorf("log lines = %q; want %q", got, want)
+	}
+
+	u.modPath = filepath.Join(t.TempDir(), "mods")
+	if err := u.SaveLog("All mods are up to date."); err != nil {
+		t.Fatalf("SaveLog() returned unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(filepath.Dir(u.modPath), "last-mod-update.log"))
+	if header, _, _ := strings.Cut(string(data), "\n"); !strings.Contains(header, "run "+id+", Factorio 2.0") {
+		t.Errorf("log header = %q; want the run ID and Factorio version", header)
+	}
+}
+
+func TestUpdateModsLogsInModOrder(t *testing.T) {
+	origRaw := pterm.RawOutput
+	defer func() { pterm.RawOutput = origRaw }()
+	pterm.RawOutput = true
+
+	// Each mod's download waits for the one sorted after it, so they
+	// complete in reverse order.
+	names := []string{"alpha", "bravo", "charlie"}
+	done := map[string]chan struct{}{}
+	for _, name := range names {
+		done[name] = make(chan struct{})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/download/")
+		if i := slices.Index(names, name); i < len(names)-1 {
+			<-done[names[i+1]]
+			time.Sleep(20 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(name))
+		close(done[name])
+	}))
+	defer server.Close()
+
+	u := &Updater{modServerURL: server.URL, modPath: t.TempDir(), httpClient: server.Client(), noFsync: true,
+		logLevel: LogQuiet, mods: make(map[string]*ModData)}
+	for _, name := range names {
+		sum := sha1.Sum([]byte(name))
+		u.mods[name] = &ModData{Name: name, Title: name, Enabled: true,
+			Latest: &ModRelease{Version: "1.0.0", FileName: name + "_1.0.0.zip", DownloadURL: "/download/" + name, Sha1: hex.EncodeToString(sum[:])}}
+	}
+
+	if _, err := u.UpdateMods(context.Background()); err != nil {
+		t.Fatalf("UpdateMods() returned unexpected error: %v", err)
+	}
+	var got []string
+	for _, line := range strings.Split(u.logBuf.String(), "\n") {
+		_, msg, _ := strings.Cut(line, "] ")
+		if strings.HasPrefix(msg, "Downloaded ") {
+			got = append(got, msg)
+		}
+	}
+	want := []string{"Downloaded alpha (1.0.0)", "Downloaded bravo (1.0.0)", "Downloaded charlie (1.0.0)"}
+	if !slices.Equal(got, want) {
+		t.Errorf("download log lines = %q; want %q", got, want)
+	}
 }
 
 // --- Integration tests below require a live Factorio installation at ~/factorio ---
@@ -962,13 +3663,12 @@ func TestNewUpdater(t *testing.T) {
 		t.Skip("no auth config found, skipping NewUpdater integration test")
 	}
 
-	updater, err := NewUpdater(
-		settingsPath,
-		playerData,
-		filepath.Join(root, "mods"),
-		filepath.Join(root, "bin", "x64", "factorio"),
-		"", "",
-	)
+	updater, err := NewUpdater(Options{
+		SettingsPath: settingsPath,
+		DataPath:     playerData,
+		ModPath:      filepath.Join(root, "mods"),
+		FactPath:     filepath.Join(root, "bin", "x64", "factorio"),
+	})
 	if err != nil {
 		t.Fatalf("NewUpdater() returned unexpected error: %v", err)
 	}
@@ -1008,13 +3708,12 @@ func TestRetrieveModMetadata(t *testing.T) {
 		playerData = ""
 	}
 
-	updater, err := NewUpdater(
-		settingsPath,
-		playerData,
-		filepath.Join(root, "mods"),
-		filepath.Join(root, "bin", "x64", "factorio"),
-		"", "",
-	)
+	updater, err := NewUpdater(Options{
+		SettingsPath: settingsPath,
+		DataPath:     playerData,
+		ModPath:      filepath.Join(root, "mods"),
+		FactPath:     filepath.Join(root, "bin", "x64", "factorio"),
+	})
 	if err != nil {
 		t.Fatalf("NewUpdater() failed: %v", err)
 	}
@@ -1029,7 +3728,7 @@ func TestRetrieveModMetadata(t *testing.T) {
 		}
 	}
 
-	if err := updater.RetrieveModMetadata(testMod); err != nil {
+	if err := updater.RetrieveModMetadata(context.Background(), testMod); err != nil {
 		t.Fatalf("RetrieveModMetadata(%q) returned unexpected error: %v", testMod, err)
 	}
 